@@ -1,6 +1,10 @@
+using System.Text.Json;
 using Microsoft.Extensions.Logging;
 using TokenTalk.Audio;
 using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+using TokenTalk.Integrations;
+using TokenTalk.Notifications;
 using TokenTalk.Overlay;
 using TokenTalk.Platform;
 using TokenTalk.PostProcessing;
@@ -17,13 +21,60 @@ public class Agent : IDisposable
     private readonly PostProcessingPipeline _pipeline;
     private readonly ClipboardService _clipboard;
     private readonly PasteService _paste;
+    private readonly OutputTargetWriter _targets;
     private readonly DictationRepository _repository;
+    private readonly FailedAudioStore _audioStore;
     private readonly DictationOverlay? _overlay;
     private readonly HotkeyListener _hotkeyListener;
     private readonly ILogger<Agent> _logger;
+    // Cancels dictations still processing. Separate from the run token so that quitting stops
+    // the hotkey at once but lets captured dictations finish; see DrainAsync
+    private readonly CancellationTokenSource _work = new();
+    private volatile bool _draining;
+    // 1 while the microphone is open; guards against double start/stop from hotkey and remote control
+    private int _recording;
+    // Incremented per recording, so a delayed announcement can tell whether its recording still runs
+    private int _recordingId;
+    // When the hold-mode hotkey went down and whether that press opened the microphone, to
+    // recognise accidental taps on release
+    private DateTime _pressedAt;
+    private bool _pressStartedRecording;
+    // Process focused when the open recording started, for AppLanguages
+    private volatile string _recordingApp = "";
+    // Text of the last successful paste, for the repaste-last action
+    private volatile string? _lastPastedText;
+    private DateTime _lastPastedAt;
+    // Text the undo-last action can still remove, and the window it went into; cleared once undone
+    private volatile string? _undoText;
+    private IntPtr _undoWindow;
+    private volatile bool _paused;
+    private volatile bool _incognito;
+    // Completes when the most recently captured dictation has pasted (or failed), so the next
+    // one can wait its turn; see QueueOptions.Policy
+    private Task _previousDictation = Task.CompletedTask;
+    private readonly object _orderLock = new();
+    // Dictations captured but not yet finished
+    private int _inFlight;
+    // Dictations collected in accumulate mode, waiting to be pasted together
+    private readonly List<string> _draft = [];
+    private readonly IDisposable? _overlaySubscription;
+    // Type-as-you-speak for the open recording, when Output.TypeWhileSpeaking applies
+    private LiveTypingSession? _live;
+    // Continuous mode: capture restarts after every segment the segmenter cuts at a pause
+    private volatile bool _continuous;
+    private readonly SpeechSegmenter _segmenter = new();
+    // 1 from the moment a segment is cut until capture of the next one has started
+    private int _cutting;
+    private readonly List<IDictationNotifier> _notifiers = [];
 
-    public event EventHandler<string>? StatusChanged;
+    public event EventHandler<bool>? PausedChanged;
+    public event EventHandler<bool>? ContinuousChanged;
+    public event EventHandler<bool>? IncognitoChanged;
     public event EventHandler<DictationCompletedEventArgs>? DictationCompleted;
+    // Raised with the full draft text whenever accumulate mode adds to, sends or clears it
+    public event EventHandler<string>? DraftChanged;
+    // Intermediate text while a dictation is processed: the raw transcript, then the text after each stage
+    public event EventHandler<TranscriptPreview>? TranscriptPreviewed;
 
     public Agent(
         ConfigManager configManager,
@@ -32,7 +83,9 @@ public class Agent : IDisposable
         PostProcessingPipeline pipeline,
         ClipboardService clipboard,
         PasteService paste,
+        OutputTargetWriter targets,
         DictationRepository repository,
+        FailedAudioStore audioStore,
         DictationOverlay? overlay,
         ILogger<Agent> logger)
     {
@@ -42,36 +95,82 @@ public class Agent : IDisposable
         _pipeline = pipeline;
         _clipboard = clipboard;
         _paste = paste;
+        _targets = targets;
         _repository = repository;
+        _audioStore = audioStore;
         _overlay = overlay;
         _hotkeyListener = new HotkeyListener();
         _logger = logger;
+        Bus = new AgentStatusBus(logger);
 
         if (_overlay != null)
+        {
             _recorder.AmplitudeAvailable += _overlay.PushAmplitude;
+            _overlaySubscription = Bus.Subscribe(UpdateOverlay);
+        }
+        _recorder.AmplitudeAvailable += OnAmplitude;
+        _recorder.DeviceFailed += OnDeviceFailed;
+        _recorder.DeviceFallback += OnDeviceFallback;
+        _configManager.Changed += OnConfigChanged;
+    }
+
+    // Provider, pipeline and audio settings are read per dictation; only the hotkey needs re-applying
+    private void OnConfigChanged(object? sender, TokenTalkOptions options)
+    {
+        // Traces hold transcripts; don't keep them around once developer mode is off
+        if (!options.DeveloperMode)
+            Traces.Clear();
+
+        var combo = options.PrimaryHotkey.Combo;
+        if (!_hotkeyListener.IsActive || combo == _hotkeyListener.Hotkey)
+            return;
+
+        if (!HotkeyListener.TryValidate(combo, out var error))
+        {
+            _logger.LogWarning("Keeping hotkey {Hotkey}, new value is invalid: {Error}", _hotkeyListener.Hotkey, error);
+            return;
+        }
+        _hotkeyListener.SetHotkey(combo);
+        _logger.LogInformation("Hotkey changed to {Hotkey}", combo);
     }
 
     public async Task RunAsync(CancellationToken ct)
     {
         var cfg = _configManager.Current;
 
-        _hotkeyListener.Start(cfg.Hotkey);
-        _logger.LogInformation("TokenTalk started. Hotkey: {Hotkey}, Provider: {Provider}",
-            cfg.Hotkey, _transcriptionProvider.Name);
-
-        SetStatus("idle");
+        _hotkeyListener.Start(cfg.PrimaryHotkey.Combo);
+        _logger.LogInformation("TokenTalk started. Hotkey: {Hotkey} ({Mode}), Provider: {Provider}",
+            cfg.PrimaryHotkey.Combo, cfg.PrimaryHotkey.Mode, _transcriptionProvider.Name);
 
         try
         {
             await foreach (var evt in _hotkeyListener.Events.ReadAllAsync(ct))
             {
+                // In toggle and continuous mode the release is ignored and the next press stops
+                var mode = _configManager.Current.PrimaryHotkey.Mode;
+                var toggle = mode == HotkeyModes.Toggle;
                 switch (evt.Type)
                 {
-                    case HotkeyEventType.Pressed:
+                    case HotkeyEventType.Pressed when mode == HotkeyModes.Continuous:
+                        SetContinuous(!_continuous);
+                        break;
+                    case HotkeyEventType.Pressed when toggle && IsRecording:
+                        StopRecording();
+                        break;
+                    case HotkeyEventType.Pressed when toggle:
                         HandleHotkeyPressed();
                         break;
-                    case HotkeyEventType.Released:
-                        _ = HandleHotkeyReleasedAsync(ct);
+                    case HotkeyEventType.Pressed:
+                        _pressedAt = DateTime.UtcNow;
+                        _pressStartedRecording = HandleHotkeyPressed(_configManager.Current.PrimaryHotkey.MinHoldMs);
+                        break;
+                    // Continuous dictation started remotely is not ended by a hold-mode release
+                    case HotkeyEventType.Released when mode == HotkeyModes.Hold && !_continuous:
+                        var held = DateTime.UtcNow - _pressedAt;
+                        if (_pressStartedRecording && held.TotalMilliseconds < _configManager.Current.PrimaryHotkey.MinHoldMs)
+                            DiscardTap(held);
+                        else
+                            _ = HandleHotkeyReleasedAsync(_work.Token);
                         break;
                 }
             }
@@ -82,24 +181,642 @@ public class Agent : IDisposable
         }
     }
 
-    private void HandleHotkeyPressed()
+    public AgentStatusBus Bus { get; }
+
+    // Pipeline traces of recent dictations, recorded while DeveloperMode is on
+    public FlightRecorder Traces { get; } = new();
+
+    public AgentState State => Bus.State;
+
+    public string ProviderName => _transcriptionProvider.Name;
+
+    public int InFlight => Volatile.Read(ref _inFlight);
+
+    public long AudioBufferBytes => _recorder.BufferedBytes;
+
+    /// <summary>
+    /// Set by the UI: shows a text for confirmation when <see cref="OutputOptions.Confirm"/> is on
+    /// and completes with the (possibly edited) text to paste, or null to discard it.
+    /// </summary>
+    public Func<string, CancellationToken, Task<string?>>? ConfirmPaste { get; set; }
+
+    public bool IsHotkeyActive => _hotkeyListener.IsActive;
+
+    public bool IsRecording => Volatile.Read(ref _recording) == 1;
+
+    public bool IsPaused => _paused;
+
+    public bool IsContinuous => _continuous;
+
+    public bool IsIncognito => _incognito;
+
+    /// <summary>
+    /// Incognito dictations are pasted as usual but never written to history, the log, traces or
+    /// notifiers, and their audio is wiped once transcribed. For passwords and sensitive messages;
+    /// <see cref="AppOutputOptions.Incognito"/> turns it on for specific apps.
+    /// </summary>
+    public void SetIncognito(bool on)
+    {
+        if (_incognito == on)
+            return;
+        _incognito = on;
+        _logger.LogInformation(on ? "Incognito dictation on" : "Incognito dictation off");
+        IncognitoChanged?.Invoke(this, on);
+    }
+
+    private static bool IsIncognitoApp(TokenTalkOptions cfg, string app) =>
+        cfg.Output.Apps.Any(a => a.Incognito && string.Equals(a.Process, app, StringComparison.OrdinalIgnoreCase));
+
+    /// <summary>
+    /// Pausing ignores the hotkey and remote start requests until resumed, e.g. while gaming or
+    /// screen-sharing. An open recording is discarded; a dictation already processing finishes.
+    /// </summary>
+    public void SetPaused(bool paused)
+    {
+        if (_paused == paused)
+            return;
+        _paused = paused;
+        if (paused)
+        {
+            CancelRecording();
+            _logger.LogInformation("Dictation paused");
+        }
+        else
+        {
+            _logger.LogInformation("Dictation resumed");
+        }
+        if (State is AgentState.Idle or AgentState.Error or AgentState.Paused)
+            SetState(AgentState.Idle);
+        PausedChanged?.Invoke(this, paused);
+    }
+
+    /// <summary>Starts recording as if the hotkey was pressed. Returns false if already recording.</summary>
+    public bool StartRecording() => HandleHotkeyPressed();
+
+    /// <summary>Stops recording and transcribes, as if the hotkey was released. Returns false if not recording.</summary>
+    public bool StopRecording()
+    {
+        if (!IsRecording)
+            return false;
+        EndContinuous();
+        _ = HandleHotkeyReleasedAsync(_work.Token);
+        return true;
+    }
+
+    /// <summary>
+    /// Called when the app quits: transcribes an open recording and waits up to
+    /// <paramref name="timeout"/> for every captured dictation to paste and reach history.
+    /// Whatever is still processing then is cancelled and saved as failed, with its audio.
+    /// </summary>
+    public async Task DrainAsync(TimeSpan timeout)
+    {
+        _draining = true;
+        StopRecording();
+
+        Task last;
+        lock (_orderLock)
+            last = _previousDictation;
+        var pending = Volatile.Read(ref _inFlight);
+        if (pending == 0)
+            return;
+
+        _logger.LogInformation("Waiting up to {Timeout}s for {Count} dictation(s) to finish", timeout.TotalSeconds, pending);
+        try
+        {
+            await last.WaitAsync(timeout);
+        }
+        catch (TimeoutException)
+        {
+            _logger.LogWarning("Dictations still processing after {Timeout}s, cancelling", timeout.TotalSeconds);
+            _work.Cancel();
+            // Cancelled dictations still write their history entry; give them a moment for that
+            try { await last.WaitAsync(TimeSpan.FromSeconds(2)); }
+            catch (TimeoutException) { }
+        }
+    }
+
+    /// <summary>
+    /// Switches hands-free dictation on or off. While on, the microphone stays open and each
+    /// pause after speech is transcribed and pasted as its own dictation; switching off
+    /// transcribes the last segment. Returns false if nothing changed or recording can't start.
+    /// </summary>
+    public bool SetContinuous(bool on)
+    {
+        if (!on)
+        {
+            if (!_continuous)
+                return false;
+            EndContinuous();
+            StopRecording();
+            return true;
+        }
+
+        if (_continuous || _paused)
+            return false;
+        _segmenter.Reset(DateTime.UtcNow);
+        Volatile.Write(ref _cutting, 0);
+        _continuous = true;
+        // An open hold/toggle recording simply becomes the first segment
+        if (!IsRecording && !HandleHotkeyPressed())
+        {
+            _continuous = false;
+            return false;
+        }
+        _logger.LogInformation("Continuous dictation started");
+        ContinuousChanged?.Invoke(this, true);
+        return true;
+    }
+
+    public bool ToggleRecording() => IsRecording ? StopRecording() : StartRecording();
+
+    /// <summary>Stops recording and discards the audio. Returns false if not recording.</summary>
+    public bool CancelRecording()
     {
+        EndContinuous();
+        if (Interlocked.Exchange(ref _recording, 0) == 0)
+            return false;
+        DiscardLiveTyping();
+
         try
         {
+            _recorder.Stop();
+            _logger.LogInformation("Recording cancelled");
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Failed to cancel recording");
+        }
+        finally
+        {
+            SetStateAfterRecording();
+        }
+        return true;
+    }
+
+    /// <summary>Pastes the last dictated text again. Returns false if nothing was dictated yet or a recording is open.</summary>
+    public async Task<bool> RepasteLastAsync(CancellationToken ct = default)
+    {
+        var text = _lastPastedText;
+        if (text == null || IsRecording)
+            return false;
+
+        try
+        {
+            await _paste.PasteTextAsync(text, ct);
+            RememberPasted(text);
+            _logger.LogInformation("Re-pasted last dictation ({Length} chars)", text.Length);
+            return true;
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogError(ex, "Failed to re-paste last dictation");
+            return false;
+        }
+    }
+
+    /// <summary>
+    /// Removes the text of the last paste from the focused window, with backspaces or the app's
+    /// own Ctrl+Z depending on <see cref="OutputOptions.UndoMode"/> and its per-app override.
+    /// Returns false when there is nothing to undo, a recording is open, or the text was only
+    /// copied to the clipboard.
+    /// </summary>
+    public async Task<bool> UndoLastAsync(CancellationToken ct = default)
+    {
+        var output = _configManager.Current.Output;
+        if (IsRecording || output.Mode == OutputModes.Clipboard)
+            return false;
+
+        // Wait for dictations ahead of us, so the undo hits the text it is meant to
+        Task previous;
+        var turn = new TaskCompletionSource(TaskCreationOptions.RunContinuationsAsynchronously);
+        lock (_orderLock)
+        {
+            previous = _previousDictation;
+            _previousDictation = turn.Task;
+        }
+
+        try
+        {
+            await previous.WaitAsync(ct);
+            var text = Interlocked.Exchange(ref _undoText, null);
+            if (string.IsNullOrEmpty(text))
+                return false;
+            // Keystrokes go to whatever has focus; never send them anywhere but the original window
+            if (!ForegroundApp.TryActivate(_undoWindow))
+            {
+                _logger.LogWarning("Not undoing: the window the dictation was pasted into can't be focused");
+                _undoText = text;
+                return false;
+            }
+            // Let the focus change settle before the keystrokes arrive
+            await Task.Delay(50, ct);
+
+            var app = ForegroundApp.GetProcessName();
+            var mode = output.Apps.FirstOrDefault(a => string.Equals(a.Process, app, StringComparison.OrdinalIgnoreCase))?.UndoMode
+                ?? output.UndoMode;
+            if (mode == UndoModes.CtrlZ)
+                TextTyper.Undo();
+            else
+                TextTyper.Backspace(TextTyper.BackspacesFor(text));
+            _logger.LogInformation("Undid last dictation ({Length} chars, {Mode})", text.Length, mode);
+            return true;
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogError(ex, "Failed to undo last dictation");
+            return false;
+        }
+        finally
+        {
+            turn.SetResult();
+        }
+    }
+
+    /// <summary>
+    /// Records the language the provider heard in place of "auto", and hands whatever language is
+    /// known to the pipeline so it picks that language's dictionary entries and voice commands.
+    /// </summary>
+    private void UseDetectedLanguage(Dictation dictation, string? detected)
+    {
+        if (detected != null)
+        {
+            if (dictation.Language != detected)
+                _logger.LogDebug("Detected language {Language}", detected);
+            dictation.Language = detected;
+        }
+        PostProcessingContext.Language = dictation.Language is "" or "auto" ? null : dictation.Language;
+    }
+
+    private static string? GetAppLanguage(TokenTalkOptions cfg, string app) =>
+        app.Length == 0
+            ? null
+            : cfg.AppLanguages.FirstOrDefault(a => string.Equals(a.Process, app, StringComparison.OrdinalIgnoreCase))?.Language;
+
+    // Only the primary hotkey is bound, so its override is the one that applies
+    // The config to dictate with: a snapshot carrying the hotkey's profile in place of the active
+    // one, without switching to it, or the current config when the hotkey has none
+    private TokenTalkOptions WithHotkeyProfile(HotkeyOptions hotkey)
+    {
+        var cfg = _configManager.Current;
+        if (hotkey.Profile.Length == 0 || string.Equals(hotkey.Profile, cfg.ActiveProfile, StringComparison.OrdinalIgnoreCase))
+            return cfg;
+        var snapshot = _configManager.Snapshot();
+        var profile = snapshot.Profiles.FirstOrDefault(p => string.Equals(p.Name, hotkey.Profile, StringComparison.OrdinalIgnoreCase));
+        if (profile == null)
+            return cfg;
+        snapshot.Transcription = profile.Transcription;
+        snapshot.PostProcessing = profile.PostProcessing;
+        snapshot.ActiveProfile = profile.Name;
+        return snapshot;
+    }
+
+    private static string GetOutputTarget(TokenTalkOptions cfg) =>
+        cfg.PrimaryHotkey.Target.Length > 0 ? cfg.PrimaryHotkey.Target : cfg.Output.Target;
+
+    // Copy-only mode puts nothing in the field to read back
+    private static bool ShouldVerify(OutputOptions output)
+    {
+        if (output.Mode == OutputModes.Clipboard)
+            return false;
+        var app = ForegroundApp.GetProcessName();
+        var overrides = output.Apps.FirstOrDefault(a => string.Equals(a.Process, app, StringComparison.OrdinalIgnoreCase));
+        return overrides?.Verify ?? output.Verify;
+    }
+
+    private async Task VerifyPasteAsync(Dictation dictation, string text, CancellationToken ct)
+    {
+        dictation.InjectionStatus = await PasteVerifier.VerifyAsync(text, ct);
+        if (dictation.InjectionStatus != InjectionStatuses.Failed)
+            return;
+
+        // Leave the text where the user can still paste it by hand
+        _logger.LogWarning("Pasted text did not appear in the focused field");
+        _clipboard.SetText(text);
+        Notify(n => n.OnError("The text didn't arrive in the focused field. It is on the clipboard, paste it with Ctrl+V.", null));
+    }
+
+    private bool IsRecentPaste(string text, int seconds) =>
+        seconds > 0
+        && DateTime.UtcNow - _lastPastedAt <= TimeSpan.FromSeconds(seconds)
+        && string.Equals(text.Trim(), _lastPastedText?.Trim(), StringComparison.OrdinalIgnoreCase);
+
+    private void RememberPasted(string text)
+    {
+        _lastPastedText = text;
+        _lastPastedAt = DateTime.UtcNow;
+        _undoWindow = ForegroundApp.GetWindow();
+        _undoText = text;
+    }
+
+    /// <summary>Registers a notifier; it only receives events while enabled in Notifications.Notifiers.</summary>
+    public void AddNotifier(IDictationNotifier notifier)
+    {
+        lock (_notifiers)
+            _notifiers.Add(notifier);
+    }
+
+    public bool CanRetry(long dictationId) => _audioStore.Exists(dictationId);
+
+    // Kept apart from the text, so the words are flagged in history but never pasted with markers
+    private static string? FlagLowConfidence(List<WordConfidence> words, double threshold)
+    {
+        if (threshold <= 0)
+            return null;
+        List<WordConfidence> flagged;
+        lock (words)
+            flagged = [.. words.Where(w => w.Confidence < threshold).Select(w => w with { Confidence = Math.Round(w.Confidence, 3) })];
+        return flagged.Count > 0 ? JsonSerializer.Serialize(flagged) : null;
+    }
+
+    /// <summary>
+    /// Transcribes and post-processes the saved audio of a failed dictation again, saving the
+    /// result as a new dictation linked through <see cref="Dictation.RetryOf"/>. With
+    /// <paramref name="paste"/> the text is also delivered like a normal dictation. Returns null
+    /// when no audio was kept for <paramref name="dictationId"/>.
+    /// </summary>
+    public async Task<Dictation?> RetryAsync(long dictationId, bool paste, CancellationToken ct = default)
+    {
+        var audio = _audioStore.Load(dictationId);
+        if (audio == null)
+            return null;
+
+        var cfg = _configManager.Current;
+        var started = DateTimeOffset.UtcNow;
+        var dictation = new Dictation
+        {
+            RecordingStartMs = started.ToUnixTimeMilliseconds(),
+            RecordingDurationMs = (long)audio.Duration.TotalMilliseconds,
+            AudioSizeBytes = audio.WavData.Length,
+            AudioSampleRate = audio.SampleRate,
+            Provider = _transcriptionProvider.Name,
+            Model = cfg.Transcription.Model,
+            Language = cfg.Transcription.Language,
+            Profile = cfg.ActiveProfile,
+            RetryOf = dictationId,
+        };
+
+        try
+        {
+            var words = TranscriptionContext.CollectWords();
+            var detected = TranscriptionContext.CollectLanguage();
+            var text = await _transcriptionProvider.TranscribeAsync(audio, ct);
+            dictation.TranscriptionLatencyMs = (long)(DateTimeOffset.UtcNow - started).TotalMilliseconds;
+            dictation.LowConfidence = FlagLowConfidence(words, cfg.Transcription.LowConfidenceThreshold);
+            UseDetectedLanguage(dictation, detected.Value);
+            if (string.IsNullOrWhiteSpace(text))
+            {
+                dictation.ErrorMessage = "Empty transcription";
+                dictation.ErrorCategory = ErrorCategories.EmptyTranscription;
+            }
+            else
+            {
+                dictation.RawText = text;
+                var result = await _pipeline.ProcessWithStagesAsync(text, ct);
+                var (processed, caretBack) = CursorMarker.Extract(result.Text);
+                dictation.TranscribedText = processed;
+                dictation.PipelineStages = JsonSerializer.Serialize(result.Stages);
+                dictation.WordCount = Dictation.CountWords(processed);
+                dictation.CharacterCount = processed.Length;
+
+                if (paste)
+                {
+                    var injectStart = DateTimeOffset.UtcNow;
+                    await _paste.PasteTextAsync(processed, ct, caretBack);
+                    RememberPasted(processed);
+                    dictation.InjectionLatencyMs = (long)(DateTimeOffset.UtcNow - injectStart).TotalMilliseconds;
+                }
+                dictation.Success = true;
+            }
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogError(ex, "Retry of dictation {Id} failed", dictationId);
+            dictation.ErrorMessage = ex.Message;
+            dictation.ErrorCategory = ErrorClassifier.Classify(ex);
+        }
+        dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - started).TotalMilliseconds;
+
+        await SaveDictationAsync(dictation, ct);
+        if (dictation.Success)
+        {
+            // The original stays in history as failed; its audio is no longer needed
+            _audioStore.Delete(dictationId);
+            _logger.LogInformation("Retried dictation {Id} as {NewId}", dictationId, dictation.Id);
+            DictationCompleted?.Invoke(this, new DictationCompletedEventArgs(dictation));
+            Notify(n => n.OnTranscription(dictation));
+        }
+        else
+        {
+            Notify(n => n.OnError(dictation.ErrorMessage ?? "Unknown error", dictation));
+        }
+        return dictation;
+    }
+
+    public string Draft
+    {
+        get { lock (_draft) return string.Join(" ", _draft); }
+    }
+
+    /// <summary>
+    /// Pastes the accumulated draft and empties it. Waits for dictations already in flight so
+    /// their text is included. Returns false if the draft is empty or the paste failed.
+    /// </summary>
+    public async Task<bool> SendDraftAsync(CancellationToken ct = default)
+    {
+        Task previous;
+        var turn = new TaskCompletionSource(TaskCreationOptions.RunContinuationsAsynchronously);
+        lock (_orderLock)
+        {
+            previous = _previousDictation;
+            _previousDictation = turn.Task;
+        }
+
+        try
+        {
+            await previous;
+            var (text, caretBack) = CursorMarker.Extract(Draft);
+            if (text.Length == 0)
+                return false;
+
+            await _paste.PasteTextAsync(text, ct, caretBack);
+            RememberPasted(text);
+            ClearDraft();
+            _logger.LogInformation("Sent draft ({Length} chars)", text.Length);
+            return true;
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogError(ex, "Failed to paste draft");
+            return false;
+        }
+        finally
+        {
+            turn.SetResult();
+        }
+    }
+
+    /// <summary>Discards the accumulated draft. Returns false if it was already empty.</summary>
+    public bool ClearDraft()
+    {
+        lock (_draft)
+        {
+            if (_draft.Count == 0)
+                return false;
+            _draft.Clear();
+        }
+        DraftChanged?.Invoke(this, "");
+        return true;
+    }
+
+    // Returns the whole draft when it should be pasted now, otherwise null
+    private string? AppendToDraft(string text, bool send)
+    {
+        string draft;
+        lock (_draft)
+        {
+            if (text.Length > 0)
+                _draft.Add(text);
+            draft = string.Join(" ", _draft);
+        }
+        DraftChanged?.Invoke(this, draft);
+        return send && draft.Length > 0 ? draft : null;
+    }
+
+    // "See you soon. Send it." → ("See you soon.", true); the phrase must be the last words
+    private static (string Text, bool Send) StripSendPhrase(string text, string phrase)
+    {
+        phrase = phrase.Trim();
+        if (phrase.Length == 0)
+            return (text, false);
+
+        var trimmed = text.TrimEnd(' ', '.', '!', ',', '\r', '\n');
+        if (!trimmed.EndsWith(phrase, StringComparison.OrdinalIgnoreCase))
+            return (text, false);
+        var start = trimmed.Length - phrase.Length;
+        if (start > 0 && char.IsLetterOrDigit(trimmed[start - 1]))
+            return (text, false);
+        return (trimmed[..start].TrimEnd(' ', ',', '\r', '\n'), true);
+    }
+
+    /// <param name="announceAfterMs">
+    /// Delays publishing the recording state and notifying, so a press that turns out to be an
+    /// accidental tap leaves no trace. The microphone opens immediately either way.
+    /// </param>
+    private bool HandleHotkeyPressed(int announceAfterMs = 0)
+    {
+        if (_paused)
+            return false;
+        if (_configManager.Current.Queue.Policy == QueuePolicies.Drop && Volatile.Read(ref _inFlight) > 0)
+        {
+            _logger.LogInformation("Previous dictation is still processing, ignoring new recording");
+            return false;
+        }
+        if (Interlocked.Exchange(ref _recording, 1) == 1)
+            return false;
+
+        try
+        {
+            _recordingApp = ForegroundApp.GetProcessName();
             _recorder.Start();
-            _overlay?.StartRecording();
-            _logger.LogInformation("Recording started");
-            SetStatus("recording");
+            var id = Interlocked.Increment(ref _recordingId);
+            if (announceAfterMs > 0)
+                _ = AnnounceRecordingAsync(id, announceAfterMs);
+            else
+                AnnounceRecording();
+            return true;
         }
         catch (Exception ex)
         {
+            Volatile.Write(ref _recording, 0);
             _logger.LogError(ex, "Failed to start recording");
-            SetStatus("idle");
+            SetState(AgentState.Error, ex.Message);
+            Notify(n => n.OnError($"Could not start recording: {ex.Message}", null));
+            return false;
         }
     }
 
-    private async Task HandleHotkeyReleasedAsync(CancellationToken ct)
+    private void AnnounceRecording()
+    {
+        _logger.LogInformation("Recording started");
+        SetState(AgentState.Recording);
+        Notify(n => n.OnRecordingStart());
+        StartLiveTyping();
+    }
+
+    // Typing is only safe when nothing else will paste into the field meanwhile
+    private void StartLiveTyping()
     {
+        var output = _configManager.Current.Output;
+        if (!output.TypeWhileSpeaking || output.Mode != OutputModes.Paste || output.Accumulate || output.Confirm
+            || GetOutputTarget(_configManager.Current) != OutputTargets.App
+            || _continuous || Volatile.Read(ref _inFlight) > 0)
+            return;
+
+        _live = new LiveTypingSession(_recorder, _transcriptionProvider,
+            TimeSpan.FromMilliseconds(output.LiveIntervalMs), () => _hotkeyListener.ModifiersDown,
+            GetAppLanguage(_configManager.Current, _recordingApp),
+            _incognito || _configManager.Current.PrimaryHotkey.Incognito || IsIncognitoApp(_configManager.Current, _recordingApp),
+            _logger, _work.Token);
+    }
+
+    private void DiscardLiveTyping()
+    {
+        var live = Interlocked.Exchange(ref _live, null);
+        if (live != null)
+            _ = EraseLiveTypingAsync(live);
+    }
+
+    // Removes provisional text of a recording that produced no dictation
+    private async Task EraseLiveTypingAsync(LiveTypingSession live)
+    {
+        try
+        {
+            await live.StopAsync();
+            await live.ReplaceAsync("", _work.Token);
+        }
+        catch (OperationCanceledException)
+        {
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "Failed to erase live text");
+        }
+    }
+
+    private async Task AnnounceRecordingAsync(int id, int delayMs)
+    {
+        await Task.Delay(delayMs);
+        if (IsRecording && Volatile.Read(ref _recordingId) == id)
+            AnnounceRecording();
+    }
+
+    // Closes a recording whose hotkey was only brushed, without transcribing or a state change
+    private void DiscardTap(TimeSpan held)
+    {
+        if (Interlocked.Exchange(ref _recording, 0) == 0)
+            return;
+
+        DiscardLiveTyping();
+        try
+        {
+            _recorder.Stop();
+            _logger.LogDebug("Ignored hotkey tap ({Duration}ms)", (int)held.TotalMilliseconds);
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Failed to stop recording");
+        }
+        // The announcement may already have gone out if the release was processed late
+        if (State == AgentState.Recording)
+            SetStateAfterRecording();
+    }
+
+    private async Task HandleHotkeyReleasedAsync(CancellationToken ct, bool nextSegment = false)
+    {
+        // A cancelled recording still sees the hotkey release; there is nothing left to stop
+        if (Interlocked.Exchange(ref _recording, 0) == 0)
+            return;
+
         _logger.LogInformation("Recording stopped, transcribing...");
         var recordingStart = DateTimeOffset.UtcNow;
 
@@ -107,24 +824,33 @@ public class Agent : IDisposable
         try
         {
             audio = _recorder.Stop();
-            _overlay?.StartProcessing();
         }
         catch (Exception ex)
         {
-            _overlay?.StopProcessing();
             _logger.LogError(ex, "Failed to stop recording");
-            SetStatus("idle");
+            EndContinuous();
+            DiscardLiveTyping();
+            SetState(AgentState.Error, ex.Message);
             return;
         }
 
-        var cfg = _configManager.Current;
+        var app = _recordingApp;
+        // Capture of the next segment starts before this one is processed, so nothing said is lost
+        if (nextSegment)
+            StartNextSegment();
+
+        var live = Interlocked.Exchange(ref _live, null);
+
+        var hotkey = _configManager.Current.PrimaryHotkey;
+        var cfg = WithHotkeyProfile(hotkey);
 
         // Validate duration
         if (AudioHelpers.IsTooShort(audio, TimeSpan.FromMilliseconds(100)))
         {
             _logger.LogWarning("Recording too short ({Duration}ms), ignoring", audio.Duration.TotalMilliseconds);
-            _overlay?.StopProcessing();
-            SetStatus("idle");
+            if (live != null)
+                await EraseLiveTypingAsync(live);
+            SetStateAfterRecording();
             return;
         }
 
@@ -132,12 +858,37 @@ public class Agent : IDisposable
         if (cfg.Audio.SilenceThreshold > 0 && AudioHelpers.IsSilent(audio, cfg.Audio.SilenceThreshold))
         {
             _logger.LogWarning("Recording too quiet, ignoring");
-            _overlay?.StopProcessing();
-            SetStatus("idle");
+            if (live != null)
+                await EraseLiveTypingAsync(live);
+            SetStateAfterRecording();
             return;
         }
 
-        SetStatus("processing");
+        // Take a place in line while still in capture order; the previous dictation's turn
+        // completes once it has pasted or failed
+        Task previous;
+        var turn = new TaskCompletionSource(TaskCreationOptions.RunContinuationsAsynchronously);
+        lock (_orderLock)
+        {
+            previous = _previousDictation;
+            _previousDictation = turn.Task;
+        }
+        Interlocked.Increment(ref _inFlight);
+        var parallel = cfg.Queue.Policy == QueuePolicies.Parallel;
+
+        // Only affects this dictation: the async-local values are restored when the method returns.
+        // A language on the hotkey is a deliberate choice and beats the per-app one
+        var language = hotkey.Language.Length > 0 ? hotkey.Language : GetAppLanguage(cfg, app);
+        TranscriptionContext.Language = language;
+        if (cfg.ActiveProfile != _configManager.Current.ActiveProfile)
+        {
+            TranscriptionContext.Options = cfg.Transcription;
+            PostProcessingContext.Options = cfg.PostProcessing;
+        }
+        if (language != null)
+            _logger.LogInformation("Transcribing in {Language} for {App}", language, app);
+
+        SetWorkState(AgentState.Transcribing);
 
         var dictation = new Dictation
         {
@@ -147,27 +898,69 @@ public class Agent : IDisposable
             AudioSampleRate = audio.SampleRate,
             Provider = _transcriptionProvider.Name,
             Model = cfg.Transcription.Model,
-            Language = cfg.Transcription.Language,
+            Language = language ?? cfg.Transcription.Language,
+            Profile = cfg.ActiveProfile,
+            App = app,
             Success = false,
+            Incognito = _incognito || hotkey.Incognito || IsIncognitoApp(cfg, app),
         };
+        TranscriptionContext.Incognito = dictation.Incognito;
+        // Tags every line logged for this dictation, from the providers and pipeline too, with the
+        // key its history entry is stored under
+        using var logScope = _logger.BeginScope(new Dictionary<string, object> { ["RecordingStartMs"] = dictation.RecordingStartMs });
+
+        // Providers without their own deadline could otherwise leave the dictation (and every
+        // one queued behind it) processing forever
+        // History writes use CancellationToken.None: a dictation cut short by shutdown is still recorded
+        using var watchdog = CancellationTokenSource.CreateLinkedTokenSource(ct);
+        var token = watchdog.Token;
+        bool TimedOut() => watchdog.IsCancellationRequested && !ct.IsCancellationRequested;
+        var timedOut = false;
+        var delivered = false;
+        var trace = cfg.DeveloperMode && !dictation.Incognito ? new List<TraceStep>() : null;
 
         try
         {
+            if (live != null)
+                await live.StopAsync();
+            // Queued dictations wait for the previous one to finish before transcribing
+            if (!parallel)
+                await previous;
+            if (cfg.Queue.TimeoutSeconds > 0)
+                watchdog.CancelAfter(TimeSpan.FromSeconds(cfg.Queue.TimeoutSeconds));
+
+            // Live typing has already transcribed the audio, so there is no upload left to save
+            if (live == null && !SpeechDetector.ContainsSpeech(audio, cfg.Audio.MinSpeechMs))
+            {
+                _logger.LogWarning("No speech detected in {Duration}, not transcribing", audio.Duration);
+                dictation.ErrorMessage = "No speech detected";
+                dictation.ErrorCategory = ErrorCategories.NoSpeech;
+                dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+                // The audio is kept like any failure, so a misjudged recording can still be retried
+                await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
+                return;
+            }
+
             // Transcribe
             var transcribeStart = DateTimeOffset.UtcNow;
+            var words = TranscriptionContext.CollectWords();
+            var detected = TranscriptionContext.CollectLanguage();
             string text;
             try
             {
-                text = await _transcriptionProvider.TranscribeAsync(audio, ct);
+                // WaitAsync abandons a provider call that ignores the token
+                text = await _transcriptionProvider.TranscribeAsync(audio, token).WaitAsync(token);
                 dictation.TranscriptionLatencyMs = (long)(DateTimeOffset.UtcNow - transcribeStart).TotalMilliseconds;
+                dictation.LowConfidence = FlagLowConfidence(words, cfg.Transcription.LowConfidenceThreshold);
+                UseDetectedLanguage(dictation, detected.Value);
             }
-            catch (Exception ex)
+            catch (Exception ex) when (!TimedOut())
             {
                 _logger.LogError(ex, "Transcription failed");
                 dictation.TranscriptionLatencyMs = (long)(DateTimeOffset.UtcNow - transcribeStart).TotalMilliseconds;
                 dictation.ErrorMessage = ex.Message;
-                await SaveDictationAsync(dictation, ct);
-                SetStatus("idle");
+                dictation.ErrorCategory = ErrorClassifier.Classify(ex);
+                await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
                 return;
             }
 
@@ -175,74 +968,297 @@ public class Agent : IDisposable
             {
                 _logger.LogWarning("Empty transcription");
                 dictation.ErrorMessage = "Empty transcription";
-                await SaveDictationAsync(dictation, ct);
-                SetStatus("idle");
+                dictation.ErrorCategory = ErrorCategories.EmptyTranscription;
+                await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
                 return;
             }
 
+            dictation.RawText = text;
             dictation.TranscribedText = text;
-            dictation.WordCount = text.Split(' ', StringSplitOptions.RemoveEmptyEntries).Length;
+            dictation.WordCount = Dictation.CountWords(text);
             dictation.CharacterCount = text.Length;
 
-            _logger.LogInformation("Transcribed: {Text} ({Duration})", text, audio.Duration);
+            if (dictation.Incognito)
+                _logger.LogInformation("Transcribed {Words} words ({Duration})", dictation.WordCount, audio.Duration);
+            else
+                _logger.LogInformation("Transcribed: {Text} ({Duration})", text, audio.Duration);
+            TranscriptPreviewed?.Invoke(this, new TranscriptPreview("Transcript", text));
 
             // Post-process
+            SetWorkState(AgentState.PostProcessing);
             var processed = text;
+            var pipelineStart = DateTimeOffset.UtcNow;
             try
             {
-                processed = await _pipeline.ProcessAsync(text, ct);
-                if (processed != text)
+                var result = await _pipeline.ProcessWithStagesAsync(text, token, (stage, stageText) =>
+                {
+                    TranscriptPreviewed?.Invoke(this, new TranscriptPreview(stage, stageText));
+                    trace?.Add(new TraceStep(stage, stageText, (long)(DateTimeOffset.UtcNow - pipelineStart).TotalMilliseconds));
+                }).WaitAsync(token);
+                processed = result.Text;
+                dictation.PipelineStages = JsonSerializer.Serialize(result.Stages);
+                if (processed != text && !dictation.Incognito)
                     _logger.LogInformation("Post-processed: {Original} → {Processed}", text, processed);
             }
-            catch (Exception ex)
+            catch (Exception ex) when (!TimedOut())
             {
                 _logger.LogWarning(ex, "Post-processing failed, using original text");
             }
 
-            // Inject text
-            var injectStart = DateTimeOffset.UtcNow;
-            try
+            // Cursor markers are only for the paste; history keeps the plain text
+            dictation.TranscribedText = CursorMarker.Strip(processed);
+            dictation.WordCount = Dictation.CountWords(dictation.TranscribedText);
+            dictation.CharacterCount = dictation.TranscribedText.Length;
+
+            // Paste strictly in capture order, even when transcriptions finish out of order
+            await previous;
+
+            // In accumulate mode the text joins the draft, which is only pasted on the send phrase
+            string? pasteText = processed;
+            if (cfg.Output.Accumulate)
             {
-                await _paste.PasteTextAsync(processed, ct);
-                dictation.InjectionLatencyMs = (long)(DateTimeOffset.UtcNow - injectStart).TotalMilliseconds;
+                var (part, send) = StripSendPhrase(processed, cfg.Output.SendPhrase);
+                dictation.TranscribedText = CursorMarker.Strip(part);
+                dictation.WordCount = Dictation.CountWords(dictation.TranscribedText);
+                dictation.CharacterCount = dictation.TranscribedText.Length;
+                pasteText = AppendToDraft(part, send);
             }
-            catch (Exception ex)
+
+            // Text typed while speaking is already in the field, so it is never held back
+            if (pasteText != null && live == null && IsRecentPaste(CursorMarker.Strip(pasteText), cfg.Output.DuplicateGuardSeconds)
+                && GetOutputTarget(cfg) == OutputTargets.App)
             {
-                _logger.LogError(ex, "Failed to inject text");
-                dictation.InjectionLatencyMs = (long)(DateTimeOffset.UtcNow - injectStart).TotalMilliseconds;
+                _logger.LogWarning("Dictation matches the paste {Seconds}s ago, not pasting it again",
+                    (int)(DateTime.UtcNow - _lastPastedAt).TotalSeconds);
+                Notify(n => n.OnError("Same text as the previous dictation, not pasted again. Use repaste-last to paste it anyway.", null));
+                dictation.ErrorMessage = "Not pasted, same as the previous paste";
+                dictation.ErrorCategory = ErrorCategories.Duplicate;
                 dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
-                dictation.ErrorMessage = ex.Message;
-                await SaveDictationAsync(dictation, ct);
-                SetStatus("idle");
+                await SaveDictationAsync(dictation, CancellationToken.None);
                 return;
             }
 
+            if (pasteText != null && cfg.Output.Confirm && ConfirmPaste != null)
+            {
+                var target = ForegroundApp.GetWindow();
+                // Time spent deciding is not processing time
+                watchdog.CancelAfter(Timeout.InfiniteTimeSpan);
+                // The UI is gone while quitting; keep the text in history rather than paste it unseen
+                var confirmed = _draining ? null : await ConfirmPaste(pasteText, ct);
+                if (confirmed == null && _draining)
+                {
+                    _logger.LogInformation("Dictation not pasted, TokenTalk is closing");
+                    dictation.ErrorMessage = "Not pasted, TokenTalk was closing";
+                    dictation.ErrorCategory = ErrorCategories.Other;
+                    dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+                    await SaveDictationAsync(dictation, CancellationToken.None);
+                    return;
+                }
+                if (confirmed == null && !cfg.Output.Accumulate)
+                {
+                    _logger.LogInformation("Dictation discarded before pasting");
+                    dictation.ErrorMessage = "Discarded before pasting";
+                    dictation.ErrorCategory = ErrorCategories.Discarded;
+                    dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+                    await SaveDictationAsync(dictation, CancellationToken.None);
+                    return;
+                }
+                // A discarded send keeps the draft as it was
+                if (confirmed != null && confirmed != pasteText && !cfg.Output.Accumulate)
+                {
+                    dictation.TranscribedText = confirmed;
+                    dictation.WordCount = Dictation.CountWords(confirmed);
+                    dictation.CharacterCount = confirmed.Length;
+                }
+                pasteText = confirmed;
+
+                // The popup had the focus; give it back to where the user was dictating
+                ForegroundApp.TryActivate(target);
+                await Task.Delay(50, ct);
+                if (cfg.Queue.TimeoutSeconds > 0)
+                    watchdog.CancelAfter(TimeSpan.FromSeconds(cfg.Queue.TimeoutSeconds));
+            }
+
+            if (pasteText != null)
+            {
+                // Inject text
+                SetWorkState(AgentState.Injecting);
+                var injectStart = DateTimeOffset.UtcNow;
+                try
+                {
+                    var outputTarget = GetOutputTarget(cfg);
+                    var (injected, caretBack) = CursorMarker.Extract(pasteText);
+                    if (outputTarget != OutputTargets.App)
+                        await _targets.WriteAsync(outputTarget, cfg.Output, injected, dictation, token);
+                    // Text typed while speaking is corrected in place instead of pasted again
+                    else if (live != null)
+                    {
+                        await live.ReplaceAsync(injected, token);
+                        TextTyper.MoveLeft(caretBack);
+                    }
+                    else
+                        await _paste.PasteTextAsync(injected, token, caretBack);
+                    delivered = true;
+                    // Undo-last and repaste act on the focused app; a journal entry isn't theirs to take back
+                    if (outputTarget == OutputTargets.App)
+                        RememberPasted(injected);
+                    dictation.InjectionLatencyMs = (long)(DateTimeOffset.UtcNow - injectStart).TotalMilliseconds;
+                    if (outputTarget == OutputTargets.App && live == null && ShouldVerify(cfg.Output))
+                        await VerifyPasteAsync(dictation, injected, token);
+                    if (cfg.Output.Accumulate)
+                        ClearDraft();
+                }
+                catch (Exception ex) when (!TimedOut())
+                {
+                    _logger.LogError(ex, "Failed to inject text");
+                    dictation.InjectionLatencyMs = (long)(DateTimeOffset.UtcNow - injectStart).TotalMilliseconds;
+                    dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+                    dictation.ErrorMessage = ex.Message;
+                    dictation.ErrorCategory = ErrorCategories.Injection;
+                    await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
+                    return;
+                }
+            }
+
             dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
             dictation.Success = true;
 
-            await SaveDictationAsync(dictation, ct);
-            DictationCompleted?.Invoke(this, new DictationCompletedEventArgs(dictation));
+            await SaveDictationAsync(dictation, CancellationToken.None);
+            if (!dictation.Incognito)
+            {
+                DictationCompleted?.Invoke(this, new DictationCompletedEventArgs(dictation));
+                Notify(n => n.OnTranscription(dictation));
+            }
         }
-        catch (OperationCanceledException)
+        catch (Exception) when (TimedOut())
+        {
+            _logger.LogWarning("Dictation timed out after {Timeout}s", cfg.Queue.TimeoutSeconds);
+            dictation.ErrorMessage = $"Timed out after {cfg.Queue.TimeoutSeconds} s";
+            dictation.ErrorCategory = ErrorCategories.Timeout;
+            dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+            await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
+            timedOut = true;
+        }
+        catch (OperationCanceledException) when (ct.IsCancellationRequested)
         {
-            throw;
+            // DrainAsync ran out of time; the audio is kept so the dictation can be retried
+            _logger.LogWarning("Dictation cancelled by shutdown");
+            dictation.ErrorMessage = "Cancelled by shutdown";
+            dictation.ErrorCategory = ErrorCategories.Other;
+            dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
+            await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
         }
         catch (Exception ex)
         {
             _logger.LogError(ex, "Unexpected error in dictation flow");
             dictation.ErrorMessage = ex.Message;
+            dictation.ErrorCategory = ErrorClassifier.Classify(ex);
             dictation.TotalLatencyMs = (long)(DateTimeOffset.UtcNow - recordingStart).TotalMilliseconds;
-            await SaveDictationAsync(dictation, ct);
+            await SaveFailedDictationAsync(dictation, CancellationToken.None, audio);
         }
         finally
         {
-            _overlay?.StopProcessing();
-            SetStatus("idle");
+            if (live != null && !delivered)
+                await EraseLiveTypingAsync(live);
+            if (trace != null)
+                Traces.Add(DictationTrace.From(dictation, app, trace));
+            if (dictation.Incognito)
+                Array.Clear(audio.WavData);
+            turn.SetResult();
+            // A failure leaves the agent in Error until the next recording; a timeout is
+            // reported but returns to idle so the stuck state clears
+            if (Interlocked.Decrement(ref _inFlight) == 0 && !IsRecording && (State != AgentState.Error || timedOut))
+                SetState(AgentState.Idle);
         }
     }
 
+    // The recorder reopens the device on the next start, so only the open recording is affected:
+    // what was captured before the failure is still transcribed
+    private void OnDeviceFailed(Exception ex)
+    {
+        _logger.LogError(ex, "Microphone failed during recording");
+        Notify(n => n.OnError($"Microphone stopped: {ex.Message}", null));
+        _ = Task.Run(StopRecording);
+    }
+
+    private void OnDeviceFallback(string reason)
+    {
+        _logger.LogWarning("{Reason}", reason);
+        Notify(n => n.OnError(reason, null));
+    }
+
+    // Runs on the capture thread for every 50 ms buffer; the cut itself happens off that thread
+    private void OnAmplitude(float amplitude)
+    {
+        if (!_continuous || !IsRecording)
+            return;
+
+        var cfg = _configManager.Current.Continuous;
+        var decision = _segmenter.Push(amplitude, DateTime.UtcNow, cfg.SpeechThreshold,
+            TimeSpan.FromMilliseconds(cfg.PauseMs), TimeSpan.FromSeconds(cfg.MaxSegmentSeconds));
+        if (decision == SegmentDecision.Continue || Interlocked.CompareExchange(ref _cutting, 1, 0) != 0)
+            return;
+
+        if (decision == SegmentDecision.Cut)
+            _ = Task.Run(() => HandleHotkeyReleasedAsync(_work.Token, nextSegment: true));
+        else
+            _ = Task.Run(DiscardSegment);
+    }
+
+    // Drops a segment that never contained speech, keeping the buffer from growing while idle
+    private void DiscardSegment()
+    {
+        if (Interlocked.Exchange(ref _recording, 0) == 0)
+            return;
+        try
+        {
+            _recorder.Stop();
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Failed to discard silent segment");
+        }
+        StartNextSegment();
+        // Continuous mode was switched off in the meantime
+        if (!IsRecording)
+            SetStateAfterRecording();
+    }
+
+    private void StartNextSegment()
+    {
+        if (!_continuous || Interlocked.Exchange(ref _recording, 1) == 1)
+            return;
+
+        try
+        {
+            _segmenter.Reset(DateTime.UtcNow);
+            _recordingApp = ForegroundApp.GetProcessName();
+            _recorder.Start();
+            Volatile.Write(ref _cutting, 0);
+        }
+        catch (Exception ex)
+        {
+            Volatile.Write(ref _recording, 0);
+            _logger.LogError(ex, "Failed to continue continuous dictation");
+            EndContinuous();
+            SetState(AgentState.Error, ex.Message);
+            Notify(n => n.OnError($"Continuous dictation stopped: {ex.Message}", null));
+        }
+    }
+
+    private void EndContinuous()
+    {
+        if (!_continuous)
+            return;
+        _continuous = false;
+        _logger.LogInformation("Continuous dictation stopped");
+        ContinuousChanged?.Invoke(this, false);
+    }
+
     private async Task SaveDictationAsync(Dictation dictation, CancellationToken ct)
     {
+        if (dictation.Incognito)
+            return;
         try
         {
             await _repository.SaveAsync(dictation, ct);
@@ -253,17 +1269,98 @@ public class Agent : IDisposable
         }
     }
 
-    private void SetStatus(string status)
+    private async Task SaveFailedDictationAsync(Dictation dictation, CancellationToken ct, AudioSegment? audio = null)
+    {
+        SetWorkState(AgentState.Error, dictation.ErrorMessage);
+        await SaveDictationAsync(dictation, ct);
+        if (audio != null && dictation.Id > 0)
+        {
+            try
+            {
+                _audioStore.Save(dictation.Id, audio);
+            }
+            catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+            {
+                _logger.LogWarning(ex, "Failed to keep audio of dictation {Id} for retry", dictation.Id);
+            }
+        }
+        // Notifiers get the error but not the dictation, whose raw text they might forward
+        Notify(n => n.OnError(dictation.ErrorMessage ?? "Unknown error", dictation.Incognito ? null : dictation));
+    }
+
+    // A failing notifier is logged and skipped; it never affects the dictation
+    private void Notify(Action<IDictationNotifier> call)
+    {
+        var enabled = _configManager.Current.Notifications.Notifiers;
+        IDictationNotifier[] notifiers;
+        lock (_notifiers)
+            notifiers = [.. _notifiers.Where(n => enabled.Contains(n.Name, StringComparer.OrdinalIgnoreCase))];
+
+        foreach (var notifier in notifiers)
+        {
+            try
+            {
+                call(notifier);
+            }
+            catch (Exception ex)
+            {
+                _logger.LogWarning(ex, "Notifier {Notifier} failed", notifier.Name);
+            }
+        }
+    }
+
+    private void SetState(AgentState state, string? error = null)
     {
-        StatusChanged?.Invoke(this, status);
+        if (state == AgentState.Idle && _paused)
+            state = AgentState.Paused;
+        Bus.Publish(state, error);
+    }
+
+    // A discarded recording returns to idle, or back to showing the dictations still queued
+    private void SetStateAfterRecording()
+    {
+        // The next continuous segment is already recording
+        if (IsRecording)
+            return;
+        SetState(Volatile.Read(ref _inFlight) > 0 ? AgentState.Transcribing : AgentState.Idle);
+    }
+
+    // A queued dictation must not hide a recording that is in progress
+    private void SetWorkState(AgentState state, string? error = null)
+    {
+        if (!IsRecording)
+            SetState(state, error);
+    }
+
+    private void UpdateOverlay(AgentStateChange change)
+    {
+        switch (change.To)
+        {
+            case AgentState.Recording:
+                _overlay!.StartRecording();
+                break;
+            case AgentState.Transcribing:
+                _overlay!.StartProcessing();
+                break;
+            case AgentState.Idle or AgentState.Error or AgentState.Paused:
+                _overlay!.StopProcessing();
+                break;
+        }
     }
 
     public void Dispose()
     {
+        _configManager.Changed -= OnConfigChanged;
+        _overlaySubscription?.Dispose();
+        _recorder.AmplitudeAvailable -= OnAmplitude;
+        _recorder.DeviceFailed -= OnDeviceFailed;
+        _recorder.DeviceFallback -= OnDeviceFallback;
         if (_overlay != null)
             _recorder.AmplitudeAvailable -= _overlay.PushAmplitude;
         _hotkeyListener.Dispose();
         _recorder.Dispose();
+        _work.Cancel();
+        _work.Dispose();
     }
 }
 
@@ -272,3 +1369,5 @@ public sealed class DictationCompletedEventArgs : EventArgs
     public Dictation Dictation { get; }
     public DictationCompletedEventArgs(Dictation d) => Dictation = d;
 }
+
+public record TranscriptPreview(string Stage, string Text);
@@ -0,0 +1,115 @@
+using Microsoft.Extensions.Logging;
+
+namespace TokenTalk;
+
+public enum AgentState
+{
+    Idle,
+    Recording,
+    Transcribing,
+    PostProcessing,
+    Injecting,
+    Error,
+    Paused,
+}
+
+/// <param name="Duration">How long the agent spent in <paramref name="From"/>.</param>
+/// <param name="Error">Set when <paramref name="To"/> is <see cref="AgentState.Error"/>.</param>
+public record AgentStateChange(AgentState From, AgentState To, DateTimeOffset At, TimeSpan Duration, string? Error);
+
+/// <summary>
+/// The agent's state machine and the one place its state is published. A dictation moves
+/// idle → recording → transcribing → post-processing → injecting → idle, or to error from any
+/// working state. Subscribers (UI, overlay, control pipe) get every change with its timestamp
+/// and the time spent in the previous state.
+/// </summary>
+public sealed class AgentStatusBus
+{
+    private static readonly Dictionary<AgentState, AgentState[]> Transitions = new()
+    {
+        [AgentState.Idle] = [AgentState.Recording, AgentState.Error, AgentState.Paused],
+        [AgentState.Recording] = [AgentState.Transcribing, AgentState.Idle, AgentState.Error, AgentState.Paused],
+        // A new recording may start while an earlier dictation is still being processed
+        [AgentState.Transcribing] = [AgentState.PostProcessing, AgentState.Error, AgentState.Recording],
+        [AgentState.PostProcessing] = [AgentState.Injecting, AgentState.Error, AgentState.Recording],
+        [AgentState.Injecting] = [AgentState.Idle, AgentState.Error, AgentState.Paused, AgentState.Recording, AgentState.Transcribing],
+        [AgentState.Error] = [AgentState.Idle, AgentState.Recording, AgentState.Paused],
+        [AgentState.Paused] = [AgentState.Idle],
+    };
+
+    private readonly object _lock = new();
+    private readonly List<Action<AgentStateChange>> _subscribers = [];
+    private readonly Dictionary<AgentState, TimeSpan> _lastDurations = [];
+    private readonly ILogger _logger;
+
+    public AgentStatusBus(ILogger logger)
+    {
+        _logger = logger;
+    }
+
+    public AgentState State { get; private set; } = AgentState.Idle;
+    public DateTimeOffset Since { get; private set; } = DateTimeOffset.UtcNow;
+    // Message of the most recent failure; kept after the agent returns to idle
+    public string? LastError { get; private set; }
+
+    /// <summary>How long the agent spent in each state the last time it left it.</summary>
+    public IReadOnlyDictionary<AgentState, TimeSpan> LastDurations
+    {
+        get { lock (_lock) return new Dictionary<AgentState, TimeSpan>(_lastDurations); }
+    }
+
+    /// <summary>
+    /// Moves to <paramref name="next"/> and notifies subscribers. Transitions outside the state
+    /// machine are still applied, so the published state never lags reality, but are logged.
+    /// </summary>
+    public void Publish(AgentState next, string? error = null)
+    {
+        AgentStateChange change;
+        Action<AgentStateChange>[] subscribers;
+        lock (_lock)
+        {
+            if (next == State)
+                return;
+            if (!Transitions[State].Contains(next))
+                _logger.LogDebug("Unexpected state transition {From} → {To}", State, next);
+
+            var now = DateTimeOffset.UtcNow;
+            change = new AgentStateChange(State, next, now, now - Since, error);
+            _lastDurations[State] = change.Duration;
+            State = next;
+            Since = now;
+            if (next == AgentState.Error)
+                LastError = error;
+            subscribers = [.. _subscribers];
+        }
+
+        foreach (var subscriber in subscribers)
+        {
+            try
+            {
+                subscriber(change);
+            }
+            catch (Exception ex)
+            {
+                _logger.LogError(ex, "State subscriber failed on {From} → {To}", change.From, change.To);
+            }
+        }
+    }
+
+    /// <summary>Calls <paramref name="handler"/> on the publishing thread for every change until disposed.</summary>
+    public IDisposable Subscribe(Action<AgentStateChange> handler)
+    {
+        lock (_lock)
+            _subscribers.Add(handler);
+        return new Subscription(this, handler);
+    }
+
+    private sealed class Subscription(AgentStatusBus bus, Action<AgentStateChange> handler) : IDisposable
+    {
+        public void Dispose()
+        {
+            lock (bus._lock)
+                bus._subscribers.Remove(handler);
+        }
+    }
+}
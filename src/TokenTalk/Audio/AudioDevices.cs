@@ -0,0 +1,43 @@
+using NAudio.CoreAudioApi;
+using NAudio.Wave;
+
+namespace TokenTalk.Audio;
+
+/// <param name="Index">Value for <c>Audio.DeviceIndex</c>.</param>
+/// <param name="IsDefault">Whether Windows currently routes the default input (index -1) here.</param>
+public record AudioDevice(int Index, string Name, bool IsDefault);
+
+public static class AudioDevices
+{
+    /// <summary>Capture devices in the numbering <see cref="AudioRecorder"/> uses.</summary>
+    public static List<AudioDevice> List()
+    {
+        var defaultName = GetDefaultName();
+        var devices = new List<AudioDevice>();
+        for (var i = 0; i < WaveIn.DeviceCount; i++)
+        {
+            var name = WaveIn.GetCapabilities(i).ProductName;
+            // WinMM cuts product names to 31 characters, so compare the prefix
+            var isDefault = defaultName != null && name.Length > 0 && defaultName.StartsWith(name, StringComparison.Ordinal);
+            devices.Add(new AudioDevice(i, name, isDefault));
+        }
+        return devices;
+    }
+
+    // WinMM doesn't say which device is the default; Core Audio does, by its full name
+    private static string? GetDefaultName()
+    {
+        try
+        {
+            using var enumerator = new MMDeviceEnumerator();
+            if (!enumerator.HasDefaultAudioEndpoint(DataFlow.Capture, Role.Console))
+                return null;
+            using var device = enumerator.GetDefaultAudioEndpoint(DataFlow.Capture, Role.Console);
+            return device.FriendlyName;
+        }
+        catch (Exception)
+        {
+            return null;
+        }
+    }
+}
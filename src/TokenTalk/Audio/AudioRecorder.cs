@@ -6,29 +6,48 @@ public record AudioSegment(byte[] WavData, int SampleRate, TimeSpan Duration);
 
 public class AudioRecorder : IDisposable
 {
-    private readonly int _deviceIndex;
+    // WAVE_MAPPER: whatever Windows currently uses as the default input
+    public const int DefaultDevice = -1;
+
+    private readonly Func<int> _getDeviceIndex;
     private readonly int _maxSeconds;
     private const int SampleRate = 16000;
     private const int BitsPerSample = 16;
     private const int Channels = 1;
+    // Buffers arrive every 50 ms; this long without one means the device is gone
+    private static readonly TimeSpan StallTimeout = TimeSpan.FromSeconds(3);
 
     public event Action<float>? AmplitudeAvailable;
+    // The open recording lost its device (unplugged, driver reset, or no more data). The audio
+    // captured so far is still returned by Stop()
+    public event Action<Exception>? DeviceFailed;
+    // Start had to fall back to the default device; the argument describes why
+    public event Action<string>? DeviceFallback;
 
     private WaveInEvent? _waveIn;
     private MemoryStream? _buffer;
     private WaveFileWriter? _writer;
     private DateTime _startTime;
+    private DateTime _lastData;
     private bool _recording;
+    private bool _failed;
+    private readonly System.Threading.Timer _stallTimer;
     private readonly object _lock = new();
 
-    public AudioRecorder(int deviceIndex, int maxSeconds)
+    public AudioRecorder(Func<int> getDeviceIndex, int maxSeconds)
     {
-        _deviceIndex = deviceIndex;
+        _getDeviceIndex = getDeviceIndex;
         _maxSeconds = maxSeconds;
+        _stallTimer = new System.Threading.Timer(_ => CheckStalled());
     }
 
+    /// <summary>
+    /// Opens the configured device, retrying once and then falling back to the default device,
+    /// so a device that was unplugged or reset doesn't require a restart.
+    /// </summary>
     public void Start()
     {
+        string? fallback = null;
         lock (_lock)
         {
             if (_recording)
@@ -37,22 +56,84 @@ public class AudioRecorder : IDisposable
             _buffer = new MemoryStream();
             var format = new WaveFormat(SampleRate, BitsPerSample, Channels);
             _writer = new WaveFileWriter(_buffer, format);
+            _startTime = DateTime.UtcNow;
+            _lastData = _startTime;
+            _failed = false;
+            // Set before opening: the first buffer can arrive before StartRecording returns
+            _recording = true;
 
-            _waveIn = new WaveInEvent
+            var index = _getDeviceIndex();
+            try
             {
-                DeviceNumber = _deviceIndex,
-                WaveFormat = format,
-                BufferMilliseconds = 50
-            };
-
-            _waveIn.DataAvailable += OnDataAvailable;
+                _waveIn = Open(index, format);
+            }
+            catch (Exception first)
+            {
+                try
+                {
+                    // A fresh handle often works after a driver reset
+                    Thread.Sleep(200);
+                    _waveIn = Open(index, format);
+                }
+                catch when (index != DefaultDevice)
+                {
+                    try
+                    {
+                        _waveIn = Open(DefaultDevice, format);
+                        fallback = $"Microphone {index} is unavailable ({first.Message}); using the default device";
+                    }
+                    catch
+                    {
+                        Reset();
+                        throw;
+                    }
+                }
+                catch
+                {
+                    Reset();
+                    throw;
+                }
+            }
+            _stallTimer.Change(TimeSpan.FromSeconds(1), TimeSpan.FromSeconds(1));
+        }
+        if (fallback != null)
+            DeviceFallback?.Invoke(fallback);
+    }
 
-            _startTime = DateTime.UtcNow;
-            _recording = true;
-            _waveIn.StartRecording();
+    private WaveInEvent Open(int index, WaveFormat format)
+    {
+        var waveIn = new WaveInEvent
+        {
+            DeviceNumber = index,
+            WaveFormat = format,
+            BufferMilliseconds = 50
+        };
+        waveIn.DataAvailable += OnDataAvailable;
+        waveIn.RecordingStopped += OnRecordingStopped;
+        try
+        {
+            waveIn.StartRecording();
+            return waveIn;
+        }
+        catch
+        {
+            waveIn.DataAvailable -= OnDataAvailable;
+            waveIn.RecordingStopped -= OnRecordingStopped;
+            waveIn.Dispose();
+            throw;
         }
     }
 
+    // Clears a half-started recording
+    private void Reset()
+    {
+        _recording = false;
+        _writer?.Dispose();
+        _writer = null;
+        _buffer?.Dispose();
+        _buffer = null;
+    }
+
     private static float ComputeNormalizedRms(byte[] buffer, int bytesRecorded)
     {
         if (bytesRecorded < 2)
@@ -79,6 +160,7 @@ public class AudioRecorder : IDisposable
                 return;
 
             _writer.Write(e.Buffer, 0, e.BytesRecorded);
+            _lastData = DateTime.UtcNow;
 
             amplitude = ComputeNormalizedRms(e.Buffer, e.BytesRecorded);
             handler = AmplitudeAvailable;
@@ -92,6 +174,57 @@ public class AudioRecorder : IDisposable
         handler?.Invoke(amplitude);
     }
 
+    // Raised by NAudio with an exception when the device disappears mid-recording
+    private void OnRecordingStopped(object? sender, StoppedEventArgs e)
+    {
+        if (e.Exception != null)
+            Fail(e.Exception);
+    }
+
+    private void CheckStalled()
+    {
+        bool stalled;
+        lock (_lock)
+            stalled = _recording && DateTime.UtcNow - _lastData > StallTimeout;
+        if (stalled)
+            Fail(new IOException($"The microphone delivered no audio for {StallTimeout.TotalSeconds:0} seconds"));
+    }
+
+    private void Fail(Exception ex)
+    {
+        lock (_lock)
+        {
+            if (!_recording || _failed)
+                return;
+            _failed = true;
+        }
+        DeviceFailed?.Invoke(ex);
+    }
+
+    /// <summary>Size of the WAV held for the open recording; 0 when not recording.</summary>
+    public long BufferedBytes
+    {
+        get
+        {
+            lock (_lock)
+                return _buffer?.Length ?? 0;
+        }
+    }
+
+    /// <summary>The audio captured so far, as a complete WAV, without stopping. Null when not recording.</summary>
+    public AudioSegment? Snapshot()
+    {
+        lock (_lock)
+        {
+            if (!_recording || _writer == null || _buffer == null)
+                return null;
+
+            // Flush also rewrites the RIFF header sizes, so the copy is a valid file
+            _writer.Flush();
+            return new AudioSegment(_buffer.ToArray(), SampleRate, DateTime.UtcNow - _startTime);
+        }
+    }
+
     public AudioSegment Stop()
     {
         lock (_lock)
@@ -100,8 +233,17 @@ public class AudioRecorder : IDisposable
                 return new AudioSegment([], SampleRate, TimeSpan.Zero);
 
             _recording = false;
-            _waveIn.StopRecording();
+            _stallTimer.Change(Timeout.Infinite, Timeout.Infinite);
             _waveIn.DataAvailable -= OnDataAvailable;
+            _waveIn.RecordingStopped -= OnRecordingStopped;
+            try
+            {
+                _waveIn.StopRecording();
+            }
+            catch (Exception) when (_failed)
+            {
+                // The device is already gone; keep what was captured
+            }
             _waveIn.Dispose();
             _waveIn = null;
 
@@ -111,6 +253,9 @@ public class AudioRecorder : IDisposable
 
             var duration = DateTime.UtcNow - _startTime;
             var wavData = _buffer.ToArray();
+            // Don't leave a copy of the audio behind in memory; incognito dictations rely on it
+            if (_buffer.TryGetBuffer(out var captured))
+                Array.Clear(captured.Array!);
             _buffer.Dispose();
             _buffer = null;
 
@@ -122,6 +267,7 @@ public class AudioRecorder : IDisposable
     {
         lock (_lock)
         {
+            _stallTimer.Dispose();
             if (_recording)
             {
                 _waveIn?.StopRecording();
@@ -0,0 +1,74 @@
+using NAudio.Wave;
+
+namespace TokenTalk.Audio;
+
+/// <summary>
+/// Keeps the WAV of failed dictations as <c>audio\{id}.wav</c> in the data directory so they
+/// can be retried from history. Successful dictations are never written; only the newest
+/// <see cref="MaxFiles"/> recordings are kept.
+/// </summary>
+public class FailedAudioStore
+{
+    public const int MaxFiles = 50;
+
+    private readonly string _directory;
+
+    public FailedAudioStore(string directory)
+    {
+        _directory = directory;
+    }
+
+    public void Save(long dictationId, AudioSegment audio)
+    {
+        Directory.CreateDirectory(_directory);
+        File.WriteAllBytes(GetPath(dictationId), audio.WavData);
+
+        foreach (var old in new DirectoryInfo(_directory).GetFiles("*.wav")
+                     .OrderByDescending(f => f.LastWriteTimeUtc)
+                     .Skip(MaxFiles))
+        {
+            try { old.Delete(); }
+            catch (IOException) { /* in use; next save tries again */ }
+        }
+    }
+
+    public bool Exists(long dictationId) => File.Exists(GetPath(dictationId));
+
+    public AudioSegment? Load(long dictationId)
+    {
+        var path = GetPath(dictationId);
+        if (!File.Exists(path))
+            return null;
+
+        var wav = File.ReadAllBytes(path);
+        using var reader = new WaveFileReader(new MemoryStream(wav));
+        return new AudioSegment(wav, reader.WaveFormat.SampleRate, reader.TotalTime);
+    }
+
+    public void Delete(long dictationId)
+    {
+        try { File.Delete(GetPath(dictationId)); }
+        catch (IOException) { /* ignore */ }
+    }
+
+    /// <summary>Deletes every kept recording; returns how many were removed.</summary>
+    public int DeleteAll()
+    {
+        if (!Directory.Exists(_directory))
+            return 0;
+
+        var removed = 0;
+        foreach (var file in new DirectoryInfo(_directory).GetFiles("*.wav"))
+        {
+            try
+            {
+                file.Delete();
+                removed++;
+            }
+            catch (IOException) { /* in use by a retry */ }
+        }
+        return removed;
+    }
+
+    private string GetPath(long dictationId) => Path.Combine(_directory, $"{dictationId}.wav");
+}
@@ -0,0 +1,79 @@
+namespace TokenTalk.Audio;
+
+/// <summary>
+/// Cheap local check for whether a recording contains speech at all, run before it is sent to
+/// a provider. Whisper given keyboard clatter or room noise tends to invent text ("Thank you.",
+/// "Thanks for watching!"), and the upload is paid for either way.
+/// </summary>
+/// <remarks>
+/// The audio is cut into 30 ms frames. A frame counts as voiced when it is well above the
+/// recording's own noise floor and its zero-crossing rate is in the range of voiced speech
+/// rather than hiss or clicks; voiced frames only count in runs of at least 90 ms, which
+/// leaves out the isolated transients of typing. Sustained tonal sound such as music can
+/// still pass, so this trims the obvious cases rather than replacing the provider's judgment.
+/// </remarks>
+public static class SpeechDetector
+{
+    private const int HeaderSize = 44;
+    private const double FrameSeconds = 0.03;
+    private const int MinRunFrames = 3;
+    // Frames this far above the quietest tenth of the recording stand out from the background
+    private const double FloorFactor = 3.0;
+    // Below this RMS nothing is voiced, however quiet the background
+    private const double MinEnergy = 100;
+    private const double MinZeroCrossingRate = 0.02;
+    private const double MaxZeroCrossingRate = 0.35;
+
+    /// <summary>Milliseconds of the recording that look like speech.</summary>
+    public static int MeasureSpeechMs(AudioSegment segment)
+    {
+        var data = segment.WavData;
+        var frameSamples = (int)(segment.SampleRate * FrameSeconds);
+        var sampleCount = (data.Length - HeaderSize) / 2;
+        if (frameSamples <= 0 || sampleCount < frameSamples)
+            return 0;
+
+        var frameCount = sampleCount / frameSamples;
+        var energies = new double[frameCount];
+        var zeroCrossings = new double[frameCount];
+        for (int f = 0; f < frameCount; f++)
+        {
+            double sumSquares = 0;
+            int crossings = 0;
+            short previous = 0;
+            for (int i = 0; i < frameSamples; i++)
+            {
+                var sample = BitConverter.ToInt16(data, HeaderSize + (f * frameSamples + i) * 2);
+                sumSquares += (double)sample * sample;
+                if (i > 0 && (sample >= 0) != (previous >= 0))
+                    crossings++;
+                previous = sample;
+            }
+            energies[f] = Math.Sqrt(sumSquares / frameSamples);
+            zeroCrossings[f] = (double)crossings / frameSamples;
+        }
+
+        var sorted = energies.Order().ToArray();
+        var threshold = Math.Max(MinEnergy, sorted[frameCount / 10] * FloorFactor);
+
+        int voicedFrames = 0, run = 0;
+        for (int f = 0; f <= frameCount; f++)
+        {
+            var voiced = f < frameCount
+                && energies[f] >= threshold
+                && zeroCrossings[f] is >= MinZeroCrossingRate and <= MaxZeroCrossingRate;
+            if (voiced)
+            {
+                run++;
+                continue;
+            }
+            if (run >= MinRunFrames)
+                voicedFrames += run;
+            run = 0;
+        }
+        return (int)(voicedFrames * FrameSeconds * 1000);
+    }
+
+    public static bool ContainsSpeech(AudioSegment segment, int minSpeechMs) =>
+        minSpeechMs <= 0 || MeasureSpeechMs(segment) >= minSpeechMs;
+}
@@ -0,0 +1,45 @@
+namespace TokenTalk.Audio;
+
+public enum SegmentDecision
+{
+    Continue,
+    // Speech followed by a pause, or the segment reached its maximum length
+    Cut,
+    // The segment reached its maximum length without any speech
+    Discard,
+}
+
+/// <summary>
+/// Energy-based voice activity detection for continuous dictation. Fed the recorder's
+/// amplitude buffers, it decides when the current segment should end.
+/// </summary>
+public class SpeechSegmenter
+{
+    private readonly object _lock = new();
+    private DateTime _segmentStart;
+    private DateTime? _lastSpeech;
+
+    public void Reset(DateTime now)
+    {
+        lock (_lock)
+        {
+            _segmentStart = now;
+            _lastSpeech = null;
+        }
+    }
+
+    public SegmentDecision Push(float amplitude, DateTime now, double speechThreshold, TimeSpan pause, TimeSpan maxSegment)
+    {
+        lock (_lock)
+        {
+            if (amplitude >= speechThreshold)
+                _lastSpeech = now;
+
+            if (_lastSpeech == null)
+                return now - _segmentStart >= maxSegment ? SegmentDecision.Discard : SegmentDecision.Continue;
+            if (now - _lastSpeech.Value >= pause || now - _segmentStart >= maxSegment)
+                return SegmentDecision.Cut;
+            return SegmentDecision.Continue;
+        }
+    }
+}
@@ -0,0 +1,51 @@
+using System.Globalization;
+using System.Reflection;
+using System.Runtime.InteropServices;
+
+namespace TokenTalk;
+
+/// <summary>
+/// Version details of the running build, for logs, bug reports and the sidebar.
+/// The SDK appends the git commit to the informational version ("1.0.0+abc123").
+/// </summary>
+public static class BuildInfo
+{
+    private static readonly Assembly Assembly = typeof(BuildInfo).Assembly;
+
+    private static string InformationalVersion =>
+        Assembly.GetCustomAttribute<AssemblyInformationalVersionAttribute>()?.InformationalVersion
+        ?? Assembly.GetName().Version?.ToString()
+        ?? "0.0.0";
+
+    public static string Version => InformationalVersion.Split('+')[0];
+
+    public static string Commit
+    {
+        get
+        {
+            var parts = InformationalVersion.Split('+', 2);
+            return parts.Length == 2 && parts[1].Length > 0 ? parts[1][..Math.Min(7, parts[1].Length)] : "unknown";
+        }
+    }
+
+    // Stamped by the BuildDate AssemblyMetadata item in TokenTalk.csproj
+    public static DateTime? BuildDate
+    {
+        get
+        {
+            var value = Assembly.GetCustomAttributes<AssemblyMetadataAttribute>()
+                .FirstOrDefault(a => a.Key == "BuildDate")?.Value;
+            return DateTime.TryParse(value, CultureInfo.InvariantCulture, DateTimeStyles.RoundtripKind, out var date)
+                ? date
+                : null;
+        }
+    }
+
+    public static string Runtime => RuntimeInformation.FrameworkDescription;
+
+    public static string OperatingSystem => RuntimeInformation.OSDescription;
+
+    public static string Summary =>
+        $"TokenTalk {Version} ({Commit}), built {BuildDate?.ToString("yyyy-MM-dd") ?? "unknown"}, " +
+        $"{Runtime}, {OperatingSystem}";
+}
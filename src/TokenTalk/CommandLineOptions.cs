@@ -0,0 +1,64 @@
+using Microsoft.Extensions.Logging;
+
+namespace TokenTalk;
+
+/// <summary>
+/// Global flags that apply before any command: <c>--config &lt;path&gt;</c>,
+/// <c>--log-level &lt;level&gt;</c>, <c>--instance &lt;name&gt;</c> and <c>--profile &lt;name&gt;</c>.
+/// A separate config file plus an instance name lets a test instance run next to the normal one.
+/// Whatever is left over is the command (<c>--record</c>, <c>--status</c>, ...).
+/// </summary>
+public class CommandLineOptions
+{
+    // Absolute path to appsettings.json; its directory also holds the database and models
+    public string? ConfigPath { get; private set; }
+    // Overrides Logging.Level from the config when given
+    public LogLevel? LogLevel { get; private set; }
+    // Suffix for the control pipe, so instances don't answer each other's commands
+    public string? Instance { get; private set; }
+    // Profile to switch to at startup
+    public string? Profile { get; private set; }
+    public string[] Command { get; private set; } = [];
+
+    public static CommandLineOptions Parse(string[] args)
+    {
+        var options = new CommandLineOptions();
+        var rest = new List<string>();
+
+        for (var i = 0; i < args.Length; i++)
+        {
+            switch (args[i])
+            {
+                case "--config":
+                    options.ConfigPath = Path.GetFullPath(Value(args, ref i));
+                    break;
+                case "--log-level":
+                    var level = Value(args, ref i);
+                    if (!Enum.TryParse<LogLevel>(level, ignoreCase: true, out var parsed))
+                        throw new ArgumentException(
+                            $"--log-level must be one of {string.Join(", ", Enum.GetNames<LogLevel>())}, got '{level}'");
+                    options.LogLevel = parsed;
+                    break;
+                case "--instance":
+                    options.Instance = Value(args, ref i);
+                    break;
+                case "--profile":
+                    options.Profile = Value(args, ref i);
+                    break;
+                default:
+                    rest.Add(args[i]);
+                    break;
+            }
+        }
+
+        options.Command = [.. rest];
+        return options;
+    }
+
+    private static string Value(string[] args, ref int i)
+    {
+        if (i + 1 >= args.Length || args[i + 1].StartsWith("--", StringComparison.Ordinal))
+            throw new ArgumentException($"{args[i]} needs a value");
+        return args[++i];
+    }
+}
@@ -1,14 +1,28 @@
 using System.Text.Json;
+using System.Text.Json.Nodes;
 using Microsoft.Extensions.Logging;
 
 namespace TokenTalk.Configuration;
 
-public class ConfigManager
+public class ConfigManager : IDisposable
 {
+    // Editors often write a file in several steps; wait for them to settle before reloading
+    private static readonly TimeSpan ReloadDelay = TimeSpan.FromMilliseconds(300);
+
     private TokenTalkOptions _current;
     private readonly string _configPath;
     private readonly ILogger<ConfigManager> _logger;
     private readonly object _lock = new();
+    private FileSystemWatcher? _watcher;
+    private Timer? _reloadTimer;
+    // File content as last read or written, so our own saves don't trigger a reload
+    private string _lastJson = "";
+
+    // Validation problems found when the file was last loaded
+    public IReadOnlyList<ConfigCheck> Problems { get; private set; } = [];
+
+    /// <summary>Raised after the options were replaced, by Save or by an external edit of the file.</summary>
+    public event EventHandler<TokenTalkOptions>? Changed;
 
     private static readonly JsonSerializerOptions JsonOptions = new()
     {
@@ -28,6 +42,13 @@ public class ConfigManager
         get { lock (_lock) return _current; }
     }
 
+    /// <summary>Deep copy of the current options, for editing without touching the live config.</summary>
+    public TokenTalkOptions Snapshot()
+    {
+        lock (_lock)
+            return JsonSerializer.Deserialize<TokenTalkOptions>(JsonSerializer.Serialize(_current, JsonOptions), JsonOptions)!;
+    }
+
     private TokenTalkOptions Load()
     {
         if (!File.Exists(_configPath))
@@ -40,9 +61,7 @@ public class ConfigManager
 
         try
         {
-            var json = File.ReadAllText(_configPath);
-            var options = JsonSerializer.Deserialize<TokenTalkOptions>(json, JsonOptions);
-            return options ?? new TokenTalkOptions();
+            return Parse(File.ReadAllText(_configPath));
         }
         catch (Exception ex)
         {
@@ -51,6 +70,36 @@ public class ConfigManager
         }
     }
 
+    // Upgrades a file written by an older version, keeping a backup of the original
+    private TokenTalkOptions Parse(string json)
+    {
+        var root = JsonNode.Parse(json)?.AsObject() ?? throw new JsonException("Config is not a JSON object");
+        var version = ConfigMigrator.GetVersion(root);
+        if (version > ConfigMigrator.LatestVersion)
+            _logger.LogWarning("Config version {Version} is newer than this build understands ({Latest}); unknown settings are ignored",
+                version, ConfigMigrator.LatestVersion);
+
+        var applied = ConfigMigrator.Migrate(root);
+        var options = root.Deserialize<TokenTalkOptions>(JsonOptions) ?? new TokenTalkOptions();
+
+        Problems = [.. ConfigValidator.FindUnknownKeys(root), .. ConfigValidator.Validate(options)];
+        foreach (var problem in Problems)
+            _logger.LogWarning("Config {Key}: {Problem}", problem.Field, problem.Message);
+
+        if (applied.Count == 0)
+        {
+            _lastJson = json;
+            return options;
+        }
+
+        var backupPath = $"{_configPath}.v{version}.bak";
+        File.Copy(_configPath, backupPath, overwrite: true);
+        SaveInternal(options);
+        _logger.LogInformation("Upgraded config from version {From} to {To} ({Migrations}), backup at {Backup}",
+            version, ConfigMigrator.LatestVersion, string.Join("; ", applied), backupPath);
+        return options;
+    }
+
     public void Save(TokenTalkOptions options)
     {
         lock (_lock)
@@ -58,6 +107,7 @@ public class ConfigManager
             SaveInternal(options);
             _current = options;
         }
+        Changed?.Invoke(this, options);
     }
 
     private void SaveInternal(TokenTalkOptions options)
@@ -65,6 +115,172 @@ public class ConfigManager
         Directory.CreateDirectory(Path.GetDirectoryName(_configPath)!);
         var json = JsonSerializer.Serialize(options, JsonOptions);
         File.WriteAllText(_configPath, json);
+        _lastJson = json;
+        // Written from the model, so there can be no unknown keys left
+        Problems = ConfigValidator.Validate(options);
+    }
+
+    /// <summary>
+    /// Makes the named profile active: the current settings are stored back into the previously
+    /// active profile first, so edits made since the last switch are kept. Returns false if no
+    /// profile has that name.
+    /// </summary>
+    public bool SwitchProfile(string name)
+    {
+        TokenTalkOptions options;
+        lock (_lock)
+        {
+            options = Snapshot();
+            var target = FindProfile(options, name);
+            if (target == null)
+                return false;
+
+            StoreActiveProfile(options);
+            options.Transcription = Clone(target.Transcription);
+            options.PostProcessing = Clone(target.PostProcessing);
+            options.ActiveProfile = target.Name;
+            SaveInternal(options);
+            _current = options;
+        }
+        _logger.LogInformation("Switched to profile {Profile}", name);
+        Changed?.Invoke(this, options);
+        return true;
+    }
+
+    /// <summary>Saves the current settings as a profile, replacing one with the same name, and makes it active.</summary>
+    public void SaveProfile(string name)
+    {
+        TokenTalkOptions options;
+        lock (_lock)
+        {
+            options = Snapshot();
+            options.ActiveProfile = FindProfile(options, name)?.Name ?? name;
+            StoreActiveProfile(options);
+            SaveInternal(options);
+            _current = options;
+        }
+        Changed?.Invoke(this, options);
+    }
+
+    /// <summary>Removes a profile. Its settings stay in effect if it was active.</summary>
+    public bool DeleteProfile(string name)
+    {
+        TokenTalkOptions options;
+        lock (_lock)
+        {
+            options = Snapshot();
+            var profile = FindProfile(options, name);
+            if (profile == null)
+                return false;
+
+            options.Profiles.Remove(profile);
+            if (profile.Name == options.ActiveProfile)
+                options.ActiveProfile = "";
+            SaveInternal(options);
+            _current = options;
+        }
+        Changed?.Invoke(this, options);
+        return true;
+    }
+
+    private static ProfileOptions? FindProfile(TokenTalkOptions options, string name) =>
+        options.Profiles.FirstOrDefault(p => string.Equals(p.Name, name, StringComparison.OrdinalIgnoreCase));
+
+    private static void StoreActiveProfile(TokenTalkOptions options)
+    {
+        if (string.IsNullOrEmpty(options.ActiveProfile))
+            return;
+
+        var profile = FindProfile(options, options.ActiveProfile);
+        if (profile == null)
+        {
+            profile = new ProfileOptions { Name = options.ActiveProfile };
+            options.Profiles.Add(profile);
+        }
+        profile.Transcription = Clone(options.Transcription);
+        profile.PostProcessing = Clone(options.PostProcessing);
+    }
+
+    private static T Clone<T>(T value) =>
+        JsonSerializer.Deserialize<T>(JsonSerializer.Serialize(value, JsonOptions), JsonOptions)!;
+
+    /// <summary>
+    /// Reloads the config when the file is edited outside the app. A file that fails to parse is
+    /// logged and ignored, keeping the running config, rather than falling back to defaults.
+    /// </summary>
+    public void StartWatching()
+    {
+        _reloadTimer = new Timer(_ => ReloadFromDisk());
+        _watcher = new FileSystemWatcher(Path.GetDirectoryName(_configPath)!, Path.GetFileName(_configPath))
+        {
+            NotifyFilter = NotifyFilters.LastWrite | NotifyFilters.Size | NotifyFilters.FileName,
+        };
+        _watcher.Changed += (_, _) => _reloadTimer.Change(ReloadDelay, Timeout.InfiniteTimeSpan);
+        _watcher.Created += (_, _) => _reloadTimer.Change(ReloadDelay, Timeout.InfiniteTimeSpan);
+        _watcher.Renamed += (_, _) => _reloadTimer.Change(ReloadDelay, Timeout.InfiniteTimeSpan);
+        _watcher.EnableRaisingEvents = true;
+    }
+
+    private void ReloadFromDisk()
+    {
+        TokenTalkOptions options;
+        lock (_lock)
+        {
+            string json;
+            try
+            {
+                json = File.ReadAllText(_configPath);
+            }
+            catch (IOException)
+            {
+                // Still being written; the next change event retries
+                _reloadTimer?.Change(ReloadDelay, Timeout.InfiniteTimeSpan);
+                return;
+            }
+
+            if (json == _lastJson)
+                return;
+
+            try
+            {
+                options = Parse(json);
+            }
+            catch (Exception ex) when (ex is JsonException or InvalidOperationException or IOException)
+            {
+                _logger.LogWarning("Ignoring edit to {Path}: {Error}", _configPath, ex.Message);
+                return;
+            }
+
+            _current = options;
+        }
+
+        _logger.LogInformation("Reloaded config from {Path}", _configPath);
+        Changed?.Invoke(this, options);
+    }
+
+    public void Dispose()
+    {
+        _watcher?.Dispose();
+        _reloadTimer?.Dispose();
+    }
+
+    /// <summary>Loads and validates a config file without changing it, for <c>--check-config</c>.</summary>
+    public static List<ConfigCheck> Check(string path)
+    {
+        if (!File.Exists(path))
+            return [new ConfigCheck(path, false, "File not found")];
+        try
+        {
+            var root = JsonNode.Parse(File.ReadAllText(path))?.AsObject()
+                ?? throw new JsonException("Config is not a JSON object");
+            ConfigMigrator.Migrate(root);
+            var options = root.Deserialize<TokenTalkOptions>(JsonOptions) ?? new TokenTalkOptions();
+            return [.. ConfigValidator.FindUnknownKeys(root), .. ConfigValidator.Validate(options)];
+        }
+        catch (Exception ex) when (ex is JsonException or InvalidOperationException)
+        {
+            return [new ConfigCheck(path, false, $"Not valid JSON: {ex.Message}")];
+        }
     }
 
     public static string GetConfigDirectory()
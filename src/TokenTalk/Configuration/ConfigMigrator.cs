@@ -0,0 +1,69 @@
+using System.Text.Json.Nodes;
+
+namespace TokenTalk.Configuration;
+
+/// <summary>
+/// Upgrades config files written by older versions. Each migration rewrites the raw JSON so
+/// renamed or restructured settings carry over instead of silently reverting to defaults.
+/// Files without a ConfigVersion predate versioning and count as version 0.
+/// </summary>
+public static class ConfigMigrator
+{
+    private record Migration(int Version, string Description, Action<JsonObject> Apply);
+
+    // Append new migrations at the end with the next version number; never edit or reorder
+    // migrations that have shipped
+    private static readonly Migration[] Migrations =
+    [
+        new(1, "Add config version", _ => { }),
+        new(2, "Move Hotkey into the Hotkeys list", MoveHotkeyIntoList),
+    ];
+
+    public static int LatestVersion => Migrations[^1].Version;
+
+    public static int GetVersion(JsonObject root) =>
+        Find(root, nameof(TokenTalkOptions.ConfigVersion))?.GetValue<int>() ?? 0;
+
+    /// <summary>Applies every migration newer than the file's version and returns the descriptions of those that ran.</summary>
+    public static List<string> Migrate(JsonObject root)
+    {
+        var current = GetVersion(root);
+        var applied = new List<string>();
+        foreach (var migration in Migrations.Where(m => m.Version > current))
+        {
+            migration.Apply(root);
+            applied.Add($"{migration.Version}: {migration.Description}");
+        }
+
+        if (applied.Count > 0)
+        {
+            Remove(root, nameof(TokenTalkOptions.ConfigVersion));
+            root[nameof(TokenTalkOptions.ConfigVersion)] = LatestVersion;
+        }
+        return applied;
+    }
+
+    private static void MoveHotkeyIntoList(JsonObject root)
+    {
+        var combo = Find(root, "Hotkey")?.GetValue<string>();
+        Remove(root, "Hotkey");
+        if (combo == null || Find(root, nameof(TokenTalkOptions.Hotkeys)) != null)
+            return;
+        root[nameof(TokenTalkOptions.Hotkeys)] = new JsonArray(new JsonObject
+        {
+            [nameof(HotkeyOptions.Combo)] = combo,
+            [nameof(HotkeyOptions.Mode)] = HotkeyModes.Hold,
+        });
+    }
+
+    // Config files are read case-insensitively, so migrations must match keys the same way
+    private static JsonNode? Find(JsonObject obj, string name) =>
+        obj.FirstOrDefault(p => string.Equals(p.Key, name, StringComparison.OrdinalIgnoreCase)).Value;
+
+    private static void Remove(JsonObject obj, string name)
+    {
+        var key = obj.FirstOrDefault(p => string.Equals(p.Key, name, StringComparison.OrdinalIgnoreCase)).Key;
+        if (key != null)
+            obj.Remove(key);
+    }
+}
@@ -0,0 +1,149 @@
+using System.Text.Json;
+using System.Text.Json.Nodes;
+
+namespace TokenTalk.Configuration;
+
+public record ConfigImportResult(IReadOnlyList<ConfigCheck> Problems, IReadOnlyList<string> Changes, bool Applied);
+
+/// <summary>
+/// Shares a setup between machines. Exports replace secrets (API keys, custom OpenAI header
+/// values, connection strings, webhook secrets, and the webhook, report, output and telemetry
+/// URLs, which often carry a token) with a placeholder; on import a placeholder keeps the value
+/// this machine already has, so a shared file never overwrites credentials.
+/// </summary>
+public static class ConfigTransfer
+{
+    public const string SecretPlaceholder = "<redacted>";
+
+    private static readonly JsonSerializerOptions JsonOptions = new()
+    {
+        WriteIndented = true,
+        PropertyNameCaseInsensitive = true,
+    };
+
+    public static string Export(TokenTalkOptions options) =>
+        JsonSerializer.Serialize(Sanitize(options), JsonOptions);
+
+    /// <summary>Replaces secrets in place with the placeholder. Empty values stay empty so "not set" is still visible.</summary>
+    public static TokenTalkOptions Sanitize(TokenTalkOptions options)
+    {
+        options.Transcription.ApiKey = Mask(options.Transcription.ApiKey);
+        MaskAll(options.Transcription.Headers);
+        foreach (var profile in options.Profiles)
+        {
+            profile.Transcription.ApiKey = Mask(profile.Transcription.ApiKey);
+            MaskAll(profile.Transcription.Headers);
+        }
+        options.Storage.ConnectionString = Mask(options.Storage.ConnectionString);
+        foreach (var hook in options.Webhooks)
+        {
+            hook.Url = Mask(hook.Url);
+            hook.Secret = Mask(hook.Secret);
+        }
+        options.Reports.WebhookUrl = Mask(options.Reports.WebhookUrl);
+        options.Output.Url = Mask(options.Output.Url);
+        options.Telemetry.Endpoint = Mask(options.Telemetry.Endpoint);
+        return options;
+    }
+
+    /// <summary>
+    /// Validates an exported file against the current config and lists the settings it would
+    /// change. Nothing is saved when <paramref name="dryRun"/> is set or any problem is found.
+    /// </summary>
+    public static ConfigImportResult Import(ConfigManager configManager, string json, bool dryRun)
+    {
+        JsonObject root;
+        try
+        {
+            root = JsonNode.Parse(json)?.AsObject() ?? throw new JsonException("Config is not a JSON object");
+        }
+        catch (Exception ex) when (ex is JsonException or InvalidOperationException)
+        {
+            return new ConfigImportResult([new ConfigCheck("(file)", false, $"Not valid JSON: {ex.Message}")], [], false);
+        }
+
+        ConfigMigrator.Migrate(root);
+        var current = configManager.Snapshot();
+        var imported = root.Deserialize<TokenTalkOptions>(JsonOptions) ?? new TokenTalkOptions();
+        RestoreSecrets(imported, current);
+
+        List<ConfigCheck> problems = [.. ConfigValidator.FindUnknownKeys(root), .. ConfigValidator.Validate(imported)];
+        var changes = Diff(
+            JsonSerializer.SerializeToNode(Sanitize(configManager.Snapshot()), JsonOptions),
+            JsonSerializer.SerializeToNode(Sanitize(Clone(imported)), JsonOptions),
+            "");
+
+        if (dryRun || problems.Count > 0)
+            return new ConfigImportResult(problems, changes, false);
+
+        configManager.Save(imported);
+        return new ConfigImportResult(problems, changes, true);
+    }
+
+    private static void RestoreSecrets(TokenTalkOptions imported, TokenTalkOptions current)
+    {
+        imported.Transcription.ApiKey = Keep(imported.Transcription.ApiKey, current.Transcription.ApiKey);
+        KeepAll(imported.Transcription.Headers, current.Transcription.Headers);
+        foreach (var profile in imported.Profiles)
+        {
+            var existing = current.Profiles.FirstOrDefault(p => string.Equals(p.Name, profile.Name, StringComparison.OrdinalIgnoreCase));
+            // A new profile falls back to this machine's main key
+            profile.Transcription.ApiKey = Keep(profile.Transcription.ApiKey,
+                existing?.Transcription.ApiKey ?? current.Transcription.ApiKey);
+            KeepAll(profile.Transcription.Headers, existing?.Transcription.Headers ?? current.Transcription.Headers);
+        }
+        imported.Storage.ConnectionString = Keep(imported.Storage.ConnectionString, current.Storage.ConnectionString);
+        for (var i = 0; i < imported.Webhooks.Count; i++)
+        {
+            var existing = i < current.Webhooks.Count ? current.Webhooks[i] : null;
+            imported.Webhooks[i].Url = Keep(imported.Webhooks[i].Url, existing?.Url ?? "");
+            imported.Webhooks[i].Secret = Keep(imported.Webhooks[i].Secret, existing?.Secret ?? "");
+        }
+        imported.Reports.WebhookUrl = Keep(imported.Reports.WebhookUrl, current.Reports.WebhookUrl);
+        imported.Output.Url = Keep(imported.Output.Url, current.Output.Url);
+        imported.Telemetry.Endpoint = Keep(imported.Telemetry.Endpoint, current.Telemetry.Endpoint);
+    }
+
+    // Lists leaf settings that differ, as "Key: old → new"
+    private static List<string> Diff(JsonNode? before, JsonNode? after, string path)
+    {
+        var changes = new List<string>();
+        if (before is JsonObject b && after is JsonObject a)
+        {
+            foreach (var key in b.Select(p => p.Key).Union(a.Select(p => p.Key)))
+                changes.AddRange(Diff(b[key], a[key], path.Length == 0 ? key : $"{path}.{key}"));
+        }
+        else if (!JsonNode.DeepEquals(before, after))
+        {
+            changes.Add($"{path}: {Describe(before)} → {Describe(after)}");
+        }
+        return changes;
+    }
+
+    private static string Describe(JsonNode? node) => node switch
+    {
+        null => "(none)",
+        JsonArray array => $"{array.Count} item(s)",
+        _ => node.ToJsonString(),
+    };
+
+    private static string Mask(string value) => string.IsNullOrEmpty(value) ? "" : SecretPlaceholder;
+
+    private static string Keep(string imported, string current) => imported == SecretPlaceholder ? current : imported;
+
+    // Custom headers usually carry a gateway credential, so every value counts as a secret
+    private static void MaskAll(Dictionary<string, string> values)
+    {
+        foreach (var key in values.Keys.ToList())
+            values[key] = Mask(values[key]);
+    }
+
+    private static void KeepAll(Dictionary<string, string> imported, Dictionary<string, string> current)
+    {
+        foreach (var key in imported.Keys.ToList())
+            imported[key] = Keep(imported[key], current.GetValueOrDefault(key, ""));
+    }
+
+    private static TokenTalkOptions Clone(TokenTalkOptions options) =>
+        JsonSerializer.Deserialize<TokenTalkOptions>(JsonSerializer.Serialize(options, JsonOptions), JsonOptions)!;
+}
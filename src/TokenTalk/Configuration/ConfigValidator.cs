@@ -0,0 +1,422 @@
+using System.Collections;
+using System.Reflection;
+using System.Text.Json.Nodes;
+using NAudio.Wave;
+using TokenTalk.Integrations;
+using TokenTalk.Notifications;
+using TokenTalk.Platform;
+using TokenTalk.Transcription;
+
+namespace TokenTalk.Configuration;
+
+public record ConfigCheck(string Field, bool Ok, string Message);
+
+/// <summary>
+/// Checks a prospective configuration before it is saved: the hotkey parses, the audio device
+/// exists, and the selected transcription provider is usable. The OpenAI check makes one small
+/// authenticated request that does not transcribe anything.
+/// </summary>
+/// <remarks>
+/// <see cref="Validate"/> and <see cref="FindUnknownKeys"/> are the offline part, run whenever the
+/// config file is loaded; they report every problem at once, each with the key it concerns.
+/// </remarks>
+public class ConfigValidator
+{
+    // Longer waits would hold every dictation for seconds; something else is wrong by then
+    private const int MaxPasteDelayMs = 10_000;
+
+    private readonly IHttpClientFactory _httpClientFactory;
+
+    public ConfigValidator(IHttpClientFactory httpClientFactory)
+    {
+        _httpClientFactory = httpClientFactory;
+    }
+
+    public async Task<List<ConfigCheck>> TestAsync(TokenTalkOptions options, CancellationToken ct = default)
+    {
+        var checks = new List<ConfigCheck>
+        {
+            HotkeyListener.TryValidate(options.PrimaryHotkey.Combo, out var hotkeyError)
+                ? new ConfigCheck("Hotkey", true, options.PrimaryHotkey.Combo)
+                : new ConfigCheck("Hotkey", false, hotkeyError),
+            CheckAudioDevice(options.Audio.DeviceIndex),
+            options.Audio.MaxSeconds > 0
+                ? new ConfigCheck("Max Recording", true, $"{options.Audio.MaxSeconds} s")
+                : new ConfigCheck("Max Recording", false, "Must be greater than 0"),
+        };
+
+        checks.Add(await CheckProviderAsync(options.Transcription, ct));
+        // The rest of the offline checks; hotkey and max recording are already covered above
+        checks.AddRange(Validate(options).Where(p => p.Field is not ("Hotkeys[0].Combo" or "Audio.MaxSeconds")));
+        return checks;
+    }
+
+    /// <summary>Offline checks of loaded options. Returns only the problems.</summary>
+    public static List<ConfigCheck> Validate(TokenTalkOptions options)
+    {
+        var problems = new List<ConfigCheck>();
+        void Fail(string key, string message) => problems.Add(new ConfigCheck(key, false, message));
+
+        if (options.Hotkeys.Count == 0)
+            Fail("Hotkeys", "At least one hotkey is required");
+        for (var i = 0; i < options.Hotkeys.Count; i++)
+        {
+            var hotkey = options.Hotkeys[i];
+            if (!HotkeyListener.TryValidate(hotkey.Combo, out var hotkeyError))
+                Fail($"Hotkeys[{i}].Combo", hotkeyError);
+            else if (options.Hotkeys.Take(i).Any(h => string.Equals(h.Combo, hotkey.Combo, StringComparison.OrdinalIgnoreCase)))
+                Fail($"Hotkeys[{i}].Combo", $"'{hotkey.Combo}' is already used by another hotkey");
+            if (!HotkeyModes.All.Contains(hotkey.Mode))
+                Fail($"Hotkeys[{i}].Mode", $"Unknown mode '{hotkey.Mode}', expected one of {string.Join(", ", HotkeyModes.All)}");
+            if (hotkey.MinHoldMs is < 0 or > 2000)
+                Fail($"Hotkeys[{i}].MinHoldMs", "Must be between 0 (off) and 2000 ms");
+            if (hotkey.Profile.Length > 0
+                && !options.Profiles.Any(p => string.Equals(p.Name, hotkey.Profile, StringComparison.OrdinalIgnoreCase)))
+                Fail($"Hotkeys[{i}].Profile", $"No profile named '{hotkey.Profile}'");
+            if (hotkey.Language.Length > 0 && !IsLanguage(hotkey.Language))
+                Fail($"Hotkeys[{i}].Language", $"'{hotkey.Language}' is not 'auto' or a language code like 'en'");
+            if (hotkey.Target.Length > 0 && !OutputTargets.All.Contains(hotkey.Target))
+                Fail($"Hotkeys[{i}].Target", $"Unknown target '{hotkey.Target}', expected one of {string.Join(", ", OutputTargets.All)}");
+        }
+
+        if (options.Audio.DeviceIndex < -1)
+            Fail("Audio.DeviceIndex", "Must be -1 (default device) or a device number");
+        if (options.Audio.MaxSeconds <= 0)
+            Fail("Audio.MaxSeconds", "Must be greater than 0");
+        // Peak amplitude of 16-bit samples; anything at the top of the range rejects every recording
+        if (options.Audio.SilenceThreshold is < 0 or >= short.MaxValue)
+            Fail("Audio.SilenceThreshold", $"Must be between 0 (off) and {short.MaxValue}");
+        if (options.Audio.MinSpeechMs is < 0 or > 5000)
+            Fail("Audio.MinSpeechMs", "Must be between 0 (off) and 5000");
+
+        if (!QueuePolicies.All.Contains(options.Queue.Policy))
+            Fail("Queue.Policy", $"Unknown policy '{options.Queue.Policy}', expected one of {string.Join(", ", QueuePolicies.All)}");
+        if (options.Queue.TimeoutSeconds < 0)
+            Fail("Queue.TimeoutSeconds", "Must be 0 (no timeout) or more");
+        // Windows ends the process soon after logoff regardless
+        if (options.Queue.ShutdownWaitSeconds is < 0 or > 120)
+            Fail("Queue.ShutdownWaitSeconds", "Must be between 0 and 120");
+
+        if (options.Continuous.PauseMs is < 200 or > 10_000)
+            Fail("Continuous.PauseMs", "Must be between 200 and 10000 ms");
+        if (options.Continuous.SpeechThreshold is <= 0 or >= 1)
+            Fail("Continuous.SpeechThreshold", "Must be between 0 and 1");
+        if (options.Continuous.MaxSegmentSeconds <= 0)
+            Fail("Continuous.MaxSegmentSeconds", "Must be greater than 0");
+        else if (options.Continuous.MaxSegmentSeconds > options.Audio.MaxSeconds)
+            Fail("Continuous.MaxSegmentSeconds", $"Must not exceed Audio.MaxSeconds ({options.Audio.MaxSeconds})");
+
+        var grammar = options.PostProcessing.GrammarProvider;
+        if (!GrammarProviders.All.Contains(grammar))
+            Fail("PostProcessing.GrammarProvider", $"Unknown provider '{grammar}', expected '' or '{GrammarProviders.LanguageTool}'");
+        else if (grammar == GrammarProviders.LanguageTool
+                 && !(Uri.TryCreate(options.PostProcessing.LanguageTool.Url, UriKind.Absolute, out var languageToolUrl) && languageToolUrl.Scheme is "http" or "https"))
+            Fail("PostProcessing.LanguageTool.Url", "An http or https URL is required for LanguageTool");
+
+        if (!OutputTargets.All.Contains(options.Output.Target))
+            Fail("Output.Target", $"Unknown target '{options.Output.Target}', expected one of {string.Join(", ", OutputTargets.All)}");
+        // Checked whenever a hotkey could select the target, not just when it is the default
+        var targets = options.Hotkeys.Select(h => h.Target.Length > 0 ? h.Target : options.Output.Target).ToList();
+        if (targets.Contains(OutputTargets.File) && string.IsNullOrWhiteSpace(options.Output.FilePath))
+            Fail("Output.FilePath", "A file path is required for the file target");
+        if (targets.Contains(OutputTargets.Url)
+            && !(Uri.TryCreate(options.Output.Url, UriKind.Absolute, out var outputUrl) && outputUrl.Scheme is "http" or "https"))
+            Fail("Output.Url", "An http or https URL is required for the url target");
+        if (!OutputModes.All.Contains(options.Output.Mode))
+            Fail("Output.Mode", $"Unknown mode '{options.Output.Mode}', expected one of {string.Join(", ", OutputModes.All)}");
+        void CheckDelay(string key, int? ms)
+        {
+            if (ms is < 0 or > MaxPasteDelayMs)
+                Fail(key, $"Must be between 0 and {MaxPasteDelayMs} ms");
+        }
+        if (options.Output.DuplicateGuardSeconds is < 0 or > 60)
+            Fail("Output.DuplicateGuardSeconds", "Must be between 0 (off) and 60");
+        if (options.Output.LiveIntervalMs is < 500 or > MaxPasteDelayMs)
+            Fail("Output.LiveIntervalMs", $"Must be between 500 and {MaxPasteDelayMs} ms");
+        if (!UndoModes.All.Contains(options.Output.UndoMode))
+            Fail("Output.UndoMode", $"Unknown undo mode '{options.Output.UndoMode}', expected one of {string.Join(", ", UndoModes.All)}");
+        CheckDelay("Output.PrePasteDelayMs", options.Output.PrePasteDelayMs);
+        CheckDelay("Output.PostPasteDelayMs", options.Output.PostPasteDelayMs);
+        CheckDelay("Output.RestoreDelayMs", options.Output.RestoreDelayMs);
+        for (var i = 0; i < options.Output.Apps.Count; i++)
+        {
+            var app = options.Output.Apps[i];
+            if (string.IsNullOrWhiteSpace(app.Process))
+                Fail($"Output.Apps[{i}].Process", "Process name is empty");
+            else if (app.Process.EndsWith(".exe", StringComparison.OrdinalIgnoreCase))
+                Fail($"Output.Apps[{i}].Process", $"Leave out the extension: '{app.Process[..^4]}'");
+            CheckDelay($"Output.Apps[{i}].PrePasteDelayMs", app.PrePasteDelayMs);
+            CheckDelay($"Output.Apps[{i}].PostPasteDelayMs", app.PostPasteDelayMs);
+            CheckDelay($"Output.Apps[{i}].RestoreDelayMs", app.RestoreDelayMs);
+            if (app.UndoMode != null && !UndoModes.All.Contains(app.UndoMode))
+                Fail($"Output.Apps[{i}].UndoMode", $"Unknown undo mode '{app.UndoMode}', expected one of {string.Join(", ", UndoModes.All)}");
+        }
+
+        ValidateTranscription("Transcription", options.Transcription, Fail);
+        for (var i = 0; i < options.Profiles.Count; i++)
+        {
+            var profile = options.Profiles[i];
+            if (string.IsNullOrWhiteSpace(profile.Name))
+                Fail($"Profiles[{i}].Name", "Profile name is empty");
+            else if (options.Profiles.Take(i).Any(p => string.Equals(p.Name, profile.Name, StringComparison.OrdinalIgnoreCase)))
+                Fail($"Profiles[{i}].Name", $"Duplicate profile name '{profile.Name}'");
+            ValidateTranscription($"Profiles[{i}].Transcription", profile.Transcription, Fail);
+        }
+        if (options.ActiveProfile.Length > 0
+            && !options.Profiles.Any(p => string.Equals(p.Name, options.ActiveProfile, StringComparison.OrdinalIgnoreCase)))
+            Fail("ActiveProfile", $"No profile named '{options.ActiveProfile}'");
+
+        if (options.History.MaxAgeDays < 0)
+            Fail("History.MaxAgeDays", "Must be 0 (keep forever) or more");
+        if (options.History.MaxCount < 0)
+            Fail("History.MaxCount", "Must be 0 (no limit) or more");
+        if (options.History.TrashDays < 0)
+            Fail("History.TrashDays", "Must be 0 or more");
+        if (options.History.SessionGapMinutes <= 0)
+            Fail("History.SessionGapMinutes", "Must be greater than 0");
+
+        if (options.Goals.DailyWords < 0)
+            Fail("Goals.DailyWords", "Must be 0 (off) or more");
+        if (options.Goals.DailyDictations < 0)
+            Fail("Goals.DailyDictations", "Must be 0 (off) or more");
+
+        if (options.Telemetry.Enabled
+            && !(Uri.TryCreate(options.Telemetry.Endpoint, UriKind.Absolute, out var telemetryUri) && telemetryUri.Scheme == Uri.UriSchemeHttps))
+            Fail("Telemetry.Endpoint", "Must be an https:// URL when telemetry is enabled");
+
+        switch (options.Storage.Provider.ToLowerInvariant())
+        {
+            case "sqlite":
+                break;
+            case "postgres":
+                if (string.IsNullOrWhiteSpace(options.Storage.ConnectionString))
+                    Fail("Storage.ConnectionString", "Required when Storage.Provider is 'postgres'");
+                break;
+            default:
+                Fail("Storage.Provider", $"Unknown provider '{options.Storage.Provider}', expected 'sqlite' or 'postgres'");
+                break;
+        }
+
+        if (!Enum.TryParse<Microsoft.Extensions.Logging.LogLevel>(options.Logging.Level, ignoreCase: true, out _))
+            Fail("Logging.Level", $"Unknown level '{options.Logging.Level}', expected one of Trace, Debug, Information, Warning, Error, Critical, None");
+        if (!LogFormats.All.Contains(options.Logging.Format))
+            Fail("Logging.Format", $"Unknown format '{options.Logging.Format}', expected one of {string.Join(", ", LogFormats.All)}");
+        if (options.Logging.MaxSizeMb <= 0)
+            Fail("Logging.MaxSizeMb", "Must be greater than 0");
+        if (options.Logging.MaxBackups < 0)
+            Fail("Logging.MaxBackups", "Must be 0 or more");
+
+        foreach (var name in options.Notifications.Notifiers.Where(n => !NotifierNames.All.Contains(n)))
+            Fail("Notifications.Notifiers", $"Unknown notifier '{name}', expected one of {string.Join(", ", NotifierNames.All)}");
+
+        string[] knownEvents = [WebhookEvents.DictationCompleted, WebhookEvents.DictationFailed];
+        for (var i = 0; i < options.AppLanguages.Count; i++)
+        {
+            var app = options.AppLanguages[i];
+            if (string.IsNullOrWhiteSpace(app.Process))
+                Fail($"AppLanguages[{i}].Process", "Process name is empty");
+            else if (app.Process.EndsWith(".exe", StringComparison.OrdinalIgnoreCase))
+                Fail($"AppLanguages[{i}].Process", $"Leave out the extension: '{app.Process[..^4]}'");
+            if (!IsLanguage(app.Language))
+                Fail($"AppLanguages[{i}].Language", $"'{app.Language}' is not 'auto' or a language code like 'en'");
+        }
+
+        for (var i = 0; i < options.Webhooks.Count; i++)
+        {
+            var hook = options.Webhooks[i];
+            if (!Uri.TryCreate(hook.Url, UriKind.Absolute, out var uri) || (uri.Scheme != "http" && uri.Scheme != "https"))
+                Fail($"Webhooks[{i}].Url", $"'{hook.Url}' is not an http(s) URL");
+            foreach (var evt in hook.Events.Where(e => !knownEvents.Contains(e)))
+                Fail($"Webhooks[{i}].Events", $"Unknown event '{evt}', expected one of {string.Join(", ", knownEvents)}");
+        }
+
+        if (options.Reports.TypingWpm is < 5 or > 300)
+            Fail("Reports.TypingWpm", "Must be between 5 and 300");
+        foreach (var model in options.Reports.CostPerMinute.Where(p => p.Value < 0).Select(p => p.Key))
+            Fail($"Reports.CostPerMinute.{model}", "Must not be negative");
+        if (options.Reports.WebhookUrl.Length > 0
+            && !(Uri.TryCreate(options.Reports.WebhookUrl, UriKind.Absolute, out var reportUrl) && reportUrl.Scheme is "http" or "https"))
+            Fail("Reports.WebhookUrl", $"'{options.Reports.WebhookUrl}' is not an http(s) URL");
+
+        var obsidian = options.Obsidian;
+        if (obsidian.VaultPath.Length > 0)
+        {
+            if (!Directory.Exists(obsidian.VaultPath))
+                Fail("Obsidian.VaultPath", $"Folder '{obsidian.VaultPath}' does not exist");
+            if (!ObsidianModes.All.Contains(obsidian.Mode))
+                Fail("Obsidian.Mode", $"Unknown mode '{obsidian.Mode}', expected one of {string.Join(", ", ObsidianModes.All)}");
+            if (obsidian.IntervalMinutes is < 1 or > 1440)
+                Fail("Obsidian.IntervalMinutes", "Must be between 1 and 1440");
+            if (string.IsNullOrWhiteSpace(obsidian.DateFormat)
+                || DateTime.Now.ToString(obsidian.DateFormat).IndexOfAny(Path.GetInvalidFileNameChars()) >= 0)
+                Fail("Obsidian.DateFormat", "Must produce a valid file name, e.g. yyyy-MM-dd");
+            if (!obsidian.Template.Contains("{text}", StringComparison.Ordinal))
+                Fail("Obsidian.Template", "Must contain {text}");
+        }
+
+        return problems;
+    }
+
+    private static void ValidateTranscription(string key, TranscriptionOptions options, Action<string, string> fail)
+    {
+        switch (options.Provider)
+        {
+            case "openai":
+                if (string.IsNullOrWhiteSpace(options.Model))
+                    fail($"{key}.Model", "Model is empty");
+                // The audio endpoint only accepts whisper and *-transcribe models
+                else if (!options.Model.StartsWith("whisper", StringComparison.Ordinal)
+                         && !options.Model.Contains("transcribe", StringComparison.Ordinal))
+                    fail($"{key}.Model", $"'{options.Model}' is not an OpenAI transcription model");
+                break;
+            case "whisper.cpp":
+                if (string.IsNullOrWhiteSpace(options.ModelPath))
+                    fail($"{key}.ModelPath", "Required when Provider is 'whisper.cpp'");
+                else if (!File.Exists(options.ModelPath))
+                    fail($"{key}.ModelPath", $"File not found: {options.ModelPath}");
+                break;
+            default:
+                fail($"{key}.Provider", $"Unknown provider '{options.Provider}', expected 'openai' or 'whisper.cpp'");
+                break;
+        }
+
+        if (options.Fallback is not ("" or "openai" or "whisper.cpp"))
+            fail($"{key}.Fallback", $"Unknown provider '{options.Fallback}', expected '', 'openai' or 'whisper.cpp'");
+        else if (options.Fallback.Length > 0 && options.Fallback == options.Provider)
+            fail($"{key}.Fallback", "Must differ from Provider");
+        else if (options.Fallback == "whisper.cpp" && string.IsNullOrWhiteSpace(options.ModelPath))
+            fail($"{key}.ModelPath", "Required when Fallback is 'whisper.cpp'");
+        else if (options.Fallback == "openai" && string.IsNullOrWhiteSpace(options.ApiKey))
+            fail($"{key}.ApiKey", "Required when Fallback is 'openai'");
+        if (options.CircuitFailures < 0)
+            fail($"{key}.CircuitFailures", "Must be 0 (never open) or more");
+        if (options.CircuitCooldownSeconds is < 1 or > 3600)
+            fail($"{key}.CircuitCooldownSeconds", "Must be between 1 and 3600");
+        // The pool drops connections idle for 10 minutes, so a longer interval would warm nothing
+        if (options.KeepWarmSeconds is < 0 or > 540)
+            fail($"{key}.KeepWarmSeconds", "Must be between 0 (off) and 540");
+        if (options.LowConfidenceThreshold is < 0 or > 1)
+            fail($"{key}.LowConfidenceThreshold", "Must be between 0 (off) and 1");
+        // A header name is an HTTP token: no spaces, colons or other separators
+        foreach (var name in options.Headers.Keys.Where(n => n.Length == 0 || n.Any(c => c <= ' ' || c >= 127 || "()<>@,;:\\\"/[]?={}".Contains(c))))
+            fail($"{key}.Headers", $"'{name}' is not a valid header name");
+
+        // Racing needs the settings of whichever provider Provider doesn't already require
+        if (options.Race && options.Provider == "openai" && string.IsNullOrWhiteSpace(options.ModelPath))
+            fail($"{key}.ModelPath", "Required when Race is on");
+        if (options.Race && options.Provider == "whisper.cpp" && string.IsNullOrWhiteSpace(options.ApiKey))
+            fail($"{key}.ApiKey", "Required when Race is on");
+
+        // Empty means auto-detect, like "auto"
+        if (options.Language.Length > 0 && !IsLanguage(options.Language))
+            fail($"{key}.Language", $"'{options.Language}' is not 'auto' or a language code like 'en'");
+    }
+
+    private static bool IsLanguage(string language) =>
+        language == "auto" || (language.Length is 2 or 3 && language.All(char.IsAsciiLetterLower));
+
+    /// <summary>Keys in the raw config that no option maps to, usually typos that would otherwise be ignored.</summary>
+    public static List<ConfigCheck> FindUnknownKeys(JsonObject root)
+    {
+        var problems = new List<ConfigCheck>();
+        CollectUnknownKeys(root, typeof(TokenTalkOptions), "", problems);
+        return problems;
+    }
+
+    private static void CollectUnknownKeys(JsonObject obj, Type type, string prefix, List<ConfigCheck> problems)
+    {
+        var properties = type.GetProperties(BindingFlags.Public | BindingFlags.Instance);
+        foreach (var (key, value) in obj)
+        {
+            var path = prefix + key;
+            var property = properties.FirstOrDefault(p => string.Equals(p.Name, key, StringComparison.OrdinalIgnoreCase));
+            if (property == null)
+            {
+                problems.Add(new ConfigCheck(path, false, "Unknown setting"));
+                continue;
+            }
+
+            var propertyType = property.PropertyType;
+            if (value is JsonObject child && IsOptionsType(propertyType))
+            {
+                CollectUnknownKeys(child, propertyType, path + ".", problems);
+            }
+            else if (value is JsonArray array && propertyType.IsGenericType
+                     && typeof(IList).IsAssignableFrom(propertyType)
+                     && IsOptionsType(propertyType.GetGenericArguments()[0]))
+            {
+                for (var i = 0; i < array.Count; i++)
+                {
+                    if (array[i] is JsonObject item)
+                        CollectUnknownKeys(item, propertyType.GetGenericArguments()[0], $"{path}[{i}].", problems);
+                }
+            }
+        }
+    }
+
+    private static bool IsOptionsType(Type type) => type.IsClass && type != typeof(string) && type.Namespace == typeof(TokenTalkOptions).Namespace;
+
+    public async Task<ConfigCheck> CheckProviderAsync(TranscriptionOptions options, CancellationToken ct = default)
+    {
+        return options.Provider switch
+        {
+            "openai" => await CheckOpenAiAsync(options, ct),
+            "whisper.cpp" => CheckLocalModel(options.ModelPath),
+            var other => new ConfigCheck("Provider", false, $"Unknown provider '{other}'"),
+        };
+    }
+
+    public static ConfigCheck CheckAudioDevice(int index)
+    {
+        try
+        {
+            if (index == -1)
+                return new ConfigCheck("Microphone", WaveIn.DeviceCount > 0,
+                    WaveIn.DeviceCount > 0 ? "Default device" : "No recording devices found");
+            if (index < 0 || index >= WaveIn.DeviceCount)
+                return new ConfigCheck("Microphone", false, $"Device {index} not found");
+            return new ConfigCheck("Microphone", true, WaveIn.GetCapabilities(index).ProductName);
+        }
+        catch (Exception ex)
+        {
+            return new ConfigCheck("Microphone", false, ex.Message);
+        }
+    }
+
+    private async Task<ConfigCheck> CheckOpenAiAsync(TranscriptionOptions options, CancellationToken ct)
+    {
+        if (string.IsNullOrWhiteSpace(options.ApiKey))
+            return new ConfigCheck("OpenAI", false, "API key is empty");
+
+        try
+        {
+            // Looking up the model validates both the key and the model name
+            var client = _httpClientFactory.CreateClient("OpenAI");
+            using var request = new HttpRequestMessage(HttpMethod.Get,
+                $"https://api.openai.com/v1/models/{Uri.EscapeDataString(options.Model)}");
+            OpenAiHeaders.Apply(request.Headers, options.ApiKey, options);
+            using var response = await client.SendAsync(request, ct);
+
+            return (int)response.StatusCode switch
+            {
+                >= 200 and < 300 => new ConfigCheck("OpenAI", true, $"API key works, model '{options.Model}' available"),
+                401 => new ConfigCheck("OpenAI", false, "API key was rejected"),
+                404 => new ConfigCheck("OpenAI", false, $"Model '{options.Model}' not found"),
+                var status => new ConfigCheck("OpenAI", false, $"Unexpected response ({status})"),
+            };
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            return new ConfigCheck("OpenAI", false, $"Could not reach OpenAI: {ex.Message}");
+        }
+    }
+
+    private static ConfigCheck CheckLocalModel(string modelPath)
+    {
+        if (string.IsNullOrWhiteSpace(modelPath))
+            return new ConfigCheck("Local Model", false, "No model selected");
+        return File.Exists(modelPath)
+            ? new ConfigCheck("Local Model", true, Path.GetFileName(modelPath))
+            : new ConfigCheck("Local Model", false, $"File not found: {modelPath}");
+    }
+}
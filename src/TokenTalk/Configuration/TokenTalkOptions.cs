@@ -1,12 +1,77 @@
+using System.Text.Json.Serialization;
+
 namespace TokenTalk.Configuration;
 
 public class TokenTalkOptions
 {
-    public string Hotkey { get; set; } = "Ctrl+Shift+V";
+    // Bumped by ConfigMigrator when an older file is upgraded
+    public int ConfigVersion { get; set; } = ConfigMigrator.LatestVersion;
+    public List<HotkeyOptions> Hotkeys { get; set; } = [new()];
     public bool DeveloperMode { get; set; } = false;
+    // Name of the profile whose settings are currently in Transcription/PostProcessing; empty when none
+    public string ActiveProfile { get; set; } = "";
+    public List<ProfileOptions> Profiles { get; set; } = [];
     public AudioOptions Audio { get; set; } = new();
+    public QueueOptions Queue { get; set; } = new();
+    public ContinuousOptions Continuous { get; set; } = new();
     public TranscriptionOptions Transcription { get; set; } = new();
     public PostProcessingOptions PostProcessing { get; set; } = new();
+    public OutputOptions Output { get; set; } = new();
+    public HistoryOptions History { get; set; } = new();
+    public GoalOptions Goals { get; set; } = new();
+    public NotificationOptions Notifications { get; set; } = new();
+    public UpdateOptions Updates { get; set; } = new();
+    public TelemetryOptions Telemetry { get; set; } = new();
+    public ReportOptions Reports { get; set; } = new();
+    public StorageOptions Storage { get; set; } = new();
+    public LoggingOptions Logging { get; set; } = new();
+    public List<WebhookOptions> Webhooks { get; set; } = [];
+    public ObsidianOptions Obsidian { get; set; } = new();
+    // Transcription language per application, chosen by the window focused when recording starts
+    public List<AppLanguageOptions> AppLanguages { get; set; } = [];
+
+    // The first hotkey block; the keyboard hook currently listens for this one only
+    [JsonIgnore]
+    public HotkeyOptions PrimaryHotkey
+    {
+        get
+        {
+            if (Hotkeys.Count == 0)
+                Hotkeys.Add(new HotkeyOptions());
+            return Hotkeys[0];
+        }
+    }
+}
+
+/// <summary>
+/// One hotkey and what it does: the key combination, whether it is held or pressed twice, and
+/// optionally the profile and language its dictations use instead of the active ones.
+/// </summary>
+public class HotkeyOptions
+{
+    public string Combo { get; set; } = "Ctrl+Shift+V";
+    // "hold" records while the combo is held; "toggle" starts on one press and stops on the next;
+    // "continuous" switches hands-free dictation on and off, see ContinuousOptions
+    public string Mode { get; set; } = HotkeyModes.Hold;
+    // In hold mode, a press released sooner than this is an accidental tap and is ignored
+    public int MinHoldMs { get; set; } = 150;
+    // Empty uses the active profile
+    public string Profile { get; set; } = "";
+    // Empty uses Transcription.Language
+    public string Language { get; set; } = "";
+    // Empty uses Output.Target, e.g. a second hotkey that journals instead of pasting
+    public string Target { get; set; } = "";
+    // Dictations from this hotkey are always incognito, as if toggled on in the tray
+    public bool Incognito { get; set; }
+}
+
+public static class HotkeyModes
+{
+    public const string Hold = "hold";
+    public const string Toggle = "toggle";
+    public const string Continuous = "continuous";
+
+    public static readonly string[] All = [Hold, Toggle, Continuous];
 }
 
 public class AudioOptions
@@ -14,22 +79,341 @@ public class AudioOptions
     public int DeviceIndex { get; set; } = 0;
     public int MaxSeconds { get; set; } = 120;
     public double SilenceThreshold { get; set; } = 200;
+    // Recordings with less speech than this (by SpeechDetector) are never sent for transcription
+    // and are kept in history as "no speech" instead. 0 turns the check off
+    public int MinSpeechMs { get; set; } = 0;
+}
+
+public class QueueOptions
+{
+    // What happens when a recording starts while an earlier dictation is still processing:
+    // "queue" processes them one after another, "drop" refuses the new recording, and
+    // "parallel" transcribes concurrently but still pastes in capture order
+    public string Policy { get; set; } = QueuePolicies.Queue;
+    // A dictation still transcribing, post-processing or pasting after this many seconds is
+    // abandoned and recorded as a timeout; counted from when it leaves the queue. 0 disables
+    public int TimeoutSeconds { get; set; } = 60;
+    // How long quitting waits for dictations still processing before cancelling them (they are
+    // kept in history as failed). 0 cancels right away
+    public int ShutdownWaitSeconds { get; set; } = 10;
+}
+
+public static class QueuePolicies
+{
+    public const string Queue = "queue";
+    public const string Drop = "drop";
+    public const string Parallel = "parallel";
+
+    public static readonly string[] All = [Queue, Drop, Parallel];
+}
+
+/// <summary>
+/// Hands-free dictation: once switched on, the microphone stays open and every pause of
+/// <see cref="PauseMs"/> after speech ends a segment, which is transcribed and pasted while
+/// capture carries on with the next one.
+/// </summary>
+public class ContinuousOptions
+{
+    public int PauseMs { get; set; } = 800;
+    // Normalized RMS (0–1) of a 50 ms buffer above which it counts as speech
+    public double SpeechThreshold { get; set; } = 0.02;
+    // A segment is cut here even without a pause; audio without speech is discarded instead
+    public int MaxSegmentSeconds { get; set; } = 30;
 }
 
 public class TranscriptionOptions
 {
     public string Provider { get; set; } = "openai";
     public string Model { get; set; } = "whisper-1";
+    // "auto" or empty lets the provider detect the language; history then records what it heard
     public string Language { get; set; } = "auto";
     public string Prompt { get; set; } = "";
     public string ApiKey { get; set; } = "";
+    // Sent as OpenAI-Organization and OpenAI-Project, for keys scoped to an organization or project
+    public string Organization { get; set; } = "";
+    public string Project { get; set; } = "";
+    // Added to every OpenAI request, replacing a standard header of the same name; for gateways
+    // that want their own auth header. Values are treated as secrets on export
+    public Dictionary<string, string> Headers { get; set; } = new();
     // Path to local GGML model file, used when Provider = "whisper.cpp"
     public string ModelPath { get; set; } = "";
+    // Experimental: sends every recording to OpenAI (Model) and whisper.cpp (ModelPath) at once
+    // and uses whichever succeeds first, ignoring Provider; both results are logged
+    public bool Race { get; set; } = false;
+    // Provider to use while Provider's circuit is open ("openai" or "whisper.cpp"); empty fails
+    // those dictations at once instead
+    public string Fallback { get; set; } = "";
+    // Consecutive failures that open a provider's circuit; 0 keeps calling it regardless
+    public int CircuitFailures { get; set; } = 3;
+    // How long an open circuit refuses calls before letting a trial one through
+    public int CircuitCooldownSeconds { get; set; } = 60;
+    // Interval of the request that keeps the connection to OpenAI open between dictations; 0 lets
+    // it close when idle
+    public int KeepWarmSeconds { get; set; } = 240;
+    // Words the provider was less sure of than this (0-1) are flagged in history; 0 turns flagging
+    // off. Only whisper.cpp and the gpt-4o transcribe models report confidence
+    public double LowConfidenceThreshold { get; set; } = 0.5;
 }
 
 public class PostProcessingOptions
 {
     public bool Commands { get; set; } = true;
     public string DictionaryFile { get; set; } = "";
+    // Grammar correction as the last stage: "" for none, "languagetool" for a LanguageTool server
+    public string GrammarProvider { get; set; } = GrammarProviders.None;
+    public LanguageToolOptions LanguageTool { get; set; } = new();
+    // Start the grammar check on the raw transcript while the local stages run. Saves the round
+    // trip's worth of latency; edits next to text the local stages changed cost a second check
+    public bool ConcurrentStages { get; set; }
+}
+
+public static class GrammarProviders
+{
+    public const string None = "";
+    public const string LanguageTool = "languagetool";
+
+    public static readonly string[] All = [None, LanguageTool];
+}
+
+public class LanguageToolOptions
+{
+    // Base URL of the server; the default is where a locally started server listens
+    public string Url { get; set; } = "http://localhost:8081";
+    // Rule ids to skip, e.g. "UPPERCASE_SENTENCE_START" when dictating into the middle of a sentence
+    public List<string> DisabledRules { get; set; } = [];
+}
+
+public class OutputOptions
+{
+    // Where dictations go: "app" pastes into the focused app as set by Mode, "file" appends them
+    // to FilePath, "url" POSTs them to Url. Hotkeys can override it
+    public string Target { get; set; } = OutputTargets.App;
+    // Markdown file the "file" target appends to, one "## HH:mm" heading per dictation; {date}
+    // is replaced with today's date, so "%USERPROFILE%\Notes\{date}.md" keeps a daily note
+    public string FilePath { get; set; } = "";
+    // Receives {"text", "timestamp", "language", "profile", "machine"} as JSON for the "url" target
+    public string Url { get; set; } = "";
+    // "paste" types the text with a simulated Ctrl+V and restores the clipboard, "clipboard" only
+    // copies it (for apps that block synthetic input), "both" pastes and leaves it on the clipboard
+    public string Mode { get; set; } = OutputModes.Paste;
+    // Collects dictations in a draft instead of pasting each one; the draft is pasted when a
+    // dictation ends with SendPhrase or the send-buffer action runs
+    public bool Accumulate { get; set; } = false;
+    // Spoken at the end of a dictation to paste the draft; empty disables the spoken trigger
+    public string SendPhrase { get; set; } = "send it";
+    // Paste mode only: types a provisional transcript into the focused field while recording,
+    // re-transcribing the audio so far every LiveIntervalMs, and corrects it to the final text.
+    // Each interval is a full transcription request, so cloud providers bill for the repeats
+    public bool TypeWhileSpeaking { get; set; } = false;
+    // Shows each text in a popup to accept, edit or discard before it is pasted, for targets
+    // where a wrong paste can't be taken back (chat boxes that send on Enter)
+    public bool Confirm { get; set; } = false;
+    // A dictation with the same text as the paste this many seconds before is not pasted again,
+    // since that is usually a double hotkey press; repaste-last still pastes it. 0 turns it off
+    public int DuplicateGuardSeconds { get; set; } = 5;
+    // Reads the focused field back after pasting to check the text arrived; when it didn't, the
+    // text is left on the clipboard and a notification says so. Overridable per app
+    public bool Verify { get; set; } = false;
+    public int LiveIntervalMs { get; set; } = 1500;
+    // Wait after putting the text on the clipboard, before sending Ctrl+V
+    public int PrePasteDelayMs { get; set; } = 50;
+    // Wait after Ctrl+V for the target app to read the clipboard
+    public int PostPasteDelayMs { get; set; } = 100;
+    // Extra wait before the original clipboard is put back; remote desktop clients may read it late
+    public int RestoreDelayMs { get; set; } = 0;
+    // How undo-last removes the last dictation: "backspace" deletes it character by character,
+    // "ctrl-z" sends the app's own undo (safer in editors that auto-indent or complete)
+    public string UndoMode { get; set; } = UndoModes.Backspace;
+    // Timing, undo and incognito overrides for specific apps, matched against the foreground process name
+    public List<AppOutputOptions> Apps { get; set; } = [];
+}
+
+public class AppOutputOptions
+{
+    // Process name without ".exe", e.g. "mstsc"; case-insensitive
+    public string Process { get; set; } = "";
+    // Unset values fall back to the Output defaults
+    public int? PrePasteDelayMs { get; set; }
+    public int? PostPasteDelayMs { get; set; }
+    public int? RestoreDelayMs { get; set; }
+    public string? UndoMode { get; set; }
+    public bool? Verify { get; set; }
+    // Every dictation into this app is incognito, e.g. for a password manager
+    public bool Incognito { get; set; }
+}
+
+public static class UndoModes
+{
+    public const string Backspace = "backspace";
+    public const string CtrlZ = "ctrl-z";
+
+    public static readonly string[] All = [Backspace, CtrlZ];
+}
+
+public class AppLanguageOptions
+{
+    // Process name without ".exe", e.g. "slack"; case-insensitive
+    public string Process { get; set; } = "";
+    public string Language { get; set; } = "";
+}
+
+public static class OutputTargets
+{
+    public const string App = "app";
+    public const string File = "file";
+    public const string Url = "url";
+
+    public static readonly string[] All = [App, File, Url];
+}
+
+public static class OutputModes
+{
+    public const string Paste = "paste";
+    public const string Clipboard = "clipboard";
+    public const string Both = "both";
+
+    public static readonly string[] All = [Paste, Clipboard, Both];
+}
+
+/// <summary>
+/// A named set of transcription and post-processing settings. Switching copies them into the
+/// top-level sections, so the rest of the app only ever reads those.
+/// </summary>
+public class ProfileOptions
+{
+    public string Name { get; set; } = "";
+    public TranscriptionOptions Transcription { get; set; } = new();
+    public PostProcessingOptions PostProcessing { get; set; } = new();
+}
+
+public class HistoryOptions
+{
+    // 0 keeps dictations forever
+    public int MaxAgeDays { get; set; } = 0;
+    // 0 means no row limit
+    public int MaxCount { get; set; } = 0;
+    // Encrypts transcribed_text and error_message of new rows with a DPAPI-protected key
+    public bool EncryptText { get; set; } = false;
+    // Deleted dictations stay restorable for this many days before being purged
+    public int TrashDays { get; set; } = 7;
+    // Dictations closer together than this are grouped into one session
+    public int SessionGapMinutes { get; set; } = 5;
+}
+
+public class GoalOptions
+{
+    // 0 disables the words target
+    public int DailyWords { get; set; } = 0;
+    // 0 disables the dictations target
+    public int DailyDictations { get; set; } = 0;
+    // Show a tray notification the first time the daily goal is reached each day
+    public bool NotifyOnGoal { get; set; } = true;
+}
+
+public class NotificationOptions
+{
+    // Enabled notifiers by name: "toast", "sound" and/or "webhook"
+    public List<string> Notifiers { get; set; } = ["toast", "webhook"];
+    // Failed transcriptions and paste errors
+    public bool OnError { get; set; } = true;
+    // A health probe finds the provider unreachable or another subsystem down
+    public bool OnHealthProblem { get; set; } = true;
+    // A preview of each pasted dictation
+    public bool OnTranscription { get; set; } = false;
+}
+
+public class UpdateOptions
+{
+    // Looks for a newer GitHub release at startup and daily; nothing is downloaded automatically
+    public bool CheckForUpdates { get; set; } = true;
+}
+
+public class TelemetryOptions
+{
+    // Off unless the user opts in. Only daily counts, latency percentiles and error categories
+    // are sent, never text, device or machine names
+    public bool Enabled { get; set; } = false;
+    public string Endpoint { get; set; } = "";
+}
+
+public class ReportOptions
+{
+    // Every Monday, writes the previous week's summary to the "reports" folder next to the config
+    public bool Weekly { get; set; } = false;
+    // Typing speed the dictated words are measured against for the time-saved estimate
+    public int TypingWpm { get; set; } = 40;
+    // Price per minute of audio by model, for the cost estimate; unlisted models (local ones) are free
+    public Dictionary<string, double> CostPerMinute { get; set; } = new()
+    {
+        ["whisper-1"] = 0.006,
+        ["gpt-4o-transcribe"] = 0.006,
+        ["gpt-4o-mini-transcribe"] = 0.003,
+    };
+    // Each report is also POSTed here as JSON; empty keeps them local
+    public string WebhookUrl { get; set; } = "";
+}
+
+public class StorageOptions
+{
+    // "sqlite" (local file) or "postgres" (shared database); changes apply on restart
+    public string Provider { get; set; } = "sqlite";
+    // Npgsql connection string, used when Provider = "postgres"
+    public string ConnectionString { get; set; } = "";
+}
+
+// Read at startup; changes apply on restart
+public class LoggingOptions
+{
+    // Minimum level: Trace, Debug, Information, Warning, Error or Critical; --log-level overrides it
+    public string Level { get; set; } = "Information";
+    // Log file, relative to the config directory unless absolute; empty disables file logging
+    public string File { get; set; } = "logs/tokentalk.log";
+    // Format of the log file: "text", or "json" with one object per line for log shippers
+    public string Format { get; set; } = LogFormats.Text;
+    public int MaxSizeMb { get; set; } = 10;
+    // Rotated files kept next to the log (tokentalk.log.1, .2, ...)
+    public int MaxBackups { get; set; } = 3;
+    public bool Console { get; set; } = true;
+}
+
+public static class LogFormats
+{
+    public const string Text = "text";
+    public const string Json = "json";
+    public static readonly string[] All = [Text, Json];
+}
+
+public class ObsidianOptions
+{
+    // Root folder of the vault; empty turns the export off
+    public string VaultPath { get; set; } = "";
+    // Daily notes folder inside the vault, as set in Obsidian's Daily notes plugin; empty is the vault root
+    public string DailyNotesFolder { get; set; } = "";
+    // .NET date format of the note names; Obsidian's default YYYY-MM-DD is "yyyy-MM-dd"
+    public string DateFormat { get; set; } = "yyyy-MM-dd";
+    // "dictation" appends each dictation as it completes; "session" appends every session as one
+    // entry once it has ended (History.SessionGapMinutes), checked every IntervalMinutes
+    public string Mode { get; set; } = ObsidianModes.Dictation;
+    public int IntervalMinutes { get; set; } = 15;
+    // One entry; {time}, {date}, {app}, {tags}, {profile}, {words} and {text} are filled in,
+    // tags as #tag. Use \n for an entry over several lines
+    public string Template { get; set; } = "- {time} {text} {tags}";
 }
 
+public static class ObsidianModes
+{
+    public const string Dictation = "dictation";
+    public const string Session = "session";
+
+    public static readonly string[] All = [Dictation, Session];
+}
+
+public class WebhookOptions
+{
+    public string Url { get; set; } = "";
+    // When set, the body is signed with HMAC-SHA256 in the X-TokenTalk-Signature header
+    public string Secret { get; set; } = "";
+    // "dictation.completed" and/or "dictation.failed"; empty sends both
+    public List<string> Events { get; set; } = [];
+    public bool Enabled { get; set; } = true;
+}
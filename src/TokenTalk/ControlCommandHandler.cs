@@ -0,0 +1,425 @@
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+using TokenTalk.Platform;
+using TokenTalk.PostProcessing;
+using TokenTalk.Storage;
+
+namespace TokenTalk;
+
+/// <summary>
+/// Executes commands received on the control pipe. Replies are a single line: "ok",
+/// "error: ..." or compact JSON.
+/// </summary>
+/// <remarks>
+/// Named actions (<c>action &lt;name&gt;</c>) are the stable surface for button software such as
+/// Stream Deck or AutoHotkey; <c>actions</c> lists them so clients can discover what is available.
+/// </remarks>
+public class ControlCommandHandler
+{
+    private const int MaxHistoryResults = 50;
+
+    public static readonly JsonSerializerOptions JsonOptions = new()
+    {
+        PropertyNamingPolicy = JsonNamingPolicy.CamelCase,
+        Converters = { new JsonStringEnumConverter(JsonNamingPolicy.CamelCase) },
+    };
+
+    public record NamedAction(string Name, string Description, Func<CancellationToken, Task<bool>> RunAsync);
+
+    private readonly Agent _agent;
+    private readonly HealthMonitor _health;
+    private readonly DictationRepository _repository;
+    private readonly ConfigManager _configManager;
+    private readonly DataWiper _wiper;
+    private readonly HistoryReprocessor _reprocessor;
+    private readonly HttpConnectionStats _httpStats;
+    private readonly WeeklyReporter _reports;
+    private readonly HistoryDeduplicator _deduplicator;
+    private readonly List<NamedAction> _actions;
+    // One-time token a client must echo back to wipe data, and when it stops being accepted
+    private string? _wipeToken;
+    private DateTime _wipeTokenExpires;
+
+    public ControlCommandHandler(Agent agent, HealthMonitor health, DictationRepository repository, ConfigManager configManager, DataWiper wiper,
+        HistoryReprocessor reprocessor, HttpConnectionStats httpStats, WeeklyReporter reports,
+        HistoryDeduplicator deduplicator)
+    {
+        _agent = agent;
+        _health = health;
+        _repository = repository;
+        _configManager = configManager;
+        _wiper = wiper;
+        _reprocessor = reprocessor;
+        _httpStats = httpStats;
+        _reports = reports;
+        _deduplicator = deduplicator;
+        _actions =
+        [
+            new("toggle-recording", "Start recording, or stop and transcribe", _ => Task.FromResult(_agent.ToggleRecording())),
+            new("start-recording", "Start recording", _ => Task.FromResult(_agent.StartRecording())),
+            new("stop-recording", "Stop recording and transcribe", _ => Task.FromResult(_agent.StopRecording())),
+            new("cancel-recording", "Stop recording and discard the audio", _ => Task.FromResult(_agent.CancelRecording())),
+            new("toggle-continuous", "Start or stop hands-free dictation that pastes at each pause", _ => Task.FromResult(_agent.SetContinuous(!_agent.IsContinuous))),
+            new("toggle-incognito", "Turn incognito dictation (nothing saved or logged) on or off", _ => Task.FromResult(ToggleIncognito())),
+            new("repaste-last", "Paste the last dictated text again", _agent.RepasteLastAsync),
+            new("undo-last", "Remove the last pasted dictation from the focused window", _agent.UndoLastAsync),
+            new("send-draft", "Paste the accumulated draft", _agent.SendDraftAsync),
+            new("clear-draft", "Discard the accumulated draft", _ => Task.FromResult(_agent.ClearDraft())),
+            new("toggle-pause", "Pause or resume dictation", _ => Task.FromResult(SetPaused(!_agent.IsPaused))),
+            new("pause", "Pause dictation", _ => Task.FromResult(SetPaused(true))),
+            new("resume", "Resume dictation", _ => Task.FromResult(SetPaused(false))),
+            new("enable-autostart", "Launch TokenTalk when you sign in to Windows", _ => Task.FromResult(SetAutostart(true))),
+            new("disable-autostart", "Stop launching TokenTalk at sign-in", _ => Task.FromResult(SetAutostart(false))),
+            new("next-profile", "Switch to the next config profile", _ => Task.FromResult(NextProfile())),
+            new("toggle-commands", "Turn spoken punctuation commands on or off", _ => Task.FromResult(ToggleCommands())),
+            new("toggle-grammar", "Turn LanguageTool grammar correction on or off", _ => Task.FromResult(ToggleGrammar())),
+        ];
+    }
+
+    public IReadOnlyList<NamedAction> Actions => _actions;
+
+    public async Task<string> HandleAsync(string command, CancellationToken ct)
+    {
+        var parts = command.Split(' ', 3, StringSplitOptions.RemoveEmptyEntries);
+        switch (parts)
+        {
+            case ["health"]:
+                // Serve the cached probe; only check inline before the first probe has finished
+                var report = _health.Latest ?? await _health.CheckAsync(ct);
+                return JsonSerializer.Serialize(report, JsonOptions);
+
+            case ["status"]:
+                var bus = _agent.Bus;
+                return JsonSerializer.Serialize(new
+                {
+                    status = bus.State,
+                    since = bus.Since,
+                    lastError = bus.LastError,
+                    // Milliseconds spent in each state the last time the agent passed through it
+                    durations = bus.LastDurations.ToDictionary(
+                        d => JsonNamingPolicy.CamelCase.ConvertName(d.Key.ToString()),
+                        d => (long)d.Value.TotalMilliseconds),
+                    recording = _agent.IsRecording,
+                    paused = _agent.IsPaused,
+                    incognito = _agent.IsIncognito,
+                    profile = _configManager.Current.ActiveProfile,
+                    grammar = _configManager.Current.PostProcessing.GrammarProvider != GrammarProviders.None,
+                    autostart = AutostartManager.IsEnabled,
+                    provider = _agent.ProviderName,
+                    health = _health.Latest?.Summary,
+                }, JsonOptions);
+
+            // Full traces include transcripts, so they are only served in developer mode
+            case ["debug", "trace"]:
+                if (!_configManager.Current.DeveloperMode)
+                    return "error: traces are only recorded in developer mode";
+                return JsonSerializer.Serialize(_agent.Traces.Recent(), JsonOptions);
+
+            case ["debug", "runtime"]:
+                if (!_configManager.Current.DeveloperMode)
+                    return "error: runtime diagnostics are only available in developer mode";
+                return JsonSerializer.Serialize(GetRuntimeInfo(), JsonOptions);
+
+            // data wipe: get a confirmation token; data wipe <token> [dictionary]: delete everything
+            case ["data", "wipe"]:
+                _wipeToken = Convert.ToHexString(System.Security.Cryptography.RandomNumberGenerator.GetBytes(4)).ToLowerInvariant();
+                _wipeTokenExpires = DateTime.UtcNow.AddMinutes(1);
+                return JsonSerializer.Serialize(new { confirm = _wipeToken, expiresInSeconds = 60 }, JsonOptions);
+
+            case ["data", "wipe", var wipeArgs]:
+                var wipeParts = wipeArgs.Split(' ', StringSplitOptions.RemoveEmptyEntries);
+                var expected = _wipeToken;
+                _wipeToken = null;
+                if (expected == null || DateTime.UtcNow > _wipeTokenExpires || wipeParts[0] != expected)
+                    return "error: invalid or expired confirmation token, request a new one with 'data wipe'";
+                var wiped = await _wiper.WipeAsync(wipeParts.Skip(1).Contains("dictionary"), ct);
+                return JsonSerializer.Serialize(wiped, JsonOptions);
+
+            case ["record", var action]:
+                return Record(action);
+
+            case ["history", "recent", var count]:
+                if (!int.TryParse(count, out var limit) || limit <= 0)
+                    return "error: count must be a positive number";
+                var (items, _) = await _repository.GetHistoryAsync(
+                    Math.Min(limit, MaxHistoryResults), 0, new HistoryFilter { Success = true }, ct);
+                return SerializeHistory(items);
+
+            // history retry <id> [paste]: transcribe a failed dictation's saved audio again
+            case ["history", "retry", var retryArgs]:
+                var retryParts = retryArgs.Split(' ', StringSplitOptions.RemoveEmptyEntries);
+                if (!long.TryParse(retryParts[0], out var retryId))
+                    return "error: id must be a number";
+                var retried = await _agent.RetryAsync(retryId, retryParts.Skip(1).Contains("paste"), ct);
+                if (retried == null)
+                    return $"error: no saved audio for dictation {retryId}";
+                return JsonSerializer.Serialize(new
+                {
+                    id = retried.Id,
+                    retryOf = retried.RetryOf,
+                    success = retried.Success,
+                    text = retried.TranscribedText,
+                    error = retried.ErrorMessage,
+                }, JsonOptions);
+
+            case ["history", "search", var query]:
+                return SerializeHistory(await _repository.SearchAsync(query, MaxHistoryResults, ct));
+
+            // history reprocess [days] [dry-run]: re-run raw transcripts through the current pipeline
+            case ["history", "reprocess"]:
+                return await ReprocessAsync(null, false, ct);
+
+            case ["history", "reprocess", var reprocessArgs]:
+                var reprocessParts = reprocessArgs.Split(' ', StringSplitOptions.RemoveEmptyEntries);
+                var dryRun = reprocessParts.Contains("dry-run");
+                var daysArg = reprocessParts.FirstOrDefault(p => p != "dry-run");
+                int? reprocessDays = null;
+                if (daysArg != null)
+                {
+                    if (!int.TryParse(daysArg, out var parsedDays) || parsedDays <= 0)
+                        return "error: days must be a positive number";
+                    reprocessDays = parsedDays;
+                }
+                return await ReprocessAsync(reprocessDays, dryRun, ct);
+
+            // history duplicates [days]: list back-to-back repeats; history dedupe [days]: trash them
+            case ["history", "duplicates" or "dedupe"]:
+                return await DeduplicateAsync(parts[1] == "dedupe", null, ct);
+
+            case ["history", "duplicates" or "dedupe", var dedupeDays]:
+                if (!int.TryParse(dedupeDays, out var duplicateDays) || duplicateDays <= 0)
+                    return "error: days must be a positive number";
+                return await DeduplicateAsync(parts[1] == "dedupe", duplicateDays, ct);
+
+            // history rate <id> up|down|clear
+            case ["history", "rate", var rateArgs]:
+                var rateParts = rateArgs.Split(' ', StringSplitOptions.RemoveEmptyEntries);
+                if (!long.TryParse(rateParts[0], out var rateId))
+                    return "error: id must be a number";
+                int? rating = rateParts.ElementAtOrDefault(1) switch
+                {
+                    "up" => 1,
+                    "down" => -1,
+                    "clear" => null,
+                    _ => 0,
+                };
+                if (rating == 0)
+                    return "error: rating must be up, down or clear";
+                try
+                {
+                    var rated = await _repository.SetRatingAsync(rateId, rating, ct);
+                    return JsonSerializer.Serialize(new { id = rated.Id, rating = rated.Rating }, JsonOptions);
+                }
+                catch (KeyNotFoundException)
+                {
+                    return $"error: dictation {rateId} not found";
+                }
+
+            case ["stats", "ratings"]:
+                return JsonSerializer.Serialize(await _repository.GetRatingStatsAsync(null, null, ct), JsonOptions);
+
+            case ["stats", "ratings", var ratingDays]:
+                if (!int.TryParse(ratingDays, out var days) || days <= 0)
+                    return "error: days must be a positive number";
+                return JsonSerializer.Serialize(await _repository.GetRatingStatsAsync(days, ct), JsonOptions);
+
+            case ["reports"]:
+                return JsonSerializer.Serialize(
+                    _reports.List().Select(r => new { week = r.Week, markdown = r.MarkdownPath, html = r.HtmlPath }), JsonOptions);
+
+            // reports weekly [weeks-ago]: build and save a report now; 0 is the week so far
+            case ["reports", "weekly"]:
+                return await WeeklyReportAsync(1, ct);
+
+            case ["reports", "weekly", var weeksAgoArg]:
+                if (!int.TryParse(weeksAgoArg, out var weeksAgo) || weeksAgo < 0)
+                    return "error: weeks-ago must be 0 or more";
+                return await WeeklyReportAsync(weeksAgo, ct);
+
+            case ["actions"]:
+                // Every profile also gets a parameterized switch-profile:<name> action
+                var profileActions = _configManager.Current.Profiles.Select(p => new
+                {
+                    name = $"switch-profile:{p.Name}",
+                    description = $"Switch to the '{p.Name}' profile",
+                });
+                return JsonSerializer.Serialize(
+                    _actions.Select(a => new { name = a.Name, description = a.Description }).Concat(profileActions));
+
+            case ["action", var name] when name.StartsWith("switch-profile:", StringComparison.Ordinal):
+                var profileName = name["switch-profile:".Length..];
+                return _configManager.SwitchProfile(profileName) ? "ok" : $"error: unknown profile '{profileName}'";
+
+            case ["profile"]:
+                var cfg = _configManager.Current;
+                return JsonSerializer.Serialize(new { active = cfg.ActiveProfile, profiles = cfg.Profiles.Select(p => p.Name) });
+
+            case ["profile", var profile]:
+                return _configManager.SwitchProfile(profile) ? "ok" : $"error: unknown profile '{profile}'";
+
+            case ["action", var name]:
+                var named = _actions.FirstOrDefault(a => a.Name == name);
+                if (named == null)
+                    return $"error: unknown action '{name}'";
+                return await named.RunAsync(ct) ? "ok" : $"error: action '{name}' did not apply";
+
+            default:
+                return $"error: unknown command '{command}'";
+        }
+    }
+
+    private async Task<string> DeduplicateAsync(bool remove, int? days, CancellationToken ct)
+    {
+        var from = days.HasValue ? DateTime.UtcNow.AddDays(-days.Value) : (DateTime?)null;
+        if (!remove)
+            return JsonSerializer.Serialize(await _deduplicator.FindAsync(from, ct), JsonOptions);
+        return JsonSerializer.Serialize(new { removed = await _deduplicator.RemoveAsync(from, ct) }, JsonOptions);
+    }
+
+    private async Task<string> WeeklyReportAsync(int weeksAgo, CancellationToken ct)
+    {
+        var report = await _reports.BuildAsync(WeeklyReporter.WeekStart(weeksAgo), ct);
+        var path = await _reports.SaveAsync(report, ct);
+        return JsonSerializer.Serialize(new { report, markdown = path, html = Path.ChangeExtension(path, ".html") }, JsonOptions);
+    }
+
+    private async Task<string> ReprocessAsync(int? days, bool dryRun, CancellationToken ct)
+    {
+        try
+        {
+            var from = days.HasValue ? DateTime.UtcNow.AddDays(-days.Value) : (DateTime?)null;
+            return JsonSerializer.Serialize(await _reprocessor.ReprocessAsync(from, dryRun, ct), JsonOptions);
+        }
+        catch (InvalidOperationException ex)
+        {
+            return $"error: {ex.Message}";
+        }
+    }
+
+    // Enough to tell a leak or thread starvation from a slow provider; for deeper profiling attach
+    // dotnet-counters or dotnet-trace to the process
+    private object GetRuntimeInfo()
+    {
+        using var process = System.Diagnostics.Process.GetCurrentProcess();
+        var gc = GC.GetGCMemoryInfo();
+        ThreadPool.GetAvailableThreads(out var availableWorkers, out _);
+        ThreadPool.GetMaxThreads(out var maxWorkers, out _);
+        return new
+        {
+            uptimeSeconds = (long)(DateTime.Now - process.StartTime).TotalSeconds,
+            workingSetBytes = process.WorkingSet64,
+            privateBytes = process.PrivateMemorySize64,
+            threads = process.Threads.Count,
+            threadPool = new
+            {
+                threads = ThreadPool.ThreadCount,
+                busyWorkers = maxWorkers - availableWorkers,
+                pendingWorkItems = ThreadPool.PendingWorkItemCount,
+            },
+            gc = new
+            {
+                heapBytes = GC.GetTotalMemory(forceFullCollection: false),
+                totalAllocatedBytes = GC.GetTotalAllocatedBytes(),
+                committedBytes = gc.TotalCommittedBytes,
+                fragmentedBytes = gc.FragmentedBytes,
+                gen0Collections = GC.CollectionCount(0),
+                gen1Collections = GC.CollectionCount(1),
+                gen2Collections = GC.CollectionCount(2),
+                pauseTimePercentage = gc.PauseTimePercentage,
+                totalPauseMs = (long)GC.GetTotalPauseDuration().TotalMilliseconds,
+            },
+            audioBufferBytes = _agent.AudioBufferBytes,
+            dictationsInFlight = _agent.InFlight,
+            http = new
+            {
+                requests = _httpStats.Requests,
+                connectionsOpened = _httpStats.ConnectionsOpened,
+                reusedRequests = _httpStats.ReusedRequests,
+                lastWarmUp = _httpStats.LastWarmUp,
+            },
+        };
+    }
+
+    private string Record(string action)
+    {
+        bool? changed = action switch
+        {
+            "start" => _agent.StartRecording(),
+            "stop" => _agent.StopRecording(),
+            "cancel" => _agent.CancelRecording(),
+            "toggle" => _agent.ToggleRecording(),
+            _ => null,
+        };
+        return changed switch
+        {
+            null => $"error: unknown record action '{action}'",
+            true => "ok",
+            false when _agent.IsPaused => "error: dictation is paused",
+            false => _agent.IsRecording ? "error: already recording" : "error: not recording",
+        };
+    }
+
+    private bool ToggleIncognito()
+    {
+        _agent.SetIncognito(!_agent.IsIncognito);
+        return true;
+    }
+
+    private bool SetPaused(bool paused)
+    {
+        _agent.SetPaused(paused);
+        return true;
+    }
+
+    private static bool SetAutostart(bool enabled)
+    {
+        AutostartManager.SetEnabled(enabled);
+        return true;
+    }
+
+    private bool NextProfile()
+    {
+        var cfg = _configManager.Current;
+        if (cfg.Profiles.Count == 0)
+            return false;
+        var index = cfg.Profiles.FindIndex(p => string.Equals(p.Name, cfg.ActiveProfile, StringComparison.OrdinalIgnoreCase));
+        return _configManager.SwitchProfile(cfg.Profiles[(index + 1) % cfg.Profiles.Count].Name);
+    }
+
+    private bool ToggleCommands()
+    {
+        var cfg = _configManager.Snapshot();
+        cfg.PostProcessing.Commands = !cfg.PostProcessing.Commands;
+        _configManager.Save(cfg);
+        return true;
+    }
+
+    // Saved like any settings change, so the choice survives a restart
+    private bool ToggleGrammar()
+    {
+        var cfg = _configManager.Snapshot();
+        cfg.PostProcessing.GrammarProvider = cfg.PostProcessing.GrammarProvider == GrammarProviders.None
+            ? GrammarProviders.LanguageTool
+            : GrammarProviders.None;
+        _configManager.Save(cfg);
+        return true;
+    }
+
+    private static string SerializeHistory(IEnumerable<Dictation> items) =>
+        JsonSerializer.Serialize(items.Select(d => new
+        {
+            id = d.Id,
+            timestamp = d.Timestamp,
+            text = d.TranscribedText,
+            words = d.WordCount,
+            provider = d.Provider,
+            tags = DictationRepository.ParseTags(d.Tags),
+            rating = d.Rating,
+            app = d.App,
+            injectionStatus = d.InjectionStatus,
+            lowConfidence = DictationRepository.ParseLowConfidence(d.LowConfidence)
+                .Select(w => new { word = w.Word, confidence = w.Confidence }),
+        }));
+}
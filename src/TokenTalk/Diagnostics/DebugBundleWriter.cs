@@ -0,0 +1,96 @@
+using System.IO.Compression;
+using System.Text.Json;
+using System.Text.Json.Serialization;
+using NAudio.Wave;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Diagnostics;
+
+/// <summary>
+/// Writes a zip a user can attach to a bug report: environment and build details, the config
+/// with secrets masked, schema version, a stats summary and the latest health report.
+/// No transcripts are included.
+/// </summary>
+public class DebugBundleWriter
+{
+    private static readonly JsonSerializerOptions JsonOptions = new()
+    {
+        WriteIndented = true,
+        Converters = { new JsonStringEnumConverter() },
+    };
+
+    private readonly ConfigManager _configManager;
+    private readonly DictationRepository _repository;
+    private readonly HealthMonitor _health;
+
+    public DebugBundleWriter(ConfigManager configManager, DictationRepository repository, HealthMonitor health)
+    {
+        _configManager = configManager;
+        _repository = repository;
+        _health = health;
+    }
+
+    public async Task WriteAsync(string path, CancellationToken ct = default)
+    {
+        await using var file = File.Create(path);
+        using var zip = new ZipArchive(file, ZipArchiveMode.Create);
+
+        await AddJsonAsync(zip, "environment.json", GetEnvironment());
+        await AddJsonAsync(zip, "config.json", ConfigTransfer.Sanitize(_configManager.Snapshot()));
+        await AddJsonAsync(zip, "database.json", await GetDatabaseInfoAsync(ct));
+        await AddJsonAsync(zip, "health.json", _health.Latest ?? await _health.CheckAsync(ct));
+    }
+
+    private static object GetEnvironment() => new
+    {
+        BuildInfo.Version,
+        BuildInfo.Commit,
+        BuildInfo.BuildDate,
+        BuildInfo.Runtime,
+        BuildInfo.OperatingSystem,
+        Architecture = System.Runtime.InteropServices.RuntimeInformation.ProcessArchitecture.ToString(),
+        AudioBackend = "NAudio WaveIn",
+        AudioDevices = GetAudioDevices(),
+        GeneratedAt = DateTime.UtcNow,
+    };
+
+    private static List<string> GetAudioDevices()
+    {
+        try
+        {
+            return Enumerable.Range(0, WaveIn.DeviceCount)
+                .Select(i => $"{i}: {WaveIn.GetCapabilities(i).ProductName}")
+                .ToList();
+        }
+        catch (Exception ex)
+        {
+            return [$"Could not enumerate devices: {ex.Message}"];
+        }
+    }
+
+    private async Task<object> GetDatabaseInfoAsync(CancellationToken ct)
+    {
+        try
+        {
+            return new
+            {
+                SchemaVersion = await _repository.GetSchemaVersionAsync(),
+                LatestSchemaVersion = SchemaMigrator.LatestVersion,
+                Last30Days = await _repository.GetOverallStatsAsync(30, ct),
+                Providers = await _repository.GetProviderStatsAsync(30, ct),
+                Failures = await _repository.GetErrorCategoryStatsAsync(30, ct),
+            };
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            return new { Error = ex.Message };
+        }
+    }
+
+    private static async Task AddJsonAsync(ZipArchive zip, string name, object value)
+    {
+        await using var stream = zip.CreateEntry(name).Open();
+        await JsonSerializer.SerializeAsync(stream, value, value.GetType(), JsonOptions);
+    }
+}
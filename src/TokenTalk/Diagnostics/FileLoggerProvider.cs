@@ -0,0 +1,208 @@
+using System.Text;
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+
+namespace TokenTalk.Diagnostics;
+
+/// <summary>
+/// Appends log lines to a file, so a tray app started without a console still leaves a trace.
+/// When the file passes <c>maxBytes</c> it is renamed to <c>.1</c> (older ones shift to
+/// <c>.2</c>, <c>.3</c>, ...) and only <c>maxBackups</c> of those are kept.
+/// In JSON mode every line is an object with <c>timestamp</c>, <c>level</c>, <c>component</c>
+/// (the logger category), <c>message</c>, the message template's arguments and any scope values.
+/// </summary>
+public sealed class FileLoggerProvider : ILoggerProvider, ISupportExternalScope
+{
+    private readonly string _path;
+    private readonly long _maxBytes;
+    private readonly int _maxBackups;
+    private readonly bool _json;
+    private readonly object _lock = new();
+    private StreamWriter? _writer;
+    private IExternalScopeProvider? _scopes;
+
+    public FileLoggerProvider(string path, long maxBytes, int maxBackups, bool json = false)
+    {
+        _path = path;
+        _maxBytes = maxBytes;
+        _maxBackups = maxBackups;
+        _json = json;
+        Directory.CreateDirectory(Path.GetDirectoryName(path)!);
+    }
+
+    public void SetScopeProvider(IExternalScopeProvider scopeProvider) => _scopes = scopeProvider;
+
+    public ILogger CreateLogger(string categoryName) => new FileLogger(this, categoryName);
+
+    private void Write(string line)
+    {
+        lock (_lock)
+        {
+            try
+            {
+                _writer ??= Open();
+                if (_writer.BaseStream.Length > _maxBytes)
+                {
+                    _writer.Dispose();
+                    Rotate();
+                    _writer = Open();
+                }
+                _writer.WriteLine(line);
+            }
+            catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+            {
+                // Logging must never take the app down; try again with the next line
+                _writer?.Dispose();
+                _writer = null;
+            }
+        }
+    }
+
+    private StreamWriter Open() =>
+        new(new FileStream(_path, FileMode.Append, FileAccess.Write, FileShare.ReadWrite | FileShare.Delete), Encoding.UTF8)
+        {
+            AutoFlush = true,
+        };
+
+    private void Rotate()
+    {
+        if (_maxBackups == 0)
+        {
+            File.Delete(_path);
+            return;
+        }
+        File.Delete($"{_path}.{_maxBackups}");
+        for (var i = _maxBackups - 1; i >= 1; i--)
+        {
+            if (File.Exists($"{_path}.{i}"))
+                File.Move($"{_path}.{i}", $"{_path}.{i + 1}");
+        }
+        File.Move(_path, $"{_path}.1");
+    }
+
+    /// <summary>Empties the log and deletes its rotated backups.</summary>
+    public void Clear()
+    {
+        lock (_lock)
+        {
+            _writer?.Dispose();
+            _writer = null;
+            for (var i = 1; i <= _maxBackups; i++)
+                File.Delete($"{_path}.{i}");
+            File.WriteAllText(_path, "");
+        }
+    }
+
+    public void Dispose()
+    {
+        lock (_lock)
+        {
+            _writer?.Dispose();
+            _writer = null;
+        }
+    }
+
+    private sealed class FileLogger(FileLoggerProvider provider, string category) : ILogger
+    {
+        public IDisposable? BeginScope<TState>(TState state) where TState : notnull =>
+            provider._scopes?.Push(state);
+
+        // Levels are filtered by the logger factory
+        public bool IsEnabled(LogLevel logLevel) => logLevel != LogLevel.None;
+
+        public void Log<TState>(LogLevel logLevel, EventId eventId, TState state, Exception? exception,
+            Func<TState, Exception?, string> formatter)
+        {
+            if (!IsEnabled(logLevel))
+                return;
+
+            var message = formatter(state, exception);
+            if (provider._json)
+            {
+                provider.Write(ToJson(logLevel, message, state, exception));
+                return;
+            }
+            var line = $"{DateTime.Now:yyyy-MM-dd HH:mm:ss.fff} {Abbreviate(logLevel)} {category}: {message}";
+            if (exception != null)
+                line += Environment.NewLine + exception;
+            provider.Write(line);
+        }
+
+        private string ToJson<TState>(LogLevel logLevel, string message, TState state, Exception? exception)
+        {
+            using var buffer = new MemoryStream();
+            using (var json = new Utf8JsonWriter(buffer))
+            {
+                json.WriteStartObject();
+                json.WriteString("timestamp", DateTimeOffset.Now);
+                json.WriteString("level", logLevel.ToString());
+                json.WriteString("component", category);
+                json.WriteString("message", message);
+                if (exception != null)
+                    json.WriteString("exception", exception.ToString());
+
+                // Template arguments ({Provider}, {Id}, ...) and scope values become fields
+                var fields = new Dictionary<string, object?>();
+                provider._scopes?.ForEachScope((scope, f) => AddFields(scope, f), fields);
+                AddFields(state, fields);
+                foreach (var (key, value) in fields)
+                {
+                    json.WritePropertyName(key);
+                    WriteValue(json, value);
+                }
+                json.WriteEndObject();
+            }
+            return Encoding.UTF8.GetString(buffer.ToArray());
+        }
+
+        // Numbers and booleans stay queryable as such; anything else is written as its string form
+        private static void WriteValue(Utf8JsonWriter json, object? value)
+        {
+            switch (value)
+            {
+                case null:
+                    json.WriteNullValue();
+                    break;
+                case bool b:
+                    json.WriteBooleanValue(b);
+                    break;
+                case int or long or short or byte or uint:
+                    json.WriteNumberValue(Convert.ToInt64(value));
+                    break;
+                case double d when double.IsFinite(d):
+                    json.WriteNumberValue(d);
+                    break;
+                case float f when float.IsFinite(f):
+                    json.WriteNumberValue(f);
+                    break;
+                default:
+                    json.WriteStringValue(value.ToString());
+                    break;
+            }
+        }
+
+        private static void AddFields(object? state, Dictionary<string, object?> fields)
+        {
+            if (state is not IEnumerable<KeyValuePair<string, object?>> pairs)
+                return;
+            foreach (var (key, value) in pairs)
+            {
+                // The fields the writer sets itself win over same-named arguments
+                if (key != "{OriginalFormat}" && key is not ("timestamp" or "level" or "component" or "message" or "exception"))
+                    fields[key] = value;
+            }
+        }
+
+        // Same abbreviations as the console logger
+        private static string Abbreviate(LogLevel level) => level switch
+        {
+            LogLevel.Trace => "trce",
+            LogLevel.Debug => "dbug",
+            LogLevel.Information => "info",
+            LogLevel.Warning => "warn",
+            LogLevel.Error => "fail",
+            LogLevel.Critical => "crit",
+            _ => "none",
+        };
+    }
+}
@@ -0,0 +1,69 @@
+using TokenTalk.Storage;
+
+namespace TokenTalk.Diagnostics;
+
+/// <summary>Output of one post-processing stage, with the time since the pipeline started.</summary>
+public record TraceStep(string Stage, string Text, long ElapsedMs);
+
+/// <summary>Everything known about one dictation's trip through the agent, text included.</summary>
+public record DictationTrace(
+    long DictationId,
+    DateTime FinishedAt,
+    string App,
+    string Provider,
+    string Model,
+    string Language,
+    long AudioDurationMs,
+    long AudioSizeBytes,
+    int AudioSampleRate,
+    string? RawText,
+    IReadOnlyList<TraceStep> Stages,
+    string FinalText,
+    long TranscriptionMs,
+    long InjectionMs,
+    long TotalMs,
+    bool Success,
+    string? Error,
+    string? ErrorCategory)
+{
+    public static DictationTrace From(Dictation d, string app, IReadOnlyList<TraceStep> stages) => new(
+        d.Id, DateTime.UtcNow, app, d.Provider, d.Model, d.Language,
+        d.RecordingDurationMs, d.AudioSizeBytes, d.AudioSampleRate,
+        d.RawText, stages, d.TranscribedText,
+        d.TranscriptionLatencyMs, d.InjectionLatencyMs, d.TotalLatencyMs,
+        d.Success, d.ErrorMessage, d.ErrorCategory);
+}
+
+/// <summary>
+/// Keeps the traces of the last <see cref="Capacity"/> dictations in memory, for working out why
+/// an output came out wrong. Only filled in developer mode and never written to disk.
+/// </summary>
+public class FlightRecorder
+{
+    public const int Capacity = 20;
+
+    private readonly Queue<DictationTrace> _traces = new();
+
+    public void Add(DictationTrace trace)
+    {
+        lock (_traces)
+        {
+            _traces.Enqueue(trace);
+            while (_traces.Count > Capacity)
+                _traces.Dequeue();
+        }
+    }
+
+    /// <summary>Newest first.</summary>
+    public List<DictationTrace> Recent()
+    {
+        lock (_traces)
+            return [.. _traces.Reverse()];
+    }
+
+    public void Clear()
+    {
+        lock (_traces)
+            _traces.Clear();
+    }
+}
@@ -0,0 +1,116 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Diagnostics;
+
+public enum HealthLevel { Healthy, Degraded, Unhealthy }
+
+public record ComponentHealth(string Name, HealthLevel Level, string Detail);
+
+public record HealthReport(HealthLevel Level, DateTime CheckedAt, IReadOnlyList<ComponentHealth> Components)
+{
+    /// <summary>Short one-line description, e.g. "degraded: provider unreachable".</summary>
+    public string Summary => Level == HealthLevel.Healthy
+        ? "healthy"
+        : $"{Level.ToString().ToLowerInvariant()}: " + string.Join(", ", Components
+            .Where(c => c.Level != HealthLevel.Healthy)
+            .Select(c => c.Name == "provider" ? "provider unreachable" : $"{c.Name} unavailable"));
+}
+
+/// <summary>
+/// Periodically probes the microphone, keyboard hook, database and transcription provider and
+/// caches the result, so health queries never wait on a network call. A subsystem that blocks
+/// dictation outright is unhealthy; an unreachable provider is only degraded since it is often
+/// transient.
+/// </summary>
+public class HealthMonitor
+{
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromSeconds(10);
+    private static readonly TimeSpan Interval = TimeSpan.FromMinutes(5);
+
+    private readonly Func<TokenTalkOptions> _getOptions;
+    private readonly ConfigValidator _validator;
+    private readonly DictationRepository _repository;
+    private readonly Func<bool> _isHotkeyActive;
+    private readonly ILogger<HealthMonitor> _logger;
+
+    public HealthReport? Latest { get; private set; }
+
+    // Raised when the overall level changes, including the first probe
+    public event EventHandler<HealthReport>? HealthChanged;
+
+    public HealthMonitor(
+        Func<TokenTalkOptions> getOptions,
+        ConfigValidator validator,
+        DictationRepository repository,
+        Func<bool> isHotkeyActive,
+        ILogger<HealthMonitor> logger)
+    {
+        _getOptions = getOptions;
+        _validator = validator;
+        _repository = repository;
+        _isHotkeyActive = isHotkeyActive;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        using var timer = new PeriodicTimer(Interval);
+        try
+        {
+            // Give the keyboard hook time to install before the first probe
+            await Task.Delay(StartupDelay, ct);
+            do
+            {
+                await CheckAsync(ct);
+            }
+            while (await timer.WaitForNextTickAsync(ct));
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    public async Task<HealthReport> CheckAsync(CancellationToken ct = default)
+    {
+        var options = _getOptions();
+        var components = new List<ComponentHealth>();
+
+        var audio = ConfigValidator.CheckAudioDevice(options.Audio.DeviceIndex);
+        components.Add(new ComponentHealth("audio", audio.Ok ? HealthLevel.Healthy : HealthLevel.Unhealthy, audio.Message));
+
+        components.Add(_isHotkeyActive()
+            ? new ComponentHealth("hotkey", HealthLevel.Healthy, options.PrimaryHotkey.Combo)
+            : new ComponentHealth("hotkey", HealthLevel.Unhealthy, "Keyboard hook is not installed"));
+
+        try
+        {
+            components.Add(await _repository.CanConnectAsync(ct)
+                ? new ComponentHealth("database", HealthLevel.Healthy, options.Storage.Provider)
+                : new ComponentHealth("database", HealthLevel.Unhealthy, "Cannot connect"));
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            components.Add(new ComponentHealth("database", HealthLevel.Unhealthy, ex.Message));
+        }
+
+        var provider = await _validator.CheckProviderAsync(options.Transcription, ct);
+        components.Add(new ComponentHealth("provider", provider.Ok ? HealthLevel.Healthy : HealthLevel.Degraded, provider.Message));
+
+        var report = new HealthReport(components.Max(c => c.Level), DateTime.UtcNow, components);
+        var changed = Latest?.Level != report.Level;
+        if (changed)
+        {
+            if (report.Level == HealthLevel.Healthy)
+                _logger.LogInformation("Health: {Level}", report.Level);
+            else
+                _logger.LogWarning("Health: {Level} ({Problems})", report.Level,
+                    string.Join("; ", components.Where(c => c.Level != HealthLevel.Healthy).Select(c => $"{c.Name}: {c.Detail}")));
+        }
+        Latest = report;
+        if (changed)
+            HealthChanged?.Invoke(this, report);
+        return report;
+    }
+}
@@ -0,0 +1,30 @@
+namespace TokenTalk.Diagnostics;
+
+/// <summary>
+/// Counts outgoing HTTP requests and the TCP connections opened for them. Requests beyond the
+/// number of connections went over a connection that was already open (warm).
+/// </summary>
+public class HttpConnectionStats
+{
+    private long _requests;
+    private long _connections;
+    private long _lastWarmUpTicks;
+
+    public long Requests => Interlocked.Read(ref _requests);
+    public long ConnectionsOpened => Interlocked.Read(ref _connections);
+    public long ReusedRequests => Math.Max(0, Requests - ConnectionsOpened);
+
+    /// <summary>UTC time of the last keep-warm request; null before the first.</summary>
+    public DateTime? LastWarmUp
+    {
+        get
+        {
+            var ticks = Interlocked.Read(ref _lastWarmUpTicks);
+            return ticks == 0 ? null : new DateTime(ticks, DateTimeKind.Utc);
+        }
+    }
+
+    public void RequestSent() => Interlocked.Increment(ref _requests);
+    public void ConnectionOpened() => Interlocked.Increment(ref _connections);
+    public void WarmedUp() => Interlocked.Exchange(ref _lastWarmUpTicks, DateTime.UtcNow.Ticks);
+}
@@ -0,0 +1,102 @@
+using System.Diagnostics;
+using System.Net.Http.Headers;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Transcription;
+
+namespace TokenTalk.Diagnostics;
+
+/// <summary>
+/// Records every request to a transcription provider while DeveloperMode is on: the request
+/// line, its headers with credentials redacted, a summary of the body (form fields, with audio
+/// reduced to its size) and the full response with the headers that matter when chasing an API
+/// problem. Dictations marked incognito get the metadata only, never the bodies.
+/// </summary>
+public sealed class ProviderTraceHandler : DelegatingHandler
+{
+    // Request ids, rate-limit state and server timings; the rest is transport noise
+    private static readonly string[] ResponseHeaderPrefixes =
+        ["x-request-id", "openai-", "x-ratelimit-", "retry-after", "content-type", "cf-ray"];
+    private static readonly string[] SecretHeaderParts = ["authorization", "key", "token", "secret", "cookie"];
+
+    private readonly string _provider;
+    private readonly ILogger _logger;
+    private readonly Func<bool> _isEnabled;
+
+    /// <param name="provider">The HTTP client name the requests are logged under.</param>
+    public ProviderTraceHandler(string provider, ILogger logger, Func<bool> isEnabled)
+    {
+        _provider = provider;
+        _logger = logger;
+        _isEnabled = isEnabled;
+    }
+
+    protected override async Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken ct)
+    {
+        if (!_isEnabled())
+            return await base.SendAsync(request, ct);
+
+        var incognito = TranscriptionContext.Incognito;
+        var requestHeaders = FormatHeaders(request.Headers, request.Content, _ => true);
+        var requestBody = incognito ? "(incognito)" : await DescribeContentAsync(request.Content, ct);
+        var stopwatch = Stopwatch.StartNew();
+        HttpResponseMessage response;
+        try
+        {
+            response = await base.SendAsync(request, ct);
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "{Provider} {Method} {Url} failed after {ElapsedMs}ms; request headers: {RequestHeaders}; request body: {RequestBody}",
+                _provider, request.Method, request.RequestUri, stopwatch.ElapsedMilliseconds, requestHeaders, requestBody);
+            throw;
+        }
+
+        // Buffered, so the caller can still read the body after it is logged
+        string responseBody = "(incognito)";
+        if (!incognito && response.Content != null)
+        {
+            await response.Content.LoadIntoBufferAsync(ct);
+            responseBody = await response.Content.ReadAsStringAsync(ct);
+        }
+        var responseHeaders = FormatHeaders(response.Headers, response.Content,
+            name => ResponseHeaderPrefixes.Any(p => name.StartsWith(p, StringComparison.OrdinalIgnoreCase)));
+
+        _logger.LogInformation("{Provider} {Method} {Url} returned {Status} in {ElapsedMs}ms; request headers: {RequestHeaders}; request body: {RequestBody}; response headers: {ResponseHeaders}; response body: {ResponseBody}",
+            _provider, request.Method, request.RequestUri, (int)response.StatusCode, stopwatch.ElapsedMilliseconds,
+            requestHeaders, requestBody, responseHeaders, responseBody);
+        return response;
+    }
+
+    private static string FormatHeaders(HttpHeaders headers, HttpContent? content, Func<string, bool> include) =>
+        string.Join(", ", headers
+            .Concat(content?.Headers ?? [])
+            .Where(h => include(h.Key))
+            .Select(h => $"{h.Key}: {(IsSecret(h.Key) ? "<redacted>" : string.Join(",", h.Value))}"));
+
+    private static bool IsSecret(string header) =>
+        SecretHeaderParts.Any(p => header.Contains(p, StringComparison.OrdinalIgnoreCase));
+
+    // Form fields are shown with their values; anything binary (the audio) only with its size
+    private static async Task<string> DescribeContentAsync(HttpContent? content, CancellationToken ct)
+    {
+        switch (content)
+        {
+            case null:
+                return "";
+            case MultipartFormDataContent form:
+                var parts = new List<string>();
+                foreach (var part in form)
+                {
+                    var name = part.Headers.ContentDisposition?.Name?.Trim('"') ?? "?";
+                    parts.Add(part is StringContent
+                        ? $"{name}={await part.ReadAsStringAsync(ct)}"
+                        : $"{name}=<{part.Headers.ContentType?.MediaType ?? "binary"}, {part.Headers.ContentLength ?? 0} bytes>");
+                }
+                return string.Join("; ", parts);
+            case StringContent:
+                return await content.ReadAsStringAsync(ct);
+            default:
+                return $"<{content.Headers.ContentType?.MediaType ?? "binary"}, {content.Headers.ContentLength ?? 0} bytes>";
+        }
+    }
+}
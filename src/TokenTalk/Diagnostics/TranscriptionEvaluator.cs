@@ -0,0 +1,125 @@
+using System.Diagnostics;
+using Microsoft.Extensions.Logging;
+using NAudio.Wave;
+using NAudio.Wave.SampleProviders;
+using TokenTalk.Audio;
+using TokenTalk.PostProcessing;
+using TokenTalk.Storage;
+using TokenTalk.Transcription;
+
+namespace TokenTalk.Diagnostics;
+
+/// <param name="Name">Shared file name of the audio and its transcript, without extension.</param>
+public record EvaluationSample(string Name, string AudioPath, string Reference);
+
+/// <summary>
+/// Scores a provider, optionally followed by the post-processing pipeline, against reference
+/// recordings. A sample is an audio file next to a <c>.txt</c> of the same name holding what was
+/// actually said; every sample gets its word error rate and latency.
+/// </summary>
+public class TranscriptionEvaluator
+{
+    private static readonly string[] AudioExtensions = [".wav", ".mp3"];
+    // What AudioRecorder captures, and what whisper.cpp requires
+    private const int SampleRate = 16000;
+
+    private readonly ITranscriptionProvider _provider;
+    private readonly string _model;
+    private readonly PostProcessingPipeline? _pipeline;
+    private readonly ILogger<TranscriptionEvaluator> _logger;
+
+    /// <param name="pipeline">Applied to the provider output; null scores the raw transcript.</param>
+    public TranscriptionEvaluator(ITranscriptionProvider provider, string model,
+        PostProcessingPipeline? pipeline, ILogger<TranscriptionEvaluator> logger)
+    {
+        _provider = provider;
+        _model = model;
+        _pipeline = pipeline;
+        _logger = logger;
+    }
+
+    /// <summary>Audio files in <paramref name="folder"/> that have a transcript, by name.</summary>
+    public static List<EvaluationSample> FindSamples(string folder)
+    {
+        return Directory.EnumerateFiles(folder)
+            .Where(f => AudioExtensions.Contains(Path.GetExtension(f), StringComparer.OrdinalIgnoreCase))
+            .Select(f => (Audio: f, Transcript: Path.ChangeExtension(f, ".txt")))
+            .Where(p => File.Exists(p.Transcript))
+            .Select(p => new EvaluationSample(
+                Path.GetFileNameWithoutExtension(p.Audio), p.Audio, File.ReadAllText(p.Transcript).Trim()))
+            .OrderBy(s => s.Name, StringComparer.OrdinalIgnoreCase)
+            .ToList();
+    }
+
+    /// <summary>
+    /// Runs every sample in turn. A provider failure is recorded on that sample (as a WER of 1)
+    /// rather than ending the run. <paramref name="onResult"/> sees each result as it is scored.
+    /// </summary>
+    public async Task<List<EvaluationResult>> RunAsync(
+        IReadOnlyList<EvaluationSample> samples, Action<EvaluationResult>? onResult = null, CancellationToken ct = default)
+    {
+        var runId = Guid.NewGuid().ToString("N");
+        var pipeline = _pipeline == null ? "" : string.Join(" + ", _pipeline.EnabledStages);
+        var results = new List<EvaluationResult>();
+
+        foreach (var sample in samples)
+        {
+            var result = new EvaluationResult
+            {
+                RunId = runId,
+                Sample = sample.Name,
+                Provider = _provider.Name,
+                Model = _model,
+                Pipeline = pipeline,
+                ReferenceText = sample.Reference,
+            };
+
+            var start = Stopwatch.GetTimestamp();
+            try
+            {
+                var text = await _provider.TranscribeAsync(LoadAudio(sample.AudioPath), ct);
+                if (_pipeline != null)
+                    text = CursorMarker.Strip(await _pipeline.ProcessAsync(text, ct));
+                result.LatencyMs = (long)Stopwatch.GetElapsedTime(start).TotalMilliseconds;
+                result.HypothesisText = text;
+
+                var wer = WordErrorRate.Compute(sample.Reference, text);
+                result.ReferenceWords = wer.ReferenceWords;
+                result.Substitutions = wer.Substitutions;
+                result.Deletions = wer.Deletions;
+                result.Insertions = wer.Insertions;
+                result.Wer = wer.Rate;
+            }
+            catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+            {
+                _logger.LogWarning(ex, "Evaluation of {Sample} failed", sample.Name);
+                var words = WordErrorRate.Compute(sample.Reference, "").ReferenceWords;
+                result.LatencyMs = (long)Stopwatch.GetElapsedTime(start).TotalMilliseconds;
+                result.ReferenceWords = words;
+                result.Deletions = words;
+                result.Wer = 1;
+                result.ErrorMessage = ex.Message;
+            }
+
+            results.Add(result);
+            onResult?.Invoke(result);
+        }
+        return results;
+    }
+
+    // Reference sets come in whatever format they were recorded in; providers get the same
+    // 16 kHz mono PCM the recorder produces
+    private static AudioSegment LoadAudio(string path)
+    {
+        using var reader = new AudioFileReader(path);
+        ISampleProvider samples = reader;
+        if (reader.WaveFormat.Channels == 2)
+            samples = samples.ToMono();
+        if (reader.WaveFormat.SampleRate != SampleRate)
+            samples = new WdlResamplingSampleProvider(samples, SampleRate);
+
+        using var wav = new MemoryStream();
+        WaveFileWriter.WriteWavFileToStream(wav, samples.ToWaveProvider16());
+        return new AudioSegment(wav.ToArray(), SampleRate, reader.TotalTime);
+    }
+}
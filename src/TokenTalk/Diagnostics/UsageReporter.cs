@@ -0,0 +1,127 @@
+using System.Globalization;
+using System.Net.Http.Json;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Diagnostics;
+
+public record ModelUsage(
+    string Provider, string Model, int Dictations, int Failures,
+    double P50TranscriptionMs, double P95TranscriptionMs, double P50TotalMs, double P95TotalMs);
+
+/// <summary>One day of usage, aggregated locally. Carries no text, names or identifiers.</summary>
+public record UsageReport(
+    string Version, string OperatingSystem, string Day,
+    int Dictations, int Failures,
+    IReadOnlyList<ModelUsage> Models,
+    IReadOnlyDictionary<string, int> Errors);
+
+/// <summary>
+/// Opt-in usage metrics: once a day, when <see cref="TelemetryOptions.Enabled"/> is set, posts
+/// the previous day's <see cref="UsageReport"/> to the configured endpoint. The day last sent
+/// is remembered in a file, so restarts don't report it twice.
+/// </summary>
+public class UsageReporter
+{
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromMinutes(2);
+    private static readonly TimeSpan Interval = TimeSpan.FromHours(1);
+
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly DictationRepository _repository;
+    private readonly Func<TelemetryOptions> _getOptions;
+    private readonly string _statePath;
+    private readonly ILogger<UsageReporter> _logger;
+
+    /// <param name="statePath">File holding the last reported day.</param>
+    public UsageReporter(IHttpClientFactory httpClientFactory, DictationRepository repository,
+        Func<TelemetryOptions> getOptions, string statePath, ILogger<UsageReporter> logger)
+    {
+        _httpClientFactory = httpClientFactory;
+        _repository = repository;
+        _getOptions = getOptions;
+        _statePath = statePath;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        // Hourly, so a day is reported soon after midnight even when the app runs for weeks
+        using var timer = new PeriodicTimer(Interval);
+        try
+        {
+            await Task.Delay(StartupDelay, ct);
+            do
+            {
+                var options = _getOptions();
+                if (options.Enabled && !string.IsNullOrWhiteSpace(options.Endpoint))
+                    await ReportYesterdayAsync(options.Endpoint, ct);
+            }
+            while (await timer.WaitForNextTickAsync(ct));
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    /// <summary>Aggregates the UTC day starting at <paramref name="day"/>.</summary>
+    public async Task<UsageReport> BuildAsync(DateTime day, CancellationToken ct = default)
+    {
+        var from = day.Date;
+        var to = from.AddDays(1);
+        var overall = await _repository.GetOverallStatsAsync(from, to, ct);
+        var models = await _repository.GetModelStatsAsync(from, to, ct);
+        var errors = await _repository.GetErrorCategoryStatsAsync(from, to, ct);
+        return new UsageReport(
+            BuildInfo.Version,
+            BuildInfo.OperatingSystem,
+            from.ToString("yyyy-MM-dd", CultureInfo.InvariantCulture),
+            overall.TotalDictations,
+            overall.FailureCount,
+            [.. models.Select(m => new ModelUsage(m.Provider, m.Model, m.TotalDictations, m.FailureCount,
+                m.P50TranscriptionMs, m.P95TranscriptionMs, m.P50TotalLatencyMs, m.P95TotalLatencyMs))],
+            errors.ToDictionary(e => e.Category, e => e.Count));
+    }
+
+    private async Task ReportYesterdayAsync(string endpoint, CancellationToken ct)
+    {
+        var day = DateTime.UtcNow.Date.AddDays(-1);
+        if (ReadLastReported() >= day)
+            return;
+
+        try
+        {
+            var report = await BuildAsync(day, ct);
+            // Idle days are not worth a request, but are marked done all the same
+            if (report.Dictations > 0)
+            {
+                var client = _httpClientFactory.CreateClient("Telemetry");
+                using var response = await client.PostAsJsonAsync(endpoint, report, ControlCommandHandler.JsonOptions, ct);
+                if (!response.IsSuccessStatusCode)
+                {
+                    _logger.LogDebug("Usage report for {Day} returned {Status}", report.Day, response.StatusCode);
+                    return;
+                }
+                _logger.LogInformation("Sent usage report for {Day}", report.Day);
+            }
+            File.WriteAllText(_statePath, day.ToString("yyyy-MM-dd", CultureInfo.InvariantCulture));
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            _logger.LogDebug(ex, "Usage report failed");
+        }
+    }
+
+    private DateTime ReadLastReported()
+    {
+        try
+        {
+            return DateTime.TryParseExact(File.ReadAllText(_statePath).Trim(), "yyyy-MM-dd",
+                CultureInfo.InvariantCulture, DateTimeStyles.None, out var day) ? day : DateTime.MinValue;
+        }
+        catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+        {
+            return DateTime.MinValue;
+        }
+    }
+}
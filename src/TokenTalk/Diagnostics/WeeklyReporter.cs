@@ -0,0 +1,260 @@
+using System.Globalization;
+using System.Net;
+using System.Net.Http.Json;
+using System.Text;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Diagnostics;
+
+public record ModelCost(string Provider, string Model, int Dictations, double AudioMinutes, double Cost);
+
+/// <param name="Week">ISO week, e.g. "2026-W41".</param>
+/// <param name="MinutesSaved">Typing time for the words at Reports.TypingWpm, less the time spent speaking and waiting.</param>
+public record WeeklyReport(
+    string Week, DateTime From, DateTime To,
+    int Dictations, int Words, int Failures, double ErrorRate,
+    double MinutesSaved, double Cost,
+    IReadOnlyList<AppStats> TopApps,
+    IReadOnlyList<ModelCost> Models);
+
+/// <summary>
+/// Summarises a week (Monday to Monday, UTC) of dictation: words, estimated time saved, top
+/// apps, error rate and estimated provider cost. When Reports.Weekly is on, the previous week is
+/// written as Markdown and HTML to the reports folder once it is over, and optionally posted to
+/// Reports.WebhookUrl; the last week written is kept in a state file.
+/// </summary>
+public class WeeklyReporter
+{
+    private const int TopAppCount = 5;
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromMinutes(3);
+    private static readonly TimeSpan Interval = TimeSpan.FromHours(1);
+
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly DictationRepository _repository;
+    private readonly Func<ReportOptions> _getOptions;
+    private readonly string _folder;
+    private readonly ILogger<WeeklyReporter> _logger;
+
+    /// <param name="folder">Where reports are written, and the state file kept.</param>
+    public WeeklyReporter(IHttpClientFactory httpClientFactory, DictationRepository repository,
+        Func<ReportOptions> getOptions, string folder, ILogger<WeeklyReporter> logger)
+    {
+        _httpClientFactory = httpClientFactory;
+        _repository = repository;
+        _getOptions = getOptions;
+        _folder = folder;
+        _logger = logger;
+    }
+
+    private string StatePath => Path.Combine(_folder, "weekly.last");
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        using var timer = new PeriodicTimer(Interval);
+        try
+        {
+            await Task.Delay(StartupDelay, ct);
+            do
+            {
+                if (_getOptions().Weekly)
+                    await WriteLastWeekAsync(ct);
+            }
+            while (await timer.WaitForNextTickAsync(ct));
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    /// <summary>Monday 00:00 UTC of the week <paramref name="weeksAgo"/> weeks before the current one.</summary>
+    public static DateTime WeekStart(int weeksAgo)
+    {
+        var today = DateTime.UtcNow.Date;
+        var monday = today.AddDays(-(((int)today.DayOfWeek + 6) % 7));
+        return DateTime.SpecifyKind(monday.AddDays(-7 * weeksAgo), DateTimeKind.Utc);
+    }
+
+    public async Task<WeeklyReport> BuildAsync(DateTime weekStart, CancellationToken ct = default)
+    {
+        var options = _getOptions();
+        var from = weekStart;
+        var to = from.AddDays(7);
+        var overall = await _repository.GetOverallStatsAsync(from, to, ct);
+        var apps = await _repository.GetAppStatsAsync(from, to, ct);
+        var models = await _repository.GetModelStatsAsync(from, to, ct);
+
+        var costs = models
+            .Select(m =>
+            {
+                var minutes = m.TotalRecordingMs / 60000.0;
+                var price = options.CostPerMinute.GetValueOrDefault(m.Model);
+                return new ModelCost(m.Provider, m.Model, m.TotalDictations, Math.Round(minutes, 1), Math.Round(minutes * price, 2));
+            })
+            .ToList();
+
+        // Speaking plus waiting for the text is what dictation costs; typing the same words is what it saves
+        var typingMinutes = (double)overall.TotalWords / options.TypingWpm;
+        var spentMinutes = (overall.TotalRecordingTimeMs + overall.AvgTotalLatencyMs * overall.TotalDictations) / 60000.0;
+
+        return new WeeklyReport(
+            WeekName(from), from, to,
+            overall.TotalDictations, overall.TotalWords, overall.FailureCount,
+            overall.TotalDictations > 0 ? Math.Round((double)overall.FailureCount / overall.TotalDictations, 3) : 0,
+            Math.Round(Math.Max(0, typingMinutes - spentMinutes), 1),
+            costs.Sum(c => c.Cost),
+            [.. apps.Take(TopAppCount)],
+            costs);
+    }
+
+    /// <summary>Writes the report's .md and .html files, replacing earlier ones; returns the Markdown path.</summary>
+    public async Task<string> SaveAsync(WeeklyReport report, CancellationToken ct = default)
+    {
+        Directory.CreateDirectory(_folder);
+        var path = Path.Combine(_folder, report.Week + ".md");
+        await File.WriteAllTextAsync(path, ToMarkdown(report), ct);
+        await File.WriteAllTextAsync(Path.ChangeExtension(path, ".html"), ToHtml(report), ct);
+        return path;
+    }
+
+    /// <summary>Saved reports, newest week first.</summary>
+    public IReadOnlyList<(string Week, string MarkdownPath, string HtmlPath)> List()
+    {
+        if (!Directory.Exists(_folder))
+            return [];
+        return Directory.EnumerateFiles(_folder, "*.md")
+            .Select(p => (Week: Path.GetFileNameWithoutExtension(p), MarkdownPath: p, HtmlPath: Path.ChangeExtension(p, ".html")))
+            .OrderByDescending(r => r.Week, StringComparer.Ordinal)
+            .ToList();
+    }
+
+    private async Task WriteLastWeekAsync(CancellationToken ct)
+    {
+        var weekStart = WeekStart(1);
+        var week = WeekName(weekStart);
+        if (ReadLastWritten() == week)
+            return;
+
+        try
+        {
+            var report = await BuildAsync(weekStart, ct);
+            var path = await SaveAsync(report, ct);
+            _logger.LogInformation("Wrote weekly report {Path}", path);
+
+            var url = _getOptions().WebhookUrl;
+            if (!string.IsNullOrWhiteSpace(url))
+                await PostAsync(url, report, ct);
+            File.WriteAllText(StatePath, week);
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            _logger.LogWarning(ex, "Weekly report for {Week} failed", week);
+        }
+    }
+
+    // A failed post is logged but doesn't hold the report back; it's already on disk
+    private async Task PostAsync(string url, WeeklyReport report, CancellationToken ct)
+    {
+        try
+        {
+            using var client = _httpClientFactory.CreateClient("webhook");
+            using var response = await client.PostAsJsonAsync(url, new
+            {
+                @event = "report.weekly",
+                report,
+                markdown = ToMarkdown(report),
+            }, ControlCommandHandler.JsonOptions, ct);
+            if (!response.IsSuccessStatusCode)
+                _logger.LogWarning("Weekly report webhook {Url} returned {Status}", url, (int)response.StatusCode);
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            _logger.LogWarning(ex, "Weekly report webhook {Url} failed", url);
+        }
+    }
+
+    private string? ReadLastWritten()
+    {
+        try
+        {
+            return File.Exists(StatePath) ? File.ReadAllText(StatePath).Trim() : null;
+        }
+        catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+        {
+            return null;
+        }
+    }
+
+    private static string WeekName(DateTime weekStart) =>
+        $"{ISOWeek.GetYear(weekStart)}-W{ISOWeek.GetWeekOfYear(weekStart):00}";
+
+    private static string AppName(AppStats app) => app.App.Length > 0 ? app.App : "(unknown)";
+
+    public static string ToMarkdown(WeeklyReport r)
+    {
+        var c = CultureInfo.InvariantCulture;
+        var sb = new StringBuilder();
+        sb.AppendLine(c, $"# TokenTalk week {r.Week}");
+        sb.AppendLine();
+        sb.AppendLine(c, $"{r.From:yyyy-MM-dd} to {r.To.AddDays(-1):yyyy-MM-dd}");
+        sb.AppendLine();
+        sb.AppendLine(c, $"- Words dictated: {r.Words:N0} in {r.Dictations:N0} dictations");
+        sb.AppendLine(c, $"- Time saved (estimate): {r.MinutesSaved:0.#} min");
+        sb.AppendLine(c, $"- Error rate: {r.ErrorRate:P1} ({r.Failures} failed)");
+        sb.AppendLine(c, $"- Cost (estimate): ${r.Cost:0.00}");
+        if (r.TopApps.Count > 0)
+        {
+            sb.AppendLine();
+            sb.AppendLine("## Top apps");
+            sb.AppendLine();
+            foreach (var app in r.TopApps)
+                sb.AppendLine(c, $"- {AppName(app)}: {app.TotalWords:N0} words, {app.TotalDictations} dictations");
+        }
+        if (r.Models.Count > 0)
+        {
+            sb.AppendLine();
+            sb.AppendLine("## Models");
+            sb.AppendLine();
+            sb.AppendLine("| Provider | Model | Dictations | Audio (min) | Cost |");
+            sb.AppendLine("|---|---|---:|---:|---:|");
+            foreach (var m in r.Models)
+                sb.AppendLine(c, $"| {m.Provider} | {m.Model} | {m.Dictations} | {m.AudioMinutes:0.0} | ${m.Cost:0.00} |");
+        }
+        return sb.ToString();
+    }
+
+    public static string ToHtml(WeeklyReport r)
+    {
+        var c = CultureInfo.InvariantCulture;
+        static string E(string s) => WebUtility.HtmlEncode(s);
+        var sb = new StringBuilder();
+        sb.AppendLine("<!DOCTYPE html>");
+        sb.AppendLine(c, $"<html><head><meta charset=\"utf-8\"><title>TokenTalk week {r.Week}</title>");
+        sb.AppendLine("<style>body{font-family:Segoe UI,sans-serif;max-width:40em;margin:2em auto}td,th{padding:.2em .8em;text-align:left}</style></head><body>");
+        sb.AppendLine(c, $"<h1>TokenTalk week {r.Week}</h1>");
+        sb.AppendLine(c, $"<p>{r.From:yyyy-MM-dd} to {r.To.AddDays(-1):yyyy-MM-dd}</p>");
+        sb.AppendLine("<ul>");
+        sb.AppendLine(c, $"<li>Words dictated: {r.Words:N0} in {r.Dictations:N0} dictations</li>");
+        sb.AppendLine(c, $"<li>Time saved (estimate): {r.MinutesSaved:0.#} min</li>");
+        sb.AppendLine(c, $"<li>Error rate: {r.ErrorRate:P1} ({r.Failures} failed)</li>");
+        sb.AppendLine(c, $"<li>Cost (estimate): ${r.Cost:0.00}</li>");
+        sb.AppendLine("</ul>");
+        if (r.TopApps.Count > 0)
+        {
+            sb.AppendLine("<h2>Top apps</h2><ul>");
+            foreach (var app in r.TopApps)
+                sb.AppendLine(c, $"<li>{E(AppName(app))}: {app.TotalWords:N0} words, {app.TotalDictations} dictations</li>");
+            sb.AppendLine("</ul>");
+        }
+        if (r.Models.Count > 0)
+        {
+            sb.AppendLine("<h2>Models</h2><table><tr><th>Provider</th><th>Model</th><th>Dictations</th><th>Audio (min)</th><th>Cost</th></tr>");
+            foreach (var m in r.Models)
+                sb.AppendLine(c, $"<tr><td>{E(m.Provider)}</td><td>{E(m.Model)}</td><td>{m.Dictations}</td><td>{m.AudioMinutes:0.0}</td><td>${m.Cost:0.00}</td></tr>");
+            sb.AppendLine("</table>");
+        }
+        sb.AppendLine("</body></html>");
+        return sb.ToString();
+    }
+}
@@ -0,0 +1,74 @@
+using System.Text;
+
+namespace TokenTalk.Diagnostics;
+
+/// <param name="ReferenceWords">Word count of the normalized reference, the WER denominator.</param>
+public record WerResult(int Substitutions, int Deletions, int Insertions, int ReferenceWords)
+{
+    public int Errors => Substitutions + Deletions + Insertions;
+
+    // An empty reference scores 0 when nothing was heard and 1 for anything inserted
+    public double Rate => ReferenceWords > 0 ? (double)Errors / ReferenceWords : Insertions > 0 ? 1 : 0;
+}
+
+/// <summary>
+/// Word error rate by Levenshtein alignment over words. Both texts are lower-cased and stripped of
+/// punctuation first, so only the words count, not the formatting the pipeline adds.
+/// </summary>
+public static class WordErrorRate
+{
+    public static WerResult Compute(string reference, string hypothesis)
+    {
+        var r = Normalize(reference);
+        var h = Normalize(hypothesis);
+
+        // cost[i, j]: edits to turn the first i reference words into the first j hypothesis words
+        var cost = new int[r.Length + 1, h.Length + 1];
+        for (var i = 0; i <= r.Length; i++)
+            cost[i, 0] = i;
+        for (var j = 0; j <= h.Length; j++)
+            cost[0, j] = j;
+        for (var i = 1; i <= r.Length; i++)
+        {
+            for (var j = 1; j <= h.Length; j++)
+            {
+                var substitute = cost[i - 1, j - 1] + (r[i - 1] == h[j - 1] ? 0 : 1);
+                cost[i, j] = Math.Min(substitute, Math.Min(cost[i - 1, j] + 1, cost[i, j - 1] + 1));
+            }
+        }
+
+        // Walk the alignment back to split the distance into its kinds of error
+        int substitutions = 0, deletions = 0, insertions = 0;
+        int a = r.Length, b = h.Length;
+        while (a > 0 || b > 0)
+        {
+            if (a > 0 && b > 0 && cost[a, b] == cost[a - 1, b - 1] + (r[a - 1] == h[b - 1] ? 0 : 1))
+            {
+                if (r[a - 1] != h[b - 1])
+                    substitutions++;
+                a--;
+                b--;
+            }
+            else if (a > 0 && cost[a, b] == cost[a - 1, b] + 1)
+            {
+                deletions++;
+                a--;
+            }
+            else
+            {
+                insertions++;
+                b--;
+            }
+        }
+        return new WerResult(substitutions, deletions, insertions, r.Length);
+    }
+
+    // Apostrophes stay so "it's" and "its" remain different words
+    private static string[] Normalize(string text)
+    {
+        var sb = new StringBuilder(text.Length);
+        foreach (var c in text.ToLowerInvariant().Replace('’', '\''))
+            sb.Append(char.IsLetterOrDigit(c) || c == '\'' ? c : ' ');
+        return sb.ToString().Split(' ', StringSplitOptions.RemoveEmptyEntries);
+    }
+}
@@ -0,0 +1,166 @@
+using System.Text.Json;
+using System.Text.Json.Nodes;
+using TokenTalk.Platform;
+
+namespace TokenTalk.Integrations;
+
+/// <summary>
+/// Minimal Model Context Protocol server over stdio (newline-delimited JSON-RPC 2.0), started with
+/// <c>TokenTalk --mcp</c> by an assistant host. It holds no state of its own: every tool call is
+/// forwarded to the running instance over the control pipe.
+/// </summary>
+public class McpServer
+{
+    private const string ProtocolVersion = "2024-11-05";
+    private static readonly TimeSpan PipeTimeout = TimeSpan.FromSeconds(5);
+
+    private record Tool(string Name, string Description, JsonObject InputSchema, Func<JsonObject?, string> ToCommand);
+
+    private static readonly Tool[] Tools =
+    [
+        new("recent_dictations", "List the most recent successful dictations, newest first.",
+            Schema(("limit", "integer", "Number of dictations to return (max 50)", false)),
+            a => $"history recent {a?["limit"]?.GetValue<int>() ?? 10}"),
+        new("search_dictations", "Search dictation history for text, newest first.",
+            Schema(("query", "string", "Text to search for (case-insensitive)", true)),
+            a => $"history search {SingleLine(a?["query"]?.GetValue<string>())}"),
+        new("start_recording", "Start a dictation recording in the running TokenTalk instance.",
+            Schema(), _ => "record start"),
+        new("stop_recording", "Stop the current recording and transcribe it.",
+            Schema(), _ => "record stop"),
+        new("toggle_recording", "Start recording if idle, otherwise stop and transcribe.",
+            Schema(), _ => "record toggle"),
+        new("get_status", "Get the current TokenTalk status, recording state and provider.",
+            Schema(), _ => "status"),
+    ];
+
+    private readonly string _pipeName;
+    private readonly TextReader _input;
+    private readonly TextWriter _output;
+
+    public McpServer(string pipeName, TextReader input, TextWriter output)
+    {
+        _pipeName = pipeName;
+        _input = input;
+        _output = output;
+    }
+
+    public async Task RunAsync()
+    {
+        while (await _input.ReadLineAsync() is { } line)
+        {
+            if (string.IsNullOrWhiteSpace(line))
+                continue;
+
+            JsonObject? response;
+            try
+            {
+                response = Handle(JsonNode.Parse(line)?.AsObject()
+                    ?? throw new JsonException("Request is not an object"));
+            }
+            catch (Exception ex) when (ex is JsonException or InvalidOperationException)
+            {
+                response = Error(null, -32700, $"Parse error: {ex.Message}");
+            }
+
+            if (response != null)
+            {
+                await _output.WriteLineAsync(response.ToJsonString());
+                await _output.FlushAsync();
+            }
+        }
+    }
+
+    private JsonObject? Handle(JsonObject request)
+    {
+        var id = request["id"]?.DeepClone();
+        var method = request["method"]?.GetValue<string>();
+
+        // Notifications carry no id and never get a response
+        if (id == null)
+            return null;
+
+        return method switch
+        {
+            "initialize" => Result(id, new JsonObject
+            {
+                ["protocolVersion"] = ProtocolVersion,
+                ["capabilities"] = new JsonObject { ["tools"] = new JsonObject() },
+                ["serverInfo"] = new JsonObject { ["name"] = "tokentalk", ["version"] = BuildInfo.Version },
+            }),
+            "ping" => Result(id, new JsonObject()),
+            "tools/list" => Result(id, new JsonObject
+            {
+                ["tools"] = new JsonArray(Tools.Select(t => (JsonNode)new JsonObject
+                {
+                    ["name"] = t.Name,
+                    ["description"] = t.Description,
+                    ["inputSchema"] = t.InputSchema.DeepClone(),
+                }).ToArray()),
+            }),
+            "tools/call" => CallTool(id, request["params"]?.AsObject()),
+            _ => Error(id, -32601, $"Method not found: {method}"),
+        };
+    }
+
+    private JsonObject CallTool(JsonNode id, JsonObject? parameters)
+    {
+        var name = parameters?["name"]?.GetValue<string>();
+        var tool = Tools.FirstOrDefault(t => t.Name == name);
+        if (tool == null)
+            return Error(id, -32602, $"Unknown tool: {name}");
+
+        string command;
+        try
+        {
+            command = tool.ToCommand(parameters?["arguments"]?.AsObject());
+        }
+        catch (Exception ex) when (ex is InvalidOperationException or FormatException)
+        {
+            return Error(id, -32602, $"Invalid arguments: {ex.Message}");
+        }
+
+        var reply = ControlPipeServer.Send(_pipeName, command, PipeTimeout);
+        var isError = reply == null || reply.StartsWith("error:", StringComparison.Ordinal);
+        return Result(id, new JsonObject
+        {
+            ["content"] = new JsonArray(new JsonObject
+            {
+                ["type"] = "text",
+                ["text"] = reply ?? "TokenTalk is not running.",
+            }),
+            ["isError"] = isError,
+        });
+    }
+
+    private static JsonObject Schema(params (string Name, string Type, string Description, bool Required)[] properties)
+    {
+        var props = new JsonObject();
+        foreach (var p in properties)
+            props[p.Name] = new JsonObject { ["type"] = p.Type, ["description"] = p.Description };
+
+        return new JsonObject
+        {
+            ["type"] = "object",
+            ["properties"] = props,
+            ["required"] = new JsonArray(properties.Where(p => p.Required).Select(p => (JsonNode)p.Name).ToArray()),
+        };
+    }
+
+    // The control pipe is line-based, so a query must not contain newlines
+    private static string SingleLine(string? text) =>
+        string.IsNullOrWhiteSpace(text)
+            ? throw new FormatException("query is required")
+            : text.ReplaceLineEndings(" ").Trim();
+
+    private static JsonObject Result(JsonNode id, JsonObject result) =>
+        new() { ["jsonrpc"] = "2.0", ["id"] = id, ["result"] = result };
+
+    private static JsonObject Error(JsonNode? id, int code, string message) =>
+        new()
+        {
+            ["jsonrpc"] = "2.0",
+            ["id"] = id,
+            ["error"] = new JsonObject { ["code"] = code, ["message"] = message },
+        };
+}
@@ -0,0 +1,184 @@
+using System.Globalization;
+using System.Text;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Integrations;
+
+/// <summary>
+/// Writes dictations into the daily notes of an Obsidian vault, one templated entry appended to
+/// the note of the day they were recorded. In "dictation" mode each completed dictation is
+/// appended right away; in "session" mode a periodic job appends each finished session as a
+/// single entry and remembers how far it got in a state file, so nothing is written twice.
+/// </summary>
+public class ObsidianExporter
+{
+    private const int BatchSize = 500;
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromMinutes(1);
+
+    private readonly DictationRepository _repository;
+    private readonly Func<TokenTalkOptions> _getOptions;
+    private readonly string _statePath;
+    private readonly ILogger<ObsidianExporter> _logger;
+    // Live entries and the session job may write the same note
+    private readonly SemaphoreSlim _writeLock = new(1, 1);
+
+    /// <param name="statePath">File holding the timestamp of the last dictation exported by session.</param>
+    public ObsidianExporter(
+        DictationRepository repository,
+        Func<TokenTalkOptions> getOptions,
+        string statePath,
+        ILogger<ObsidianExporter> logger)
+    {
+        _repository = repository;
+        _getOptions = getOptions;
+        _statePath = statePath;
+        _logger = logger;
+    }
+
+    /// <summary>Appends a completed dictation in "dictation" mode; does nothing otherwise.</summary>
+    public void OnDictationCompleted(Dictation dictation)
+    {
+        var options = _getOptions().Obsidian;
+        if (options.VaultPath.Length == 0 || options.Mode != ObsidianModes.Dictation)
+            return;
+        _ = AppendLiveAsync(options, dictation);
+    }
+
+    private async Task AppendLiveAsync(ObsidianOptions options, Dictation dictation)
+    {
+        try
+        {
+            await AppendAsync(options, [dictation], CancellationToken.None);
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "Could not append dictation {Id} to Obsidian", dictation.Id);
+        }
+    }
+
+    /// <summary>The session job; returns when <paramref name="ct"/> is cancelled.</summary>
+    public async Task RunAsync(CancellationToken ct)
+    {
+        try
+        {
+            await Task.Delay(StartupDelay, ct);
+            while (true)
+            {
+                var options = _getOptions();
+                if (options.Obsidian.VaultPath.Length > 0 && options.Obsidian.Mode == ObsidianModes.Session)
+                    await ExportSessionsAsync(options, ct);
+                // The interval is re-read each round so a Settings change takes effect without restart
+                await Task.Delay(TimeSpan.FromMinutes(Math.Max(1, options.Obsidian.IntervalMinutes)), ct);
+            }
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    private async Task ExportSessionsAsync(TokenTalkOptions options, CancellationToken ct)
+    {
+        try
+        {
+            // The first run starts from now rather than pouring the whole history into the vault
+            if (!TryReadState(out var exportedUntil))
+            {
+                WriteState(DateTime.UtcNow);
+                return;
+            }
+
+            var dictations = await _repository.GetRecordedAfterAsync(exportedUntil, Environment.MachineName, BatchSize, ct);
+            // A session still within the gap may get more dictations; it waits for a later round
+            var closedBefore = DateTime.UtcNow - TimeSpan.FromMinutes(options.History.SessionGapMinutes);
+            var sessions = dictations
+                .GroupBy(d => d.SessionId ?? d.Id.ToString(CultureInfo.InvariantCulture))
+                .Select(g => g.ToList())
+                .ToList();
+
+            var exported = 0;
+            foreach (var session in sessions)
+            {
+                if (session[^1].Timestamp >= closedBefore)
+                    break;
+                await AppendAsync(options.Obsidian, session, ct);
+                WriteState(session[^1].Timestamp);
+                exported++;
+            }
+            if (exported > 0)
+                _logger.LogInformation("Exported {Count} session(s) to Obsidian", exported);
+        }
+        catch (OperationCanceledException)
+        {
+            throw;
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Obsidian session export failed");
+        }
+    }
+
+    /// <summary>Appends one entry made of <paramref name="dictations"/> to the note of the first one's day.</summary>
+    private async Task AppendAsync(ObsidianOptions options, IReadOnlyList<Dictation> dictations, CancellationToken ct)
+    {
+        var first = dictations[0];
+        var local = first.Timestamp.ToLocalTime();
+        var folder = Path.Combine(options.VaultPath, options.DailyNotesFolder);
+        var path = Path.Combine(folder, local.ToString(options.DateFormat, CultureInfo.InvariantCulture) + ".md");
+        var entry = Render(options.Template, local, dictations);
+
+        await _writeLock.WaitAsync(ct);
+        try
+        {
+            Directory.CreateDirectory(folder);
+            // Keep the entry on its own line even when the note was last edited without a trailing newline
+            var separator = File.Exists(path) && !EndsWithNewline(path) ? "\n" : "";
+            await File.AppendAllTextAsync(path, separator + entry + "\n", ct);
+        }
+        finally
+        {
+            _writeLock.Release();
+        }
+    }
+
+    private static string Render(string template, DateTime local, IReadOnlyList<Dictation> dictations)
+    {
+        var tags = dictations
+            .SelectMany(d => DictationRepository.ParseTags(d.Tags))
+            .Distinct()
+            .Select(t => "#" + t.Replace(' ', '-'));
+        var apps = dictations.Select(d => d.App).Where(a => a.Length > 0).Distinct();
+
+        var sb = new StringBuilder(template.Replace("\\n", "\n"));
+        sb.Replace("{time}", local.ToString("HH:mm", CultureInfo.InvariantCulture));
+        sb.Replace("{date}", local.ToString("yyyy-MM-dd", CultureInfo.InvariantCulture));
+        sb.Replace("{app}", string.Join(", ", apps));
+        sb.Replace("{tags}", string.Join(" ", tags));
+        sb.Replace("{profile}", dictations[0].Profile);
+        sb.Replace("{words}", dictations.Sum(d => d.WordCount).ToString(CultureInfo.InvariantCulture));
+        // Last, so braces in what was said are never taken for placeholders
+        sb.Replace("{text}", string.Join(" ", dictations.Select(d => d.TranscribedText.Trim())));
+        return sb.ToString().TrimEnd();
+    }
+
+    private static bool EndsWithNewline(string path)
+    {
+        using var stream = File.OpenRead(path);
+        if (stream.Length == 0)
+            return true;
+        stream.Seek(-1, SeekOrigin.End);
+        return stream.ReadByte() == '\n';
+    }
+
+    private bool TryReadState(out DateTime exportedUntil)
+    {
+        exportedUntil = default;
+        return File.Exists(_statePath)
+            && DateTime.TryParse(File.ReadAllText(_statePath).Trim(), CultureInfo.InvariantCulture,
+                DateTimeStyles.AdjustToUniversal | DateTimeStyles.AssumeUniversal, out exportedUntil);
+    }
+
+    private void WriteState(DateTime exportedUntil) =>
+        File.WriteAllText(_statePath, exportedUntil.ToString("o", CultureInfo.InvariantCulture));
+}
@@ -0,0 +1,90 @@
+using System.Text;
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Integrations;
+
+/// <summary>
+/// Delivers dictations to the targets that aren't the focused app: appended to a Markdown file
+/// under a timestamp heading (a voice journal), or POSTed as JSON to a URL. Unlike webhooks these
+/// are the dictation's only destination, so a failure is thrown back and the dictation fails.
+/// </summary>
+public class OutputTargetWriter
+{
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly ILogger<OutputTargetWriter> _logger;
+    // Continuous mode can finish segments back to back; entries must not interleave in the file
+    private readonly SemaphoreSlim _fileLock = new(1, 1);
+
+    public OutputTargetWriter(IHttpClientFactory httpClientFactory, ILogger<OutputTargetWriter> logger)
+    {
+        _httpClientFactory = httpClientFactory;
+        _logger = logger;
+    }
+
+    /// <param name="target">One of <see cref="OutputTargets"/> other than <see cref="OutputTargets.App"/>.</param>
+    public Task WriteAsync(string target, OutputOptions output, string text, Dictation dictation, CancellationToken ct = default)
+    {
+        return target switch
+        {
+            OutputTargets.File => AppendToFileAsync(output.FilePath, text, ct),
+            OutputTargets.Url => PostAsync(output.Url, text, dictation, ct),
+            _ => throw new ArgumentException($"'{target}' is not a file or URL target", nameof(target)),
+        };
+    }
+
+    /// <summary>
+    /// The file a dictation made now goes to: <c>{date}</c> in the configured path becomes today's
+    /// date, so one note per day, and environment variables like <c>%USERPROFILE%</c> are expanded.
+    /// </summary>
+    public static string ResolveFilePath(string pattern, DateTime now) =>
+        Path.GetFullPath(Environment.ExpandEnvironmentVariables(
+            pattern.Replace("{date}", now.ToString("yyyy-MM-dd"), StringComparison.OrdinalIgnoreCase)));
+
+    private async Task AppendToFileAsync(string pattern, string text, CancellationToken ct)
+    {
+        var now = DateTime.Now;
+        var path = ResolveFilePath(pattern, now);
+
+        await _fileLock.WaitAsync(ct);
+        try
+        {
+            var dir = Path.GetDirectoryName(path);
+            if (!string.IsNullOrEmpty(dir))
+                Directory.CreateDirectory(dir);
+
+            // A new note gets the day as its title; every entry gets the time it was spoken
+            var sb = new StringBuilder();
+            if (!File.Exists(path))
+                sb.Append($"# {now:yyyy-MM-dd}\n");
+            sb.Append($"\n## {now:HH:mm}\n\n{text.Trim()}\n");
+            await File.AppendAllTextAsync(path, sb.ToString(), ct);
+        }
+        finally
+        {
+            _fileLock.Release();
+        }
+        _logger.LogInformation("Appended dictation to {Path}", path);
+    }
+
+    private async Task PostAsync(string url, string text, Dictation dictation, CancellationToken ct)
+    {
+        var body = JsonSerializer.Serialize(new
+        {
+            text,
+            timestamp = dictation.Timestamp,
+            language = dictation.Language,
+            profile = dictation.Profile,
+            machine = Environment.MachineName,
+        });
+
+        using var client = _httpClientFactory.CreateClient("output");
+        using var content = new StringContent(body, Encoding.UTF8, "application/json");
+        using var response = await client.PostAsync(url, content, ct);
+        if (!response.IsSuccessStatusCode)
+            throw new HttpRequestException($"{url} returned {(int)response.StatusCode} {response.ReasonPhrase}", null, response.StatusCode);
+        _logger.LogInformation("Posted dictation to {Url}", url);
+    }
+}
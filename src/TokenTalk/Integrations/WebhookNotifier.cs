@@ -0,0 +1,124 @@
+using System.Security.Cryptography;
+using System.Text;
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Notifications;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Integrations;
+
+public static class WebhookEvents
+{
+    public const string DictationCompleted = "dictation.completed";
+    public const string DictationFailed = "dictation.failed";
+}
+
+/// <summary>
+/// POSTs dictation events as JSON to the configured webhooks. Each delivery is retried a few
+/// times with backoff; failures are logged and never affect dictation.
+/// </summary>
+public class WebhookNotifier : IDictationNotifier
+{
+    private const int MaxAttempts = 3;
+
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly Func<List<WebhookOptions>> _getWebhooks;
+    private readonly ILogger<WebhookNotifier> _logger;
+    // Cancels deliveries still retrying at shutdown
+    private readonly CancellationToken _stoppingToken;
+
+    public WebhookNotifier(
+        IHttpClientFactory httpClientFactory,
+        Func<List<WebhookOptions>> getWebhooks,
+        ILogger<WebhookNotifier> logger,
+        CancellationToken stoppingToken = default)
+    {
+        _httpClientFactory = httpClientFactory;
+        _getWebhooks = getWebhooks;
+        _logger = logger;
+        _stoppingToken = stoppingToken;
+    }
+
+    public string Name => NotifierNames.Webhook;
+
+    // Webhooks have no recording-started event
+    public void OnRecordingStart()
+    {
+    }
+
+    public void OnTranscription(Dictation dictation) =>
+        _ = NotifyAsync(WebhookEvents.DictationCompleted, dictation, _stoppingToken);
+
+    public void OnError(string message, Dictation? dictation)
+    {
+        if (dictation != null)
+            _ = NotifyAsync(WebhookEvents.DictationFailed, dictation, _stoppingToken);
+    }
+
+    public async Task NotifyAsync(string eventName, Dictation dictation, CancellationToken ct = default)
+    {
+        var hooks = _getWebhooks()
+            .Where(h => h.Enabled && !string.IsNullOrWhiteSpace(h.Url))
+            .Where(h => h.Events.Count == 0 || h.Events.Contains(eventName, StringComparer.OrdinalIgnoreCase))
+            .ToList();
+        if (hooks.Count == 0)
+            return;
+
+        var body = JsonSerializer.Serialize(new
+        {
+            @event = eventName,
+            sentAt = DateTime.UtcNow,
+            machine = Environment.MachineName,
+            dictation,
+        });
+
+        await Task.WhenAll(hooks.Select(h => DeliverAsync(h, eventName, body, ct)));
+    }
+
+    private async Task DeliverAsync(WebhookOptions hook, string eventName, string body, CancellationToken ct)
+    {
+        using var client = _httpClientFactory.CreateClient("webhook");
+        for (int attempt = 1; attempt <= MaxAttempts; attempt++)
+        {
+            try
+            {
+                using var request = new HttpRequestMessage(HttpMethod.Post, hook.Url)
+                {
+                    Content = new StringContent(body, Encoding.UTF8, "application/json"),
+                };
+                request.Headers.Add("X-TokenTalk-Event", eventName);
+                if (!string.IsNullOrEmpty(hook.Secret))
+                    request.Headers.Add("X-TokenTalk-Signature", "sha256=" + Sign(hook.Secret, body));
+
+                using var response = await client.SendAsync(request, ct);
+                if (response.IsSuccessStatusCode)
+                    return;
+
+                _logger.LogWarning("Webhook {Url} returned {Status} (attempt {Attempt}/{Max})",
+                    hook.Url, (int)response.StatusCode, attempt, MaxAttempts);
+            }
+            catch (OperationCanceledException) when (ct.IsCancellationRequested)
+            {
+                return;
+            }
+            catch (Exception ex)
+            {
+                _logger.LogWarning(ex, "Webhook {Url} failed (attempt {Attempt}/{Max})", hook.Url, attempt, MaxAttempts);
+            }
+
+            if (attempt < MaxAttempts)
+            {
+                try { await Task.Delay(TimeSpan.FromSeconds(attempt * 2), ct); }
+                catch (OperationCanceledException) { return; }
+            }
+        }
+    }
+
+    // Hex HMAC-SHA256 of the raw body, so receivers can verify the payload came from this instance
+    private static string Sign(string secret, string body)
+    {
+        var hash = HMACSHA256.HashData(Encoding.UTF8.GetBytes(secret), Encoding.UTF8.GetBytes(body));
+        return Convert.ToHexString(hash).ToLowerInvariant();
+    }
+}
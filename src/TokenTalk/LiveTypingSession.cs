@@ -0,0 +1,130 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Audio;
+using TokenTalk.Platform;
+using TokenTalk.Transcription;
+
+namespace TokenTalk;
+
+/// <summary>
+/// Type-as-you-speak for one recording. While the microphone is open, the audio so far is
+/// re-transcribed every interval and the focused field is updated to match: the part that
+/// agrees with what was typed stays, the provisional tail is backspaced and retyped. The
+/// final, post-processed text is applied the same way by <see cref="ReplaceAsync"/>.
+/// </summary>
+public sealed class LiveTypingSession
+{
+    // Less audio than this rarely transcribes to anything useful
+    private static readonly TimeSpan MinAudio = TimeSpan.FromMilliseconds(500);
+
+    private readonly AudioRecorder _recorder;
+    private readonly ITranscriptionProvider _provider;
+    private readonly TimeSpan _interval;
+    private readonly Func<bool> _modifiersDown;
+    private readonly string? _language;
+    private readonly bool _incognito;
+    private readonly ILogger _logger;
+    private readonly CancellationTokenSource _cts;
+    private readonly SemaphoreSlim _typing = new(1, 1);
+    private readonly Task _loop;
+    private bool _stopped;
+
+    public LiveTypingSession(
+        AudioRecorder recorder,
+        ITranscriptionProvider provider,
+        TimeSpan interval,
+        Func<bool> modifiersDown,
+        string? language,
+        bool incognito,
+        ILogger logger,
+        CancellationToken ct)
+    {
+        _language = language;
+        _incognito = incognito;
+        _recorder = recorder;
+        _provider = provider;
+        _interval = interval;
+        _modifiersDown = modifiersDown;
+        _logger = logger;
+        _cts = CancellationTokenSource.CreateLinkedTokenSource(ct);
+        _loop = Task.Run(() => RunAsync(_cts.Token));
+    }
+
+    /// <summary>Text currently typed into the target by this session.</summary>
+    public string Typed { get; private set; } = "";
+
+    private async Task RunAsync(CancellationToken ct)
+    {
+        TranscriptionContext.Language = _language;
+        TranscriptionContext.Incognito = _incognito;
+        try
+        {
+            while (true)
+            {
+                await Task.Delay(_interval, ct);
+                var audio = _recorder.Snapshot();
+                if (audio == null)
+                    return;
+                if (audio.Duration < MinAudio)
+                    continue;
+
+                var text = await _provider.TranscribeAsync(audio, ct);
+                // Keystrokes sent while the hotkey's modifiers are held would become shortcuts
+                // (Ctrl+Backspace deletes a word); wait for the next round or the final text
+                if (!_modifiersDown())
+                    await ApplyAsync(text, ct);
+            }
+        }
+        catch (OperationCanceledException)
+        {
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "Live transcription stopped; the text will be typed when the recording ends");
+        }
+    }
+
+    /// <summary>Stops updating the provisional text; an update in progress is abandoned.</summary>
+    public async Task StopAsync()
+    {
+        if (!_stopped)
+        {
+            _stopped = true;
+            _cts.Cancel();
+        }
+        await _loop;
+    }
+
+    /// <summary>
+    /// Makes the target show <paramref name="text"/> in place of the provisional text, waiting
+    /// briefly for the hotkey's modifiers to be released. An empty string erases it.
+    /// </summary>
+    public async Task ReplaceAsync(string text, CancellationToken ct)
+    {
+        for (var i = 0; i < 20 && _modifiersDown(); i++)
+            await Task.Delay(25, ct);
+        await ApplyAsync(text, ct);
+    }
+
+    private async Task ApplyAsync(string text, CancellationToken ct)
+    {
+        await _typing.WaitAsync(ct);
+        try
+        {
+            var common = 0;
+            var max = Math.Min(Typed.Length, text.Length);
+            while (common < max && Typed[common] == text[common])
+                common++;
+            // Never keep half of a surrogate pair; one backspace removes the whole character
+            if (common > 0 && char.IsHighSurrogate(Typed[common - 1]))
+                common--;
+
+            TextTyper.Backspace(TextTyper.BackspacesFor(Typed[common..]));
+            TextTyper.Type(text[common..]);
+            Typed = text;
+        }
+        finally
+        {
+            _typing.Release();
+        }
+    }
+}
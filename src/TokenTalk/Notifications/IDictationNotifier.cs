@@ -0,0 +1,27 @@
+using TokenTalk.Storage;
+
+namespace TokenTalk.Notifications;
+
+/// <summary>
+/// Receives dictation events from the agent. Notifiers are registered with
+/// <see cref="Agent.AddNotifier"/> and only called while their <see cref="Name"/> is listed in
+/// Notifications.Notifiers. Calls happen on the dictation's thread, so slow work (network,
+/// UI) must be handed off rather than awaited.
+/// </summary>
+public interface IDictationNotifier
+{
+    string Name { get; }
+    void OnRecordingStart();
+    void OnTranscription(Dictation dictation);
+    // dictation is null when the failure happened before there was one, e.g. the microphone didn't open
+    void OnError(string message, Dictation? dictation);
+}
+
+public static class NotifierNames
+{
+    public const string Toast = "toast";
+    public const string Sound = "sound";
+    public const string Webhook = "webhook";
+
+    public static readonly string[] All = [Toast, Sound, Webhook];
+}
@@ -0,0 +1,21 @@
+using System.Media;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Notifications;
+
+/// <summary>Plays the Windows system sounds as audible cues, so dictation works without watching the screen.</summary>
+public class SoundNotifier : IDictationNotifier
+{
+    public string Name => NotifierNames.Sound;
+
+    public void OnRecordingStart() => SystemSounds.Asterisk.Play();
+
+    public void OnTranscription(Dictation dictation) => SystemSounds.Beep.Play();
+
+    public void OnError(string message, Dictation? dictation)
+    {
+        // Same reasoning as the toast: a silent press isn't worth an error sound
+        if (dictation?.ErrorCategory is not (ErrorCategories.EmptyTranscription or ErrorCategories.NoSpeech))
+            SystemSounds.Hand.Play();
+    }
+}
@@ -0,0 +1,34 @@
+using Microsoft.Win32;
+
+namespace TokenTalk.Platform;
+
+/// <summary>
+/// Launch-at-login through the per-user Run key, so no elevation is needed. The value stores the
+/// current executable path; re-enabling after moving the exe updates it.
+/// </summary>
+public static class AutostartManager
+{
+    private const string RunKeyPath = @"Software\Microsoft\Windows\CurrentVersion\Run";
+    private const string ValueName = "TokenTalk";
+
+    public static bool IsEnabled
+    {
+        get
+        {
+            using var key = Registry.CurrentUser.OpenSubKey(RunKeyPath);
+            return key?.GetValue(ValueName) is string command
+                && string.Equals(command, Command, StringComparison.OrdinalIgnoreCase);
+        }
+    }
+
+    public static void SetEnabled(bool enabled)
+    {
+        using var key = Registry.CurrentUser.CreateSubKey(RunKeyPath);
+        if (enabled)
+            key.SetValue(ValueName, Command);
+        else
+            key.DeleteValue(ValueName, throwOnMissingValue: false);
+    }
+
+    private static string Command => $"\"{Environment.ProcessPath}\"";
+}
@@ -7,6 +7,9 @@ public class ClipboardService
     // Clipboard operations must run on an STA thread.
     // We use Task.Run with STA marshaling via a dedicated helper.
 
+    // Incremented by Windows on every clipboard change; reading it doesn't need the clipboard open
+    public uint SequenceNumber => NativeMethods.GetClipboardSequenceNumber();
+
     public string GetText()
     {
         return RunOnStaThread(() =>
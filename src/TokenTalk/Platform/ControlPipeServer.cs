@@ -0,0 +1,185 @@
+using System.IO.Pipes;
+using Microsoft.Extensions.Logging;
+
+namespace TokenTalk.Platform;
+
+/// <summary>
+/// Local control channel so scripts, Stream Deck buttons and the command line can drive a
+/// running instance. Each connection sends one command line and receives one reply line.
+/// The pipe is scoped to the current Windows user. Up to <see cref="MaxConnections"/> commands
+/// are handled at once, so a slow one (a reprocess, an undo waiting on the queue) doesn't make
+/// the instance look gone to a <c>--health</c> check.
+/// </summary>
+public class ControlPipeServer
+{
+    /// <summary>Pipe name for the default instance, or a named one started with <c>--instance</c>.</summary>
+    public static string GetPipeName(string? instance) =>
+        string.IsNullOrEmpty(instance)
+            ? $"TokenTalk.Control.{Environment.UserName}"
+            : $"TokenTalk.Control.{Environment.UserName}.{instance}";
+
+    private const int MaxConnections = 4;
+    private static readonly TimeSpan DefaultReplyTimeout = TimeSpan.FromSeconds(60);
+    private static readonly TimeSpan RetryDelay = TimeSpan.FromSeconds(1);
+
+    private readonly string _pipeName;
+    private readonly Func<string, CancellationToken, Task<string>> _handler;
+    private readonly ILogger<ControlPipeServer> _logger;
+    private readonly SemaphoreSlim _slots = new(MaxConnections, MaxConnections);
+
+    public ControlPipeServer(string pipeName, Func<string, CancellationToken, Task<string>> handler, ILogger<ControlPipeServer> logger)
+    {
+        _pipeName = pipeName;
+        _handler = handler;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        var connections = new List<Task>();
+        var first = true;
+        try
+        {
+            while (!ct.IsCancellationRequested)
+            {
+                // A free slot first: creating an instance beyond the maximum would throw
+                await _slots.WaitAsync(ct);
+                NamedPipeServerStream? pipe;
+                try
+                {
+                    pipe = CreatePipe(first);
+                }
+                catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+                {
+                    // Only a later instance gets here; the name is ours, so wait a moment and retry
+                    _logger.LogWarning(ex, "Failed to open another control pipe instance");
+                    _slots.Release();
+                    await Task.Delay(RetryDelay, ct);
+                    continue;
+                }
+                if (pipe == null)
+                {
+                    _slots.Release();
+                    break;
+                }
+                first = false;
+
+                try
+                {
+                    await pipe.WaitForConnectionAsync(ct);
+                }
+                catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+                {
+                    // A client that connected and dropped at once; keep listening
+                    _logger.LogDebug(ex, "Control pipe connection dropped");
+                    await pipe.DisposeAsync();
+                    _slots.Release();
+                    continue;
+                }
+                catch
+                {
+                    await pipe.DisposeAsync();
+                    _slots.Release();
+                    throw;
+                }
+                connections.RemoveAll(t => t.IsCompleted);
+                connections.Add(HandleAsync(pipe, ct));
+            }
+        }
+        catch (OperationCanceledException)
+        {
+        }
+        await Task.WhenAll(connections);
+    }
+
+    private async Task HandleAsync(NamedPipeServerStream pipe, CancellationToken ct)
+    {
+        try
+        {
+            using var reader = new StreamReader(pipe, leaveOpen: true);
+            await using var writer = new StreamWriter(pipe, leaveOpen: true) { AutoFlush = true };
+
+            var command = (await reader.ReadLineAsync(ct))?.Trim() ?? string.Empty;
+            // Only the verb: arguments can be search text or a wipe token
+            var verb = command.Split(' ', 2)[0];
+            string reply;
+            try
+            {
+                reply = await _handler(command, ct);
+            }
+            catch (Exception ex) when (ex is not OperationCanceledException)
+            {
+                _logger.LogWarning(ex, "Control command {Command} failed", verb);
+                reply = "error: " + ex.Message;
+            }
+            // Replies carry transcripts and traces, which must not reach the log file
+            _logger.LogDebug("Control command {Command}: {Status}, {Length} chars",
+                verb, reply.StartsWith("error:", StringComparison.Ordinal) ? "error" : "ok", reply.Length);
+            await writer.WriteLineAsync(reply);
+        }
+        catch (OperationCanceledException)
+        {
+        }
+        catch (IOException ex)
+        {
+            // Client disconnected mid-command
+            _logger.LogDebug(ex, "Control pipe connection dropped");
+        }
+        finally
+        {
+            await pipe.DisposeAsync();
+            _slots.Release();
+        }
+    }
+
+    // Only the first instance claims the name, so a second TokenTalk can't join this pipe
+    private NamedPipeServerStream? CreatePipe(bool first)
+    {
+        var options = PipeOptions.Asynchronous | PipeOptions.CurrentUserOnly;
+        if (first)
+            options |= PipeOptions.FirstPipeInstance;
+        try
+        {
+            return new NamedPipeServerStream(_pipeName, PipeDirection.InOut, MaxConnections, PipeTransmissionMode.Byte, options);
+        }
+        catch (Exception ex) when (first && ex is IOException or UnauthorizedAccessException)
+        {
+            // Retrying would spin; a second instance needs its own --instance name
+            _logger.LogWarning("Control pipe {Pipe} is used by another instance; remote control is disabled", _pipeName);
+            return null;
+        }
+    }
+
+    /// <summary>
+    /// Sends a command to the running instance and returns its reply, or null if no instance is
+    /// listening within <paramref name="timeout"/>. A reply that doesn't arrive within
+    /// <paramref name="replyTimeout"/> (a minute by default) comes back as an error reply.
+    /// </summary>
+    public static string? Send(string pipeName, string command, TimeSpan timeout, TimeSpan? replyTimeout = null)
+    {
+        using var pipe = new NamedPipeClientStream(".", pipeName, PipeDirection.InOut,
+            PipeOptions.Asynchronous | PipeOptions.CurrentUserOnly);
+        try
+        {
+            pipe.Connect((int)timeout.TotalMilliseconds);
+        }
+        catch (TimeoutException)
+        {
+            return null;
+        }
+
+        using var reader = new StreamReader(pipe, leaveOpen: true);
+        using var writer = new StreamWriter(pipe, leaveOpen: true) { AutoFlush = true };
+        writer.WriteLine(command);
+        var wait = replyTimeout ?? DefaultReplyTimeout;
+        using var cts = new CancellationTokenSource(wait);
+        try
+        {
+            return reader.ReadLineAsync(cts.Token).AsTask().GetAwaiter().GetResult();
+        }
+        catch (OperationCanceledException)
+        {
+            return $"error: no reply within {wait.TotalSeconds:0} s";
+        }
+    }
+}
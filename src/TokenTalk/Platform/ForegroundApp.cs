@@ -0,0 +1,38 @@
+using System.Diagnostics;
+
+namespace TokenTalk.Platform;
+
+public static class ForegroundApp
+{
+    public static IntPtr GetWindow() => NativeMethods.GetForegroundWindow();
+
+    /// <summary>
+    /// Gives <paramref name="hwnd"/> the focus back, e.g. after the tray menu took it. Returns
+    /// false if the window is gone or Windows refused.
+    /// </summary>
+    public static bool TryActivate(IntPtr hwnd)
+    {
+        if (hwnd == NativeMethods.GetForegroundWindow())
+            return true;
+        return NativeMethods.IsWindow(hwnd) && NativeMethods.SetForegroundWindow(hwnd);
+    }
+
+    /// <summary>Process name (without ".exe") of the window that has focus, or "" when it can't be determined.</summary>
+    public static string GetProcessName()
+    {
+        var hwnd = NativeMethods.GetForegroundWindow();
+        if (hwnd == IntPtr.Zero)
+            return "";
+        NativeMethods.GetWindowThreadProcessId(hwnd, out var processId);
+        try
+        {
+            using var process = Process.GetProcessById((int)processId);
+            return process.ProcessName;
+        }
+        catch (ArgumentException)
+        {
+            // Exited between the two calls
+            return "";
+        }
+    }
+}
@@ -43,6 +43,14 @@ public class HotkeyListener : IDisposable
 
     public ChannelReader<HotkeyEvent> Events => _channel.Reader;
 
+    // True while the low-level keyboard hook is installed
+    public bool IsActive => _hookHandle != IntPtr.Zero;
+
+    public string Hotkey { get; private set; } = "";
+
+    // True while any modifier is physically held, as seen by the hook
+    public bool ModifiersDown => _ctrlDown || _shiftDown || _altDown || _winDown;
+
     public void Start(string hotkey)
     {
         ParseHotkey(hotkey);
@@ -55,8 +63,24 @@ public class HotkeyListener : IDisposable
         _hookThread.Start();
     }
 
+    /// <summary>Switches to a different combo without reinstalling the hook.</summary>
+    public void SetHotkey(string hotkey)
+    {
+        // A held combo would never see its release under the new definition
+        if (_isPressed)
+        {
+            _isPressed = false;
+            _channel.Writer.TryWrite(new HotkeyEvent(HotkeyEventType.Released));
+        }
+        ParseHotkey(hotkey);
+    }
+
     private void ParseHotkey(string hotkey)
     {
+        Hotkey = hotkey;
+        _requireCtrl = _requireShift = _requireAlt = _requireWin = false;
+        _triggerVk = 0;
+
         var parts = hotkey.Split('+', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries);
         foreach (var part in parts)
         {
@@ -83,6 +107,35 @@ public class HotkeyListener : IDisposable
         }
     }
 
+    /// <summary>
+    /// Checks that a hotkey string uses known modifiers and at most one known trigger key.
+    /// </summary>
+    public static bool TryValidate(string hotkey, out string error)
+    {
+        var parts = hotkey.Split('+', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries);
+        if (parts.Length == 0)
+        {
+            error = "Hotkey is empty";
+            return false;
+        }
+
+        int triggers = 0;
+        foreach (var part in parts)
+        {
+            if (part.ToLower() is "ctrl" or "control" or "shift" or "alt" or "win" or "windows")
+                continue;
+            if (VkFromString(part) == 0)
+            {
+                error = $"Unknown key '{part}'";
+                return false;
+            }
+            triggers++;
+        }
+
+        error = triggers > 1 ? "Only one non-modifier key is supported" : "";
+        return triggers <= 1;
+    }
+
     private static int VkFromString(string key)
     {
         if (key.Length == 1)
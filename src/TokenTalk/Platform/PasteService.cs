@@ -1,41 +1,83 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+
 namespace TokenTalk.Platform;
 
 public class PasteService
 {
     private readonly ClipboardService _clipboard;
+    private readonly Func<OutputOptions> _getOptions;
+    private readonly ILogger<PasteService> _logger;
 
-    public PasteService(ClipboardService clipboard)
+    public PasteService(ClipboardService clipboard, Func<OutputOptions> getOptions, ILogger<PasteService> logger)
     {
         _clipboard = clipboard;
+        _getOptions = getOptions;
+        _logger = logger;
     }
 
-    public async Task PasteTextAsync(string text, CancellationToken ct = default)
+    /// <summary>Delivers text according to <see cref="OutputOptions.Mode"/>.</summary>
+    /// <param name="caretBack">Left arrow presses sent once the paste has landed, to leave the
+    /// caret inside the text (see <see cref="PostProcessing.CursorMarker"/>). Ignored in clipboard mode.</param>
+    public async Task PasteTextAsync(string text, CancellationToken ct = default, int caretBack = 0)
     {
+        var options = _getOptions();
+        var mode = options.Mode;
+        if (mode == OutputModes.Clipboard)
+        {
+            if (!_clipboard.SetText(text))
+                throw new InvalidOperationException("Could not copy the text to the clipboard");
+            return;
+        }
+
         // Save current clipboard
         string original = string.Empty;
         try { original = _clipboard.GetText(); }
         catch { /* ignore */ }
 
+        var app = ForegroundApp.GetProcessName();
+        var overrides = options.Apps.FirstOrDefault(a => string.Equals(a.Process, app, StringComparison.OrdinalIgnoreCase));
+
         // Set clipboard to new text
         _clipboard.SetText(text);
+        var sequence = _clipboard.SequenceNumber;
 
         // Wait for clipboard to be ready
-        await Task.Delay(50, ct);
+        await Task.Delay(overrides?.PrePasteDelayMs ?? options.PrePasteDelayMs, ct);
 
         // Simulate Ctrl+V
         SendCtrlV();
 
         // Wait for target app to process paste
-        await Task.Delay(100, ct);
+        await Task.Delay(overrides?.PostPasteDelayMs ?? options.PostPasteDelayMs, ct);
+        TextTyper.MoveLeft(caretBack);
 
-        // Restore clipboard
-        if (!string.IsNullOrEmpty(original))
+        // Restore clipboard, unless the text is meant to stay there
+        if (mode != OutputModes.Both && !string.IsNullOrEmpty(original))
         {
+            var restoreDelay = overrides?.RestoreDelayMs ?? options.RestoreDelayMs;
+            if (restoreDelay > 0)
+                await Task.Delay(restoreDelay, ct);
+
+            // Something copied while we waited is newer than what we saved; putting the old text
+            // back would lose it. Some apps re-set the same text on paste, so content decides
+            if (_clipboard.SequenceNumber != sequence && !ClipboardHolds(text))
+            {
+                _logger.LogInformation("Clipboard changed during paste, not restoring it");
+                return;
+            }
+
             try { _clipboard.SetText(original); }
             catch { /* ignore */ }
         }
     }
 
+    private bool ClipboardHolds(string text)
+    {
+        try { return _clipboard.GetText() == text; }
+        catch { return false; }
+    }
+
     private static void SendCtrlV()
     {
         var inputs = new NativeMethods.INPUT[]
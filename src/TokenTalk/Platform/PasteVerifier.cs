@@ -0,0 +1,68 @@
+using System.Windows.Automation;
+
+namespace TokenTalk.Platform;
+
+public static class InjectionStatuses
+{
+    // The focused field was read back and ends with the pasted text
+    public const string Verified = "verified";
+    // The field was read back and the text is not there
+    public const string Failed = "failed";
+    // The field exposes no text to UI Automation (many terminals, games, remote sessions)
+    public const string Unverifiable = "unverifiable";
+}
+
+/// <summary>
+/// Checks after a paste that the text actually reached the focused field by reading the field
+/// back through UI Automation. Only the end of the text is compared, with whitespace removed,
+/// since editors re-wrap, auto-indent or auto-complete what they receive.
+/// </summary>
+public static class PasteVerifier
+{
+    private const int CompareChars = 40;
+    // UI Automation calls go into the target process and can hang on a busy app
+    private static readonly TimeSpan ReadTimeout = TimeSpan.FromSeconds(1);
+
+    /// <summary>One of <see cref="InjectionStatuses"/>.</summary>
+    public static async Task<string> VerifyAsync(string text, CancellationToken ct = default)
+    {
+        var expected = Tail(text);
+        if (expected.Length == 0)
+            return InjectionStatuses.Verified;
+
+        var read = Task.Run(ReadFocusedText, ct);
+        var finished = await Task.WhenAny(read, Task.Delay(ReadTimeout, ct));
+        if (finished != read || read.IsFaulted)
+            return InjectionStatuses.Unverifiable;
+
+        var actual = read.Result;
+        if (actual == null)
+            return InjectionStatuses.Unverifiable;
+        // The caret may not be at the end of the field, so the text can be anywhere in it
+        return Strip(actual).Contains(expected, StringComparison.Ordinal)
+            ? InjectionStatuses.Verified
+            : InjectionStatuses.Failed;
+    }
+
+    // Null when the focused element has neither a value nor a text pattern
+    private static string? ReadFocusedText()
+    {
+        var element = AutomationElement.FocusedElement;
+        if (element == null)
+            return null;
+        if (element.TryGetCurrentPattern(ValuePattern.Pattern, out var value))
+            return ((ValuePattern)value).Current.Value;
+        if (element.TryGetCurrentPattern(TextPattern.Pattern, out var textPattern))
+            return ((TextPattern)textPattern).DocumentRange.GetText(-1);
+        return null;
+    }
+
+    private static string Tail(string text)
+    {
+        var stripped = Strip(text);
+        return stripped.Length <= CompareChars ? stripped : stripped[^CompareChars..];
+    }
+
+    private static string Strip(string text) =>
+        string.Concat(text.Where(c => !char.IsWhiteSpace(c)));
+}
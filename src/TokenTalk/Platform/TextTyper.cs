@@ -0,0 +1,119 @@
+using System.Globalization;
+using System.Runtime.InteropServices;
+
+namespace TokenTalk.Platform;
+
+/// <summary>
+/// Types text into the focused window as Unicode keystrokes, without touching the clipboard.
+/// Slower than a paste for long text, but it can be corrected in place with backspaces.
+/// </summary>
+public static class TextTyper
+{
+    public static void Type(string text)
+    {
+        if (text.Length == 0)
+            return;
+
+        var inputs = new List<NativeMethods.INPUT>(text.Length * 2);
+        foreach (var c in text)
+        {
+            switch (c)
+            {
+                case '\r':
+                    continue;
+                case '\n':
+                    AddKey(inputs, NativeMethods.VK_RETURN);
+                    break;
+                default:
+                    inputs.Add(Unicode(c, 0));
+                    inputs.Add(Unicode(c, NativeMethods.KEYEVENTF_KEYUP));
+                    break;
+            }
+        }
+        Send(inputs);
+    }
+
+    public static void Backspace(int count)
+    {
+        if (count <= 0)
+            return;
+
+        var inputs = new List<NativeMethods.INPUT>(count * 2);
+        for (var i = 0; i < count; i++)
+            AddKey(inputs, NativeMethods.VK_BACK);
+        Send(inputs);
+    }
+
+    /// <summary>Moves the caret <paramref name="count"/> characters to the left.</summary>
+    public static void MoveLeft(int count)
+    {
+        if (count <= 0)
+            return;
+
+        var inputs = new List<NativeMethods.INPUT>(count * 2);
+        for (var i = 0; i < count; i++)
+        {
+            inputs.Add(Key(NativeMethods.VK_LEFT, NativeMethods.KEYEVENTF_EXTENDEDKEY));
+            inputs.Add(Key(NativeMethods.VK_LEFT, NativeMethods.KEYEVENTF_EXTENDEDKEY | NativeMethods.KEYEVENTF_KEYUP));
+        }
+        Send(inputs);
+    }
+
+    /// <summary>Sends Ctrl+Z, the target app's own undo.</summary>
+    public static void Undo()
+    {
+        var inputs = new List<NativeMethods.INPUT>(4)
+        {
+            Key(NativeMethods.VK_CONTROL, 0),
+        };
+        AddKey(inputs, NativeMethods.VK_Z);
+        inputs.Add(Key(NativeMethods.VK_CONTROL, NativeMethods.KEYEVENTF_KEYUP));
+        Send(inputs);
+    }
+
+    /// <summary>Number of backspaces that delete <paramref name="text"/> typed or pasted before the caret.</summary>
+    public static int BackspacesFor(string text) =>
+        new StringInfo(text.Replace("\r", "")).LengthInTextElements;
+
+    private static void AddKey(List<NativeMethods.INPUT> inputs, int vk)
+    {
+        inputs.Add(Key(vk, 0));
+        inputs.Add(Key(vk, NativeMethods.KEYEVENTF_KEYUP));
+    }
+
+    private static NativeMethods.INPUT Key(int vk, uint flags) => new()
+    {
+        type = NativeMethods.INPUT_KEYBOARD,
+        u = new NativeMethods.InputUnion
+        {
+            ki = new NativeMethods.KEYBDINPUT
+            {
+                wVk = (ushort)vk,
+                dwFlags = flags,
+                dwExtraInfo = NativeMethods.GetMessageExtraInfo()
+            }
+        }
+    };
+
+    private static NativeMethods.INPUT Unicode(char c, uint flags) => new()
+    {
+        type = NativeMethods.INPUT_KEYBOARD,
+        u = new NativeMethods.InputUnion
+        {
+            ki = new NativeMethods.KEYBDINPUT
+            {
+                wScan = c,
+                dwFlags = NativeMethods.KEYEVENTF_UNICODE | flags,
+                dwExtraInfo = NativeMethods.GetMessageExtraInfo()
+            }
+        }
+    };
+
+    private static void Send(List<NativeMethods.INPUT> inputs)
+    {
+        var array = inputs.ToArray();
+        var sent = NativeMethods.SendInput((uint)array.Length, array, Marshal.SizeOf<NativeMethods.INPUT>());
+        if (sent != array.Length)
+            throw new InvalidOperationException($"SendInput delivered {sent} of {array.Length} key events; the target may be running elevated");
+    }
+}
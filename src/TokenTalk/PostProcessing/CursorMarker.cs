@@ -0,0 +1,28 @@
+using TokenTalk.Platform;
+
+namespace TokenTalk.PostProcessing;
+
+/// <summary>
+/// <c>{|}</c> in a dictionary replacement marks where the caret should be left once the text is
+/// in, so a spoken phrase can expand to a template and the user keeps talking inside it:
+/// "parens -> ({|})" or "if block -> if ({|}) { }". With several markers the last one wins.
+/// </summary>
+public static class CursorMarker
+{
+    public const string Marker = "{|}";
+
+    public static string Strip(string text) => text.Replace(Marker, "", StringComparison.Ordinal);
+
+    /// <summary>
+    /// The text without markers, and how many Left arrow presses from the end of it put the
+    /// caret at the last marker; 0 when there is none.
+    /// </summary>
+    public static (string Text, int CaretBack) Extract(string text)
+    {
+        var at = text.LastIndexOf(Marker, StringComparison.Ordinal);
+        if (at < 0)
+            return (text, 0);
+        // One press per character as the field sees it, the same count that would delete it
+        return (Strip(text), TextTyper.BackspacesFor(Strip(text[(at + Marker.Length)..])));
+    }
+}
@@ -12,6 +12,10 @@ public class DictionaryEntry
 
     [JsonPropertyName("isMapping")]
     public bool IsMapping { get; set; }
+
+    // Language code the mapping is for, e.g. "sv"; empty applies it whatever the language
+    [JsonPropertyName("language")]
+    public string Language { get; set; } = string.Empty;
 }
 
 public class CustomDictionary
@@ -21,6 +25,10 @@ public class CustomDictionary
     public IEnumerable<string> GetSimpleTerms() =>
         Entries.Where(e => !e.IsMapping).Select(e => e.Replacement);
 
-    public IEnumerable<(string Original, string Replacement)> GetMappings() =>
-        Entries.Where(e => e.IsMapping).Select(e => (e.Original, e.Replacement));
+    /// <param name="language">Language of the text; null when unknown, which applies every mapping.</param>
+    public IEnumerable<(string Original, string Replacement)> GetMappings(string? language = null) =>
+        Entries
+            .Where(e => e.IsMapping)
+            .Where(e => language == null || e.Language.Length == 0 || string.Equals(e.Language, language, StringComparison.OrdinalIgnoreCase))
+            .Select(e => (e.Original, e.Replacement));
 }
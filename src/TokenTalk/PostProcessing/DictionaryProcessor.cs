@@ -12,7 +12,7 @@ public class DictionaryProcessor : IPostProcessor
     public Task<string> ProcessAsync(string text, CancellationToken ct = default)
     {
         var result = text;
-        foreach (var (original, replacement) in _dictionary.GetMappings())
+        foreach (var (original, replacement) in _dictionary.GetMappings(PostProcessingContext.Language))
         {
             result = ReplaceInsensitive(result, original, replacement);
         }
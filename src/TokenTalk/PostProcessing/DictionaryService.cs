@@ -29,14 +29,16 @@ public class DictionaryService
 
                 if (trimmed.Contains("->"))
                 {
-                    var parts = trimmed.Split("->", 2);
+                    var (language, mapping) = SplitLanguage(trimmed);
+                    var parts = mapping.Split("->", 2);
                     if (parts.Length == 2)
                     {
                         entries.Add(new DictionaryEntry
                         {
                             Original = parts[0].Trim(),
                             Replacement = parts[1].Trim(),
-                            IsMapping = true
+                            IsMapping = true,
+                            Language = language
                         });
                     }
                 }
@@ -71,9 +73,21 @@ public class DictionaryService
             writer.WriteLine(entry.Replacement);
 
         writer.WriteLine();
-        writer.WriteLine("# Correction mappings (misheard -> correct):");
+        writer.WriteLine("# Correction mappings (misheard -> correct), \"[sv] \" in front limits one to a language:");
         foreach (var entry in dictionary.Entries.Where(e => e.IsMapping))
-            writer.WriteLine($"{entry.Original} -> {entry.Replacement}");
+        {
+            var prefix = entry.Language.Length > 0 ? $"[{entry.Language}] " : "";
+            writer.WriteLine($"{prefix}{entry.Original} -> {entry.Replacement}");
+        }
+    }
+
+    // "[sv] mejl -> e-post" applies only to Swedish dictations; a line without the prefix to all
+    private static (string Language, string Mapping) SplitLanguage(string line)
+    {
+        var close = line.IndexOf(']');
+        if (!line.StartsWith('[') || close < 2 || close > line.IndexOf("->", StringComparison.Ordinal))
+            return ("", line);
+        return (line[1..close].Trim().ToLowerInvariant(), line[(close + 1)..].TrimStart());
     }
 
     public static string ResolvePath(string path)
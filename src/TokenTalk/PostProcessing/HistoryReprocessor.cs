@@ -0,0 +1,81 @@
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Storage;
+
+namespace TokenTalk.PostProcessing;
+
+/// <param name="Edited">Dictations left alone because their text was edited by hand.</param>
+public record ReprocessResult(int Scanned, int Changed, int Edited, bool DryRun);
+
+/// <summary>
+/// Runs the raw provider text of past dictations through the current pipeline again and stores
+/// the result as their final text, so dictionary and command improvements reach older history
+/// (and exports of it). Dictations edited by hand keep the edit. One job runs at a time.
+/// </summary>
+public class HistoryReprocessor
+{
+    private const int BatchSize = 200;
+
+    private readonly DictationRepository _repository;
+    private readonly PostProcessingPipeline _pipeline;
+    private readonly ILogger<HistoryReprocessor> _logger;
+    private int _running;
+
+    public HistoryReprocessor(DictationRepository repository, PostProcessingPipeline pipeline, ILogger<HistoryReprocessor> logger)
+    {
+        _repository = repository;
+        _pipeline = pipeline;
+        _logger = logger;
+    }
+
+    /// <param name="from">Only dictations since then; null for all history.</param>
+    /// <param name="dryRun">Count what would change without saving.</param>
+    /// <exception cref="InvalidOperationException">Another job is already running.</exception>
+    public async Task<ReprocessResult> ReprocessAsync(DateTime? from, bool dryRun, CancellationToken ct = default)
+    {
+        if (Interlocked.Exchange(ref _running, 1) == 1)
+            throw new InvalidOperationException("A reprocessing job is already running");
+
+        try
+        {
+            int scanned = 0, changed = 0, edited = 0;
+            long lastId = 0;
+            while (true)
+            {
+                var batch = await _repository.GetReprocessBatchAsync(from, lastId, BatchSize, ct);
+                if (batch.Count == 0)
+                    break;
+                lastId = batch[^1].Id;
+
+                var updates = new List<(long Id, string Text, string Stages)>();
+                foreach (var dictation in batch)
+                {
+                    scanned++;
+                    if (dictation.OriginalText != null)
+                    {
+                        edited++;
+                        continue;
+                    }
+                    PostProcessingContext.Language = dictation.Language is "" or "auto" ? null : dictation.Language;
+                    var result = await _pipeline.ProcessWithStagesAsync(dictation.RawText!, ct);
+                    var text = CursorMarker.Strip(result.Text);
+                    if (text == dictation.TranscribedText)
+                        continue;
+                    changed++;
+                    updates.Add((dictation.Id, text, JsonSerializer.Serialize(result.Stages)));
+                }
+
+                if (!dryRun && updates.Count > 0)
+                    await _repository.UpdateProcessedTextAsync(updates, ct);
+            }
+
+            _logger.LogInformation("Reprocessed history: {Changed} of {Scanned} dictation(s) changed, {Edited} edited skipped{DryRun}",
+                changed, scanned, edited, dryRun ? " (dry run)" : "");
+            return new ReprocessResult(scanned, changed, edited, dryRun);
+        }
+        finally
+        {
+            Volatile.Write(ref _running, 0);
+        }
+    }
+}
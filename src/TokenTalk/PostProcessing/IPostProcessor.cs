@@ -2,5 +2,18 @@ namespace TokenTalk.PostProcessing;
 
 public interface IPostProcessor
 {
+    // Disabled processors are skipped and not recorded as a pipeline stage
+    bool IsEnabled => true;
+
     Task<string> ProcessAsync(string text, CancellationToken ct = default);
 }
+
+/// <summary>
+/// A stage that asks a service for edits rather than rewriting text itself. Being slow and
+/// independent of the local stages' work, its check can be started on the pipeline's input
+/// while they run (<c>PostProcessing.ConcurrentStages</c>).
+/// </summary>
+public interface IRemotePostProcessor : IPostProcessor
+{
+    Task<IReadOnlyList<TextEdit>> CheckAsync(string text, CancellationToken ct = default);
+}
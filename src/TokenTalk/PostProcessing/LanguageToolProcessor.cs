@@ -0,0 +1,71 @@
+using System.Text.Json;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.PostProcessing;
+
+/// <summary>
+/// Grammar correction by a LanguageTool server, usually one running locally
+/// (<c>java -jar languagetool-server.jar --port 8081</c> or the Docker image), so it costs
+/// nothing and works offline. Each match's first suggestion is applied; spelling matches are
+/// left alone, since the transcript's spelling comes from the model and the dictionary and
+/// LanguageTool would "fix" names and jargon it doesn't know.
+/// </summary>
+public class LanguageToolProcessor : IRemotePostProcessor
+{
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly Func<PostProcessingOptions> _getOptions;
+
+    public LanguageToolProcessor(IHttpClientFactory httpClientFactory, Func<PostProcessingOptions> getOptions)
+    {
+        _httpClientFactory = httpClientFactory;
+        _getOptions = getOptions;
+    }
+
+    public bool IsEnabled => _getOptions().GrammarProvider == GrammarProviders.LanguageTool;
+
+    public async Task<string> ProcessAsync(string text, CancellationToken ct = default)
+        => TextEdit.Apply(text, await CheckAsync(text, ct));
+
+    public async Task<IReadOnlyList<TextEdit>> CheckAsync(string text, CancellationToken ct = default)
+    {
+        if (string.IsNullOrWhiteSpace(text))
+            return [];
+
+        var options = _getOptions().LanguageTool;
+        var form = new Dictionary<string, string>
+        {
+            ["text"] = text,
+            // The dictation's language when it is known; LanguageTool guesses otherwise
+            ["language"] = PostProcessingContext.Language ?? "auto",
+        };
+        if (options.DisabledRules.Count > 0)
+            form["disabledRules"] = string.Join(',', options.DisabledRules);
+
+        using var client = _httpClientFactory.CreateClient("LanguageTool");
+        using var content = new FormUrlEncodedContent(form);
+        using var response = await client.PostAsync(options.Url.TrimEnd('/') + "/v2/check", content, ct);
+        if (!response.IsSuccessStatusCode)
+        {
+            var errorBody = await response.Content.ReadAsStringAsync(ct);
+            throw new HttpRequestException(
+                $"LanguageTool error ({response.StatusCode}): {errorBody}", null, response.StatusCode);
+        }
+
+        using var doc = JsonDocument.Parse(await response.Content.ReadAsStringAsync(ct));
+        var edits = new List<TextEdit>();
+        foreach (var match in doc.RootElement.GetProperty("matches").EnumerateArray())
+        {
+            if (match.TryGetProperty("rule", out var rule)
+                && rule.TryGetProperty("issueType", out var issueType)
+                && issueType.GetString() == "misspelling")
+                continue;
+            var replacements = match.GetProperty("replacements");
+            if (replacements.GetArrayLength() == 0)
+                continue;
+            // Offsets are UTF-16 positions, the same as .NET string indices
+            edits.Add(new TextEdit(match.GetProperty("offset").GetInt32(), match.GetProperty("length").GetInt32(),
+                replacements[0].GetProperty("value").GetString() ?? ""));
+        }
+        return edits;
+    }
+}
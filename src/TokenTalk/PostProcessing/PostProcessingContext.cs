@@ -0,0 +1,28 @@
+using TokenTalk.Configuration;
+
+namespace TokenTalk.PostProcessing;
+
+/// <summary>
+/// Per-dictation facts the processors can't get from the text alone, flowing with the async call
+/// like <see cref="Transcription.TranscriptionContext"/> does for the providers.
+/// </summary>
+public static class PostProcessingContext
+{
+    private static readonly AsyncLocal<string?> _language = new();
+    private static readonly AsyncLocal<PostProcessingOptions?> _options = new();
+
+    // Language code of the text being processed, as detected by the provider or configured;
+    // null when it isn't known, in which case every language's dictionary entries apply
+    public static string? Language
+    {
+        get => _language.Value;
+        set => _language.Value = value;
+    }
+
+    // The PostProcessing section of the profile the dictation's hotkey is bound to; null uses the config
+    public static PostProcessingOptions? Options
+    {
+        get => _options.Value;
+        set => _options.Value = value;
+    }
+}
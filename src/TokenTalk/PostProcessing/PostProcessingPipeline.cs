@@ -2,14 +2,19 @@ using Microsoft.Extensions.Logging;
 
 namespace TokenTalk.PostProcessing;
 
+/// <summary>Final text plus the names of the stages that ran successfully, in order.</summary>
+public record PipelineResult(string Text, IReadOnlyList<string> Stages);
+
 public class PostProcessingPipeline
 {
     private readonly List<IPostProcessor> _processors = [];
     private readonly ILogger<PostProcessingPipeline> _logger;
+    private readonly Func<bool> _concurrentStages;
 
-    public PostProcessingPipeline(ILogger<PostProcessingPipeline> logger)
+    public PostProcessingPipeline(ILogger<PostProcessingPipeline> logger, Func<bool>? concurrentStages = null)
     {
         _logger = logger;
+        _concurrentStages = concurrentStages ?? (() => false);
     }
 
     public void AddProcessor(IPostProcessor processor)
@@ -17,21 +22,83 @@ public class PostProcessingPipeline
         _processors.Add(processor);
     }
 
+    /// <summary>Names of the stages that would run right now.</summary>
+    public IReadOnlyList<string> EnabledStages =>
+        [.. _processors.Where(p => p.IsEnabled).Select(p => p.GetType().Name)];
+
     public async Task<string> ProcessAsync(string text, CancellationToken ct = default)
+        => (await ProcessWithStagesAsync(text, ct)).Text;
+
+    /// <summary>
+    /// Runs every enabled processor in order. <paramref name="onStage"/> receives the stage name and
+    /// the text after each successful stage, for live previews.
+    /// </summary>
+    public async Task<PipelineResult> ProcessWithStagesAsync(
+        string text, CancellationToken ct = default, Action<string, string>? onStage = null)
     {
+        var enabled = _processors.Where(p => p.IsEnabled).ToList();
+        if (_concurrentStages() && enabled is [_, .., IRemotePostProcessor remote])
+            return await ProcessConcurrentlyAsync(text, enabled.GetRange(0, enabled.Count - 1), remote, ct, onStage);
+
         var result = text;
-        foreach (var processor in _processors)
+        var stages = new List<string>();
+        foreach (var processor in enabled)
+            result = await RunStageAsync(processor, result, stages, ct, onStage);
+        return new PipelineResult(result, stages);
+    }
+
+    // The remote check starts on the input while the local stages run. Its edits are then placed
+    // in their output by surrounding text; if a local stage rewrote text next to one of them, the
+    // check is repeated on the final text as it would have been without the overlap.
+    private async Task<PipelineResult> ProcessConcurrentlyAsync(
+        string text, List<IPostProcessor> local, IRemotePostProcessor remote,
+        CancellationToken ct, Action<string, string>? onStage)
+    {
+        var check = remote.CheckAsync(text, ct);
+        var result = text;
+        var stages = new List<string>();
+        foreach (var processor in local)
+            result = await RunStageAsync(processor, result, stages, ct, onStage);
+
+        IReadOnlyList<TextEdit> edits;
+        try
+        {
+            edits = await check;
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "Post-processor {Processor} failed, continuing with previous text", remote.GetType().Name);
+            return new PipelineResult(result, stages);
+        }
+
+        var rebased = TextEdit.Rebase(text, result, edits);
+        if (rebased == null)
+        {
+            _logger.LogDebug("Local stages touched text near a {Processor} edit, checking again", remote.GetType().Name);
+            result = await RunStageAsync(remote, result, stages, ct, onStage);
+            return new PipelineResult(result, stages);
+        }
+        result = TextEdit.Apply(result, rebased);
+        stages.Add(remote.GetType().Name);
+        onStage?.Invoke(remote.GetType().Name, result);
+        return new PipelineResult(result, stages);
+    }
+
+    private async Task<string> RunStageAsync(
+        IPostProcessor processor, string text, List<string> stages, CancellationToken ct, Action<string, string>? onStage)
+    {
+        try
+        {
+            var result = await processor.ProcessAsync(text, ct);
+            stages.Add(processor.GetType().Name);
+            onStage?.Invoke(processor.GetType().Name, result);
+            return result;
+        }
+        catch (Exception ex)
         {
-            try
-            {
-                result = await processor.ProcessAsync(result, ct);
-            }
-            catch (Exception ex)
-            {
-                _logger.LogWarning(ex, "Post-processor {Processor} failed, continuing with previous text",
-                    processor.GetType().Name);
-            }
+            _logger.LogWarning(ex, "Post-processor {Processor} failed, continuing with previous text",
+                processor.GetType().Name);
+            return text;
         }
-        return result;
     }
 }
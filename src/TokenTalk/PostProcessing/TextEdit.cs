@@ -0,0 +1,67 @@
+using System.Text;
+
+namespace TokenTalk.PostProcessing;
+
+/// <summary>Replace <paramref name="Length"/> UTF-16 chars at <paramref name="Offset"/> with <paramref name="Replacement"/>.</summary>
+public record TextEdit(int Offset, int Length, string Replacement)
+{
+    // Characters either side of a span that must still match for it to be found again
+    private const int ContextLength = 12;
+
+    /// <summary>
+    /// Applies edits found in <paramref name="text"/>, last first so earlier offsets stay valid.
+    /// Edits overlapping one already applied, or touching a cursor marker, are skipped.
+    /// </summary>
+    public static string Apply(string text, IEnumerable<TextEdit> edits)
+    {
+        var markers = MarkerRanges(text);
+        var sb = new StringBuilder(text);
+        var end = text.Length;
+        foreach (var edit in edits.OrderByDescending(e => e.Offset))
+        {
+            if (edit.Offset < 0 || edit.Offset + edit.Length > end)
+                continue;
+            // A checker sees "{|}" as stray punctuation; the marker must survive for the paste
+            if (markers.Any(m => edit.Offset < m.End && edit.Offset + edit.Length > m.Start))
+                continue;
+            sb.Remove(edit.Offset, edit.Length).Insert(edit.Offset, edit.Replacement);
+            end = edit.Offset;
+        }
+        return sb.ToString();
+    }
+
+    /// <summary>
+    /// Moves edits found in <paramref name="original"/> onto <paramref name="changed"/> by finding
+    /// each span with its surrounding text, or returns null when any can't be placed exactly once,
+    /// meaning another stage rewrote text close to it.
+    /// </summary>
+    public static List<TextEdit>? Rebase(string original, string changed, IReadOnlyList<TextEdit> edits)
+    {
+        if (original == changed)
+            return [.. edits];
+
+        var rebased = new List<TextEdit>(edits.Count);
+        foreach (var edit in edits)
+        {
+            if (edit.Offset < 0 || edit.Offset + edit.Length > original.Length)
+                return null;
+            var start = Math.Max(0, edit.Offset - ContextLength);
+            var stop = Math.Min(original.Length, edit.Offset + edit.Length + ContextLength);
+            var anchor = original[start..stop];
+            var at = changed.IndexOf(anchor, StringComparison.Ordinal);
+            if (at < 0 || changed.IndexOf(anchor, at + 1, StringComparison.Ordinal) >= 0)
+                return null;
+            rebased.Add(edit with { Offset = at + edit.Offset - start });
+        }
+        return rebased;
+    }
+
+    private static List<(int Start, int End)> MarkerRanges(string text)
+    {
+        var ranges = new List<(int Start, int End)>();
+        for (var at = text.IndexOf(CursorMarker.Marker, StringComparison.Ordinal); at >= 0;
+             at = text.IndexOf(CursorMarker.Marker, at + 1, StringComparison.Ordinal))
+            ranges.Add((at, at + CursorMarker.Marker.Length));
+        return ranges;
+    }
+}
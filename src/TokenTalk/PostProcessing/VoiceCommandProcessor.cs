@@ -9,7 +9,7 @@ public class VoiceCommandProcessor : IPostProcessor
         _isEnabled = isEnabled;
     }
 
-    private static readonly (string Phrase, string Replacement)[] Commands =
+    private static readonly (string Phrase, string Replacement)[] English =
     [
         ("new line", "\n"),
         ("newline", "\n"),
@@ -47,13 +47,40 @@ public class VoiceCommandProcessor : IPostProcessor
         ("equals", "="),
     ];
 
+    private static readonly (string Phrase, string Replacement)[] Swedish =
+    [
+        ("ny rad", "\n"),
+        ("nytt stycke", "\n\n"),
+        ("punkt", "."),
+        ("komma", ","),
+        ("frågetecken", "?"),
+        ("utropstecken", "!"),
+        ("semikolon", ";"),
+        ("kolon", ":"),
+    ];
+
+    // Keyed by the language code providers detect; a language missing here gets no commands,
+    // since English phrases like "dot" or "plus" are ordinary words elsewhere
+    private static readonly Dictionary<string, (string Phrase, string Replacement)[]> CommandsByLanguage =
+        new(StringComparer.OrdinalIgnoreCase)
+        {
+            ["en"] = English,
+            ["sv"] = Swedish,
+        };
+
+    // Unknown language keeps the English commands, as before languages were told apart
+    private static (string Phrase, string Replacement)[] CommandsFor(string? language) =>
+        language == null ? English : CommandsByLanguage.GetValueOrDefault(language, []);
+
+    public bool IsEnabled => _isEnabled();
+
     public Task<string> ProcessAsync(string text, CancellationToken ct = default)
     {
         if (!_isEnabled())
             return Task.FromResult(text);
 
         var result = text;
-        foreach (var (phrase, replacement) in Commands)
+        foreach (var (phrase, replacement) in CommandsFor(PostProcessingContext.Language))
         {
             result = ReplaceWithWordBoundaries(result, phrase, replacement);
         }
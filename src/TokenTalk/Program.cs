@@ -1,9 +1,14 @@
+using System.Data.Common;
 using System.Windows;
 using Microsoft.EntityFrameworkCore;
 using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
 using TokenTalk;
 using TokenTalk.Audio;
 using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+using TokenTalk.Integrations;
+using TokenTalk.Notifications;
 using TokenTalk.Platform;
 using TokenTalk.PostProcessing;
 using TokenTalk.Storage;
@@ -12,21 +17,179 @@ using TokenTalk.Overlay;
 using TokenTalk.Tray;
 using TokenTalk.UI;
 using TokenTalk.UI.ViewModels;
+using TokenTalk.Updates;
 
 namespace TokenTalk;
 
 public class Program
 {
     [STAThread]
-    public static void Main()
+    public static void Main(string[] args)
     {
+        // A WinExe has no console of its own; without this, CLI output and errors are lost
+        // unless redirected. Failing is fine: started from Explorer there is nothing to attach to
+        if (args.Length > 0)
+            NativeMethods.AttachConsole(NativeMethods.ATTACH_PARENT_PROCESS);
+
+        CommandLineOptions options;
+        try
+        {
+            options = CommandLineOptions.Parse(args);
+        }
+        catch (ArgumentException ex)
+        {
+            Console.Error.WriteLine(ex.Message);
+            Environment.ExitCode = 1;
+            return;
+        }
+        var pipeName = ControlPipeServer.GetPipeName(options.Instance);
+        var command = options.Command;
+
+        if (command is ["--version", ..])
+        {
+            Console.WriteLine(BuildInfo.Summary);
+            return;
+        }
+
+        // `TokenTalk --record start|stop|cancel|toggle` drives the running instance and exits;
+        // `TokenTalk --status` prints its current state as JSON
+        if (command is ["--record", ..])
+        {
+            Environment.ExitCode = SendControlCommand(pipeName, $"record {(command.Length > 1 ? command[1] : "toggle")}");
+            return;
+        }
+        // `TokenTalk --action <name>` runs a named action; without a name it lists them
+        if (command is ["--action", ..])
+        {
+            Environment.ExitCode = SendControlCommand(pipeName, command.Length > 1 ? $"action {command[1]}" : "actions");
+            return;
+        }
+        if (command is ["--status", ..])
+        {
+            Environment.ExitCode = SendControlCommand(pipeName, "status");
+            return;
+        }
+        // `TokenTalk --runtime` prints memory, GC and thread figures of the running instance (developer mode only)
+        if (command is ["--runtime", ..])
+        {
+            Environment.ExitCode = SendControlCommand(pipeName, "debug runtime");
+            return;
+        }
+        // `TokenTalk --wipe-data [--dictionary]` asks the running instance for a confirmation token;
+        // `TokenTalk --wipe-data <token> [--dictionary]` then deletes history, kept audio and logs
+        if (command is ["--wipe-data", .. var wipeArgs])
+        {
+            var token = wipeArgs.FirstOrDefault(a => !a.StartsWith("--", StringComparison.Ordinal));
+            var dictionaryFlag = wipeArgs.Contains("--dictionary") ? " dictionary" : "";
+            Environment.ExitCode = SendControlCommand(pipeName, token == null ? "data wipe" : $"data wipe {token}{dictionaryFlag}");
+            return;
+        }
+        // `TokenTalk --reprocess [--days N] [--dry-run]` has the running instance re-run stored raw
+        // transcripts through the current post-processing pipeline
+        if (command is ["--reprocess", .. var reprocessFlags])
+        {
+            var daysAt = Array.IndexOf(reprocessFlags, "--days");
+            var reprocessArgs = daysAt >= 0 && daysAt + 1 < reprocessFlags.Length ? $" {reprocessFlags[daysAt + 1]}" : "";
+            if (reprocessFlags.Contains("--dry-run"))
+                reprocessArgs += " dry-run";
+            Environment.ExitCode = SendControlCommand(pipeName, $"history reprocess{reprocessArgs}");
+            return;
+        }
+        // `TokenTalk --trace` dumps the running instance's recent pipeline traces (developer mode only)
+        if (command is ["--trace", ..])
+        {
+            Environment.ExitCode = SendControlCommand(pipeName, "debug trace");
+            return;
+        }
+        // Exit codes follow the Nagios convention: 0 healthy, 1 degraded, 2 unhealthy or not running
+        if (command is ["--health", ..])
+        {
+            Environment.ExitCode = SendHealthCommand(pipeName);
+            return;
+        }
+        // `TokenTalk --check-config` lists every problem in the config file; exit code 1 if there are any
+        if (command is ["--check-config", ..])
+        {
+            var problems = ConfigManager.Check(options.ConfigPath ?? ConfigManager.GetConfigPath());
+            foreach (var problem in problems)
+                Console.WriteLine($"{problem.Field}: {problem.Message}");
+            if (problems.Count == 0)
+                Console.WriteLine("Config OK");
+            Environment.ExitCode = problems.Count == 0 ? 0 : 1;
+            return;
+        }
+        // `TokenTalk --devices` lists capture devices with the index Audio.DeviceIndex expects
+        if (command is ["--devices", ..])
+        {
+            var devices = AudioDevices.List();
+            Console.WriteLine($"{AudioRecorder.DefaultDevice,3}  Default (follows Windows)");
+            foreach (var device in devices)
+                Console.WriteLine($"{device.Index,3}  {device.Name}{(device.IsDefault ? "  (default)" : "")}");
+            if (devices.Count == 0)
+                Console.WriteLine("No capture devices found");
+            return;
+        }
+        // `TokenTalk --stats [--days N] [--json]` prints usage statistics from the history database
+        if (command is ["--stats", .. var statsFlags])
+        {
+            Environment.ExitCode = PrintStats(options, statsFlags);
+            return;
+        }
+        // `TokenTalk --eval <folder> [--provider openai|whisper.cpp] [--raw] [--json]` scores a provider
+        // against reference recordings and stores the word error rates; `--eval-runs` lists past runs
+        if (command is ["--eval", var evalFolder, .. var evalFlags])
+        {
+            Environment.ExitCode = RunEvaluation(options, evalFolder, evalFlags);
+            return;
+        }
+        if (command is ["--eval-runs", .. var evalRunsFlags])
+        {
+            Environment.ExitCode = PrintEvaluationRuns(options, evalRunsFlags);
+            return;
+        }
+        // `TokenTalk --export-config <file>` writes the config with secrets replaced by placeholders
+        if (command is ["--export-config", var exportPath, ..])
+        {
+            var source = new ConfigManager(options.ConfigPath ?? ConfigManager.GetConfigPath(), NullLogger<ConfigManager>.Instance);
+            File.WriteAllText(exportPath, ConfigTransfer.Export(source.Snapshot()));
+            Console.WriteLine($"Exported to {Path.GetFullPath(exportPath)}");
+            return;
+        }
+        // `TokenTalk --import-config <file> [--dry-run]` validates and applies an exported config;
+        // a running instance picks the change up through its file watcher
+        if (command is ["--import-config", var importPath, .. var importFlags])
+        {
+            var target = new ConfigManager(options.ConfigPath ?? ConfigManager.GetConfigPath(), NullLogger<ConfigManager>.Instance);
+            var result = ConfigTransfer.Import(target, File.ReadAllText(importPath), importFlags.Contains("--dry-run"));
+            foreach (var problem in result.Problems)
+                Console.WriteLine($"{problem.Field}: {problem.Message}");
+            foreach (var change in result.Changes)
+                Console.WriteLine(change);
+            if (result.Changes.Count == 0)
+                Console.WriteLine("No changes");
+            Console.WriteLine(result.Applied ? "Imported" : result.Problems.Count > 0 ? "Not imported" : "Dry run, nothing saved");
+            Environment.ExitCode = result.Problems.Count == 0 ? 0 : 1;
+            return;
+        }
+        // `TokenTalk --mcp` serves MCP over stdio for AI assistants, proxying to the running instance
+        if (command is ["--mcp", ..])
+        {
+            new McpServer(pipeName, Console.In, Console.Out).RunAsync().GetAwaiter().GetResult();
+            return;
+        }
+
         var cts = new CancellationTokenSource();
 
         // ── Logging ──────────────────────────────────────────────────────
+        // The filters read these, so the Logging section can apply once the config is loaded
+        var minLevel = options.LogLevel ?? LogLevel.Information;
+        var logToConsole = true;
         using var loggerFactory = LoggerFactory.Create(builder =>
         {
             builder
-                .SetMinimumLevel(LogLevel.Information)
+                .SetMinimumLevel(LogLevel.Trace)
+                .AddFilter(level => level >= minLevel)
+                .AddFilter<Microsoft.Extensions.Logging.Console.ConsoleLoggerProvider>((_, level) => logToConsole && level >= minLevel)
                 .AddSimpleConsole(opts =>
                 {
                     opts.TimestampFormat = "HH:mm:ss ";
@@ -37,94 +200,121 @@ public class Program
         var logger = loggerFactory.CreateLogger<Program>();
 
         // ── Configuration ─────────────────────────────────────────────────
-        var configPath = ConfigManager.GetConfigPath();
-        var configDir = ConfigManager.GetConfigDirectory();
+        // --config moves the whole data directory (database, key, models) next to that file
+        var configPath = options.ConfigPath ?? ConfigManager.GetConfigPath();
+        var configDir = Path.GetDirectoryName(configPath)!;
         Directory.CreateDirectory(configDir);
 
         var configManager = new ConfigManager(configPath, loggerFactory.CreateLogger<ConfigManager>());
+        if (options.Profile != null && !configManager.SwitchProfile(options.Profile))
+            logger.LogWarning("Unknown profile {Profile}, keeping {Active}", options.Profile, configManager.Current.ActiveProfile);
         var cfg = configManager.Current;
+        configManager.StartWatching();
+
+        if (options.LogLevel == null && Enum.TryParse<LogLevel>(cfg.Logging.Level, ignoreCase: true, out var configLevel))
+            minLevel = configLevel;
+        logToConsole = cfg.Logging.Console;
+        FileLoggerProvider? fileLogger = null;
+        if (!string.IsNullOrWhiteSpace(cfg.Logging.File))
+        {
+            // Path.Combine keeps an absolute path as is
+            var logPath = Path.Combine(configDir, cfg.Logging.File);
+            try
+            {
+                fileLogger = new FileLoggerProvider(logPath, cfg.Logging.MaxSizeMb * 1024L * 1024, cfg.Logging.MaxBackups,
+                    json: cfg.Logging.Format == LogFormats.Json);
+                loggerFactory.AddProvider(fileLogger);
+            }
+            catch (Exception ex) when (ex is IOException or UnauthorizedAccessException)
+            {
+                logger.LogWarning(ex, "Cannot write log file {Path}", logPath);
+            }
+        }
 
         logger.LogInformation("TokenTalk starting. Config: {Path}", configPath);
+        logger.LogInformation("{BuildInfo}", BuildInfo.Summary);
 
         // ── Database ──────────────────────────────────────────────────────
         var dbPath = Path.Combine(configDir, "tokentalk.db");
-        var db = new TokenTalkDbContext(dbPath);
-        db.InitializeAsync().GetAwaiter().GetResult();
-        var repository = new DictationRepository(db);
+        TextProtector? protector = TextProtector.LoadOrCreate(
+            Path.Combine(configDir, "storage.key"),
+            () => configManager.Current.History.EncryptText);
+        var postgres = string.Equals(cfg.Storage.Provider, "postgres", StringComparison.OrdinalIgnoreCase)
+            ? cfg.Storage.ConnectionString
+            : null;
+        if (postgres != null)
+        {
+            logger.LogInformation("Using Postgres storage");
+            // The encryption key is DPAPI-bound to this Windows user, so other machines sharing
+            // the database could not read encrypted rows
+            if (cfg.History.EncryptText)
+                logger.LogWarning("History.EncryptText is ignored with Postgres storage");
+            protector = null;
+        }
+        TokenTalkDbContext CreateDbContext() => new(dbPath, protector, postgres);
+        using (var db = CreateDbContext())
+            db.InitializeAsync().GetAwaiter().GetResult();
+        var repository = new DictationRepository(
+            CreateDbContext,
+            () => TimeSpan.FromMinutes(Math.Max(1, configManager.Current.History.SessionGapMinutes)));
+        var retention = new HistoryRetentionService(
+            repository,
+            () => configManager.Current.History,
+            loggerFactory.CreateLogger<HistoryRetentionService>());
+        var goals = new GoalTracker(
+            repository,
+            () => configManager.Current.Goals,
+            loggerFactory.CreateLogger<GoalTracker>());
 
         // ── Dictionary ────────────────────────────────────────────────────
         var dictionaryService = new DictionaryService(loggerFactory.CreateLogger<DictionaryService>());
         var dictionary = dictionaryService.Load(cfg.PostProcessing.DictionaryFile);
 
         // ── HTTP Client Factory ───────────────────────────────────────────
-        IHttpClientFactory httpClientFactory = new SimpleHttpClientFactory(TimeSpan.FromSeconds(60));
+        var httpStats = new HttpConnectionStats();
+        // Provider requests and responses go to their own file, written only in developer mode
+        var providerLog = new FileLoggerProvider(Path.Combine(configDir, "logs", "providers.log"),
+            cfg.Logging.MaxSizeMb * 1024L * 1024, cfg.Logging.MaxBackups, json: true);
+        IHttpClientFactory httpClientFactory = new SimpleHttpClientFactory(TimeSpan.FromSeconds(60), httpStats,
+            providerLog.CreateLogger("ProviderTrace"), () => configManager.Current.DeveloperMode);
 
         // ── Model Manager (whisper.cpp local models) ──────────────────────
         var modelsDir = Path.Combine(configDir, "models");
         var modelManager = new ModelManager(modelsDir);
 
         // ── Transcription Provider ────────────────────────────────────────
-        string BuildWhisperPrompt(TokenTalkOptions options)
-        {
-            var sb = new System.Text.StringBuilder(
-                "Transcribe accurately with correct grammar, punctuation, and capitalization. " +
-                "Sentences start with a capital letter and end with a period, question mark, or exclamation mark. " +
-                "Remove filler words (um, uh, like, you know, I mean) unless they carry meaning. " +
-                "Use numerals for specific quantities (e.g., 'five items' → '5 items', 'thirty percent' → '30%'). " +
-                "Preserve proper nouns and brand names with their correct capitalisation. " +
-                "Treat spoken punctuation commands as formatting: 'comma' → ',', 'period' or 'full stop' → '.', 'new line' → line break, 'new paragraph' → paragraph break, 'open quote'/'close quote' → quotation marks. " +
-                "Correct minor grammatical errors while preserving the speaker's intended meaning, voice, and tone. " +
-                "Do not add commentary, explanations, or any text that was not spoken. ");
-
-            if (options.DeveloperMode)
-                sb.Append(
-                    "Developer mode: transcribe all technical content precisely. " +
-                    "Recognise programming languages: C#, F#, VB.NET, Python, JavaScript, TypeScript, Rust, Go, Java, Kotlin, Swift, C, C++, PHP, Ruby. " +
-                    "Recognise frameworks and libraries: .NET, ASP.NET Core, Entity Framework, LINQ, WPF, WinForms, React, Vue, Angular, Next.js, Node.js, Express, FastAPI, Django, Spring Boot. " +
-                    "Recognise cloud and infrastructure terms: Azure, AWS, GCP, Kubernetes, Docker, Terraform, Helm, CI/CD, GitHub Actions, Azure DevOps, Bicep, ARM. " +
-                    "Recognise developer tools: Visual Studio, VS Code, JetBrains Rider, Git, GitHub, GitLab, npm, pnpm, NuGet, pip, cargo, Postman. " +
-                    "Expand acronyms correctly: API, REST, GraphQL, gRPC, SQL, NoSQL, JSON, XML, YAML, HTML, CSS, JWT, OAuth, OIDC, CRUD, ORM, DI, IoC, MVVM, MVC, SPA, PWA, SDK, CLI, IDE, TDD, BDD, DDD, CQRS, SOLID. " +
-                    "Preserve identifier casing: camelCase for variables and methods, PascalCase for classes and types, snake_case or SCREAMING_SNAKE_CASE as spoken. " +
-                    "Recognise spoken code constructs: 'async await', 'try catch finally', 'if else', 'for loop', 'foreach', 'lambda', 'dependency injection', 'interface', 'abstract class', 'generic type', 'null check', 'null coalescing'. ");
-
-            sb.Append("Format output as natural, well-structured text in the configured language.");
-
-            if (!string.IsNullOrEmpty(options.Transcription.Prompt))
-                sb.Append(' ').Append(options.Transcription.Prompt);
-            return sb.ToString();
-        }
-
-        ITranscriptionProvider transcriptionProvider = new TranscriptionProviderFactory(
-            () => configManager.Current.Transcription.Provider,
+        // A dictation started by a hotkey bound to another profile brings that profile's settings
+        TranscriptionOptions CurrentTranscription() => TranscriptionContext.Options ?? configManager.Current.Transcription;
+        var transcriptionProvider = new TranscriptionProviderFactory(
+            CurrentTranscription,
             new OpenAiWhisperProvider(
                 httpClientFactory,
-                () => configManager.Current.Transcription.ApiKey,
-                () => configManager.Current.Transcription.Model,
-                () => configManager.Current.Transcription.Language,
+                () => CurrentTranscription().ApiKey,
+                CurrentTranscription,
+                () => CurrentTranscription().Model,
+                () => TranscriptionContext.Language ?? CurrentTranscription().Language,
                 () => BuildWhisperPrompt(configManager.Current),
                 dictionary.GetSimpleTerms()),
             new WhisperCppProvider(
-                () => configManager.Current.Transcription.ModelPath,
-                () => configManager.Current.Transcription.Language));
+                () => CurrentTranscription().ModelPath,
+                () => TranscriptionContext.Language ?? CurrentTranscription().Language,
+                dictionary.GetSimpleTerms()),
+            loggerFactory.CreateLogger<TranscriptionProviderFactory>());
 
         // ── Post-Processing Pipeline ──────────────────────────────────────
-        var pipeline = new PostProcessingPipeline(loggerFactory.CreateLogger<PostProcessingPipeline>());
-
-        // Dictionary mapping replacement always runs when entries exist (independent of PostProcessing toggle)
-        if (dictionary.Entries.Any(e => e.IsMapping))
-            pipeline.AddProcessor(new DictionaryProcessor(dictionary));
-
-        pipeline.AddProcessor(new VoiceCommandProcessor(() => configManager.Current.PostProcessing.Commands));
+        var pipeline = CreatePipeline(dictionary, configManager, httpClientFactory, loggerFactory);
 
         // ── Platform Services ─────────────────────────────────────────────
         var clipboard = new ClipboardService();
-        var paste = new PasteService(clipboard);
-        var recorder = new AudioRecorder(cfg.Audio.DeviceIndex, cfg.Audio.MaxSeconds);
+        var paste = new PasteService(clipboard, () => configManager.Current.Output, loggerFactory.CreateLogger<PasteService>());
+        var outputTargets = new OutputTargetWriter(httpClientFactory, loggerFactory.CreateLogger<OutputTargetWriter>());
+        var recorder = new AudioRecorder(() => configManager.Current.Audio.DeviceIndex, cfg.Audio.MaxSeconds);
 
         // ── Overlay ───────────────────────────────────────────────────────
         var overlay = new DictationOverlay();
 
         // ── Agent ─────────────────────────────────────────────────────────
+        var audioStore = new FailedAudioStore(Path.Combine(configDir, "audio"));
         var agent = new Agent(
             configManager,
             recorder,
@@ -132,16 +322,41 @@ public class Program
             pipeline,
             clipboard,
             paste,
+            outputTargets,
             repository,
+            audioStore,
             overlay,
             loggerFactory.CreateLogger<Agent>());
 
+        // ── Diagnostics ───────────────────────────────────────────────────
+        var validator = new ConfigValidator(httpClientFactory);
+        var health = new HealthMonitor(
+            () => configManager.Current,
+            validator,
+            repository,
+            () => agent.IsHotkeyActive,
+            loggerFactory.CreateLogger<HealthMonitor>());
+        var debugBundle = new DebugBundleWriter(configManager, repository, health);
+        var wiper = new DataWiper(
+            repository,
+            audioStore,
+            agent.Traces,
+            [fileLogger, providerLog],
+            dictionaryService,
+            dictionary,
+            () => configManager.Current.PostProcessing.DictionaryFile,
+            loggerFactory.CreateLogger<DataWiper>());
+        var reprocessor = new HistoryReprocessor(repository, pipeline, loggerFactory.CreateLogger<HistoryReprocessor>());
+        var deduplicator = new HistoryDeduplicator(repository, loggerFactory.CreateLogger<HistoryDeduplicator>());
+
         // ── WPF Application ───────────────────────────────────────────────
         var wpfApp = new App();
         wpfApp.SetCancellationSource(cts);
 
-        var mainVm = new MainViewModel(agent, repository, configManager, dictionaryService, dictionary, modelManager);
+        var mainVm = new MainViewModel(agent, repository, configManager, dictionaryService, dictionary, modelManager, retention, goals,
+            validator, new OpenAiModelLister(httpClientFactory), debugBundle, wiper);
         var mainWindow = new MainWindow(mainVm);
+        agent.ConfirmPaste = (text, ct) => ConfirmWindow.AskAsync(wpfApp.Dispatcher, text, ct);
 
         // When cts is cancelled (e.g. from tray Quit), shut down WPF
         cts.Token.Register(() =>
@@ -159,7 +374,83 @@ public class Program
                 mainWindow.WindowState = WindowState.Normal;
         });
 
-        var trayManager = new TrayIconManager(cts, showWindow, loggerFactory.CreateLogger<TrayIconManager>());
+        var trayManager = new TrayIconManager(cts, showWindow, configManager, repository, clipboard, loggerFactory.CreateLogger<TrayIconManager>());
+        agent.DictationCompleted += (_, _) => _ = trayManager.RefreshAsync(cts.Token);
+        repository.DictationUpdated += (_, _) => _ = trayManager.RefreshAsync(cts.Token);
+
+        goals.GoalReached += (_, p) => trayManager.ShowNotification(
+            "Daily goal reached",
+            p.CurrentStreak > 1 ? $"{p.TodayWords:N0} words today. {p.CurrentStreak}-day streak!" : $"{p.TodayWords:N0} words today.");
+        agent.DictationCompleted += (_, _) => _ = goals.RefreshAsync();
+        transcriptionProvider.CircuitOpened += (_, reason) => trayManager.ShowNotification(
+            "Transcription provider down", reason, System.Windows.Forms.ToolTipIcon.Warning);
+
+        trayManager.PauseToggled += (_, paused) => agent.SetPaused(paused);
+        agent.PausedChanged += (_, paused) => trayManager.SetPaused(paused);
+        trayManager.ContinuousToggled += (_, on) => agent.SetContinuous(on);
+        trayManager.UndoRequested += (_, _) => _ = agent.UndoLastAsync(cts.Token);
+        agent.ContinuousChanged += (_, on) => trayManager.SetContinuous(on);
+        trayManager.IncognitoToggled += (_, on) => agent.SetIncognito(on);
+        agent.IncognitoChanged += (_, on) => trayManager.SetIncognito(on);
+        configManager.Changed += (_, c) => trayManager.SetGrammar(c.PostProcessing.GrammarProvider != GrammarProviders.None);
+
+        // Warn as soon as a probe fails so the next dictation doesn't come as a surprise
+        var notifier = new DictationNotifier(trayManager, () => configManager.Current.Notifications);
+        health.HealthChanged += (_, report) =>
+        {
+            trayManager.SetHealthStatus(report.Level == HealthLevel.Healthy ? null : report.Summary);
+            notifier.OnHealthChanged(report);
+        };
+
+        // ── Notifiers ─────────────────────────────────────────────────────
+        // Each is enabled by name in Notifications.Notifiers; the overlay follows agent.Bus instead
+        agent.AddNotifier(notifier);
+        agent.AddNotifier(new SoundNotifier());
+        agent.AddNotifier(new WebhookNotifier(
+            httpClientFactory,
+            () => configManager.Current.Webhooks,
+            loggerFactory.CreateLogger<WebhookNotifier>(),
+            cts.Token));
+
+        // Not a notifier: the vault gets every completed dictation whatever Notifications.Notifiers says
+        var obsidian = new ObsidianExporter(
+            repository,
+            () => configManager.Current,
+            Path.Combine(configDir, "obsidian-export.state"),
+            loggerFactory.CreateLogger<ObsidianExporter>());
+        agent.DictationCompleted += (_, e) => obsidian.OnDictationCompleted(e.Dictation);
+
+        // ── Updates ───────────────────────────────────────────────────────
+        var updates = new UpdateChecker(
+            httpClientFactory,
+            () => configManager.Current.Updates,
+            loggerFactory.CreateLogger<UpdateChecker>());
+        updates.UpdateAvailable += (_, update) => trayManager.SetUpdateAvailable(update);
+        var warmer = new ConnectionWarmer(
+            httpClientFactory,
+            () => configManager.Current.Transcription,
+            httpStats,
+            loggerFactory.CreateLogger<ConnectionWarmer>());
+        var usage = new UsageReporter(
+            httpClientFactory,
+            repository,
+            () => configManager.Current.Telemetry,
+            Path.Combine(configDir, "telemetry.last"),
+            loggerFactory.CreateLogger<UsageReporter>());
+        var reports = new WeeklyReporter(
+            httpClientFactory,
+            repository,
+            () => configManager.Current.Reports,
+            Path.Combine(configDir, "reports"),
+            loggerFactory.CreateLogger<WeeklyReporter>());
+
+        // ── Remote control (named pipe) ───────────────────────────────────
+        var controlCommands = new ControlCommandHandler(agent, health, repository, configManager, wiper, reprocessor, httpStats, reports,
+            deduplicator);
+        var controlServer = new ControlPipeServer(
+            pipeName,
+            controlCommands.HandleAsync,
+            loggerFactory.CreateLogger<ControlPipeServer>());
 
         var trayThread = new Thread(() =>
         {
@@ -173,6 +464,19 @@ public class Program
 
         // ── Agent task (background thread) ───────────────────────────────
         var agentTask = Task.Run(() => agent.RunAsync(cts.Token));
+        var retentionTask = Task.Run(() => retention.RunAsync(cts.Token));
+        var controlTask = Task.Run(() => controlServer.RunAsync(cts.Token));
+        var healthTask = Task.Run(() => health.RunAsync(cts.Token));
+        var updateTask = Task.Run(() => updates.RunAsync(cts.Token));
+        var usageTask = Task.Run(() => usage.RunAsync(cts.Token));
+        var reportTask = Task.Run(() => reports.RunAsync(cts.Token));
+        var warmerTask = Task.Run(() => warmer.RunAsync(cts.Token));
+        var obsidianTask = Task.Run(() => obsidian.RunAsync(cts.Token));
+        var trayRefreshTask = Task.Run(async () =>
+        {
+            await trayManager.RefreshAsync(cts.Token);
+            await trayManager.RunAsync(cts.Token);
+        });
 
         logger.LogInformation("All services started. Use tray menu to quit.");
 
@@ -180,17 +484,351 @@ public class Program
         wpfApp.Run(mainWindow);
 
         // ── Cleanup ───────────────────────────────────────────────────────
+        // Dictations already captured finish (or are saved as failed) before anything shuts down
+        try { agent.DrainAsync(TimeSpan.FromSeconds(configManager.Current.Queue.ShutdownWaitSeconds)).Wait(); }
+        catch (AggregateException ex) { logger.LogWarning(ex.InnerException, "Draining dictations failed"); }
+
         cts.Cancel();
 
         try { agentTask.Wait(TimeSpan.FromSeconds(5)); }
         catch (AggregateException) { }
 
+        try { retentionTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { controlTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { healthTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { updateTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { usageTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { reportTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { warmerTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { obsidianTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
+        try { trayRefreshTask.Wait(TimeSpan.FromSeconds(5)); }
+        catch (AggregateException) { }
+
         mainVm.Dispose();
         agent.Dispose();
         overlay.Dispose();
+        configManager.Dispose();
         trayManager.Dispose();
-        db.DisposeAsync().AsTask().Wait(TimeSpan.FromSeconds(5));
+        providerLog.Dispose();
+        // Close pooled connections so SQLite checkpoints the WAL on exit
+        Microsoft.Data.Sqlite.SqliteConnection.ClearAllPools();
 
         logger.LogInformation("TokenTalk stopped.");
     }
+
+    private static string BuildWhisperPrompt(TokenTalkOptions options)
+    {
+        var sb = new System.Text.StringBuilder(
+            "Transcribe accurately with correct grammar, punctuation, and capitalization. " +
+            "Sentences start with a capital letter and end with a period, question mark, or exclamation mark. " +
+            "Remove filler words (um, uh, like, you know, I mean) unless they carry meaning. " +
+            "Use numerals for specific quantities (e.g., 'five items' → '5 items', 'thirty percent' → '30%'). " +
+            "Preserve proper nouns and brand names with their correct capitalisation. " +
+            "Treat spoken punctuation commands as formatting: 'comma' → ',', 'period' or 'full stop' → '.', 'new line' → line break, 'new paragraph' → paragraph break, 'open quote'/'close quote' → quotation marks. " +
+            "Correct minor grammatical errors while preserving the speaker's intended meaning, voice, and tone. " +
+            "Do not add commentary, explanations, or any text that was not spoken. ");
+
+        if (options.DeveloperMode)
+            sb.Append(
+                "Developer mode: transcribe all technical content precisely. " +
+                "Recognise programming languages: C#, F#, VB.NET, Python, JavaScript, TypeScript, Rust, Go, Java, Kotlin, Swift, C, C++, PHP, Ruby. " +
+                "Recognise frameworks and libraries: .NET, ASP.NET Core, Entity Framework, LINQ, WPF, WinForms, React, Vue, Angular, Next.js, Node.js, Express, FastAPI, Django, Spring Boot. " +
+                "Recognise cloud and infrastructure terms: Azure, AWS, GCP, Kubernetes, Docker, Terraform, Helm, CI/CD, GitHub Actions, Azure DevOps, Bicep, ARM. " +
+                "Recognise developer tools: Visual Studio, VS Code, JetBrains Rider, Git, GitHub, GitLab, npm, pnpm, NuGet, pip, cargo, Postman. " +
+                "Expand acronyms correctly: API, REST, GraphQL, gRPC, SQL, NoSQL, JSON, XML, YAML, HTML, CSS, JWT, OAuth, OIDC, CRUD, ORM, DI, IoC, MVVM, MVC, SPA, PWA, SDK, CLI, IDE, TDD, BDD, DDD, CQRS, SOLID. " +
+                "Preserve identifier casing: camelCase for variables and methods, PascalCase for classes and types, snake_case or SCREAMING_SNAKE_CASE as spoken. " +
+                "Recognise spoken code constructs: 'async await', 'try catch finally', 'if else', 'for loop', 'foreach', 'lambda', 'dependency injection', 'interface', 'abstract class', 'generic type', 'null check', 'null coalescing'. ");
+
+        sb.Append("Format output as natural, well-structured text in the configured language.");
+
+        if (!string.IsNullOrEmpty(options.Transcription.Prompt))
+            sb.Append(' ').Append(options.Transcription.Prompt);
+        return sb.ToString();
+    }
+
+    private static PostProcessingPipeline CreatePipeline(
+        CustomDictionary dictionary, ConfigManager configManager, IHttpClientFactory httpClientFactory, ILoggerFactory loggerFactory)
+    {
+        var pipeline = new PostProcessingPipeline(
+            loggerFactory.CreateLogger<PostProcessingPipeline>(),
+            () => (PostProcessingContext.Options ?? configManager.Current.PostProcessing).ConcurrentStages);
+
+        // Dictionary mapping replacement always runs when entries exist (independent of PostProcessing toggle)
+        if (dictionary.Entries.Any(e => e.IsMapping))
+            pipeline.AddProcessor(new DictionaryProcessor(dictionary));
+
+        pipeline.AddProcessor(new VoiceCommandProcessor(() => (PostProcessingContext.Options ?? configManager.Current.PostProcessing).Commands));
+        // Last, so it sees the punctuation voice commands put in
+        pipeline.AddProcessor(new LanguageToolProcessor(httpClientFactory, () => PostProcessingContext.Options ?? configManager.Current.PostProcessing));
+        return pipeline;
+    }
+
+    // The SQLite file next to the config, unless Storage points at Postgres
+    private static (string DbPath, string? Postgres) GetDatabase(string configPath, TokenTalkOptions cfg)
+    {
+        var dbPath = Path.Combine(Path.GetDirectoryName(configPath)!, "tokentalk.db");
+        var postgres = string.Equals(cfg.Storage.Provider, "postgres", StringComparison.OrdinalIgnoreCase)
+            ? cfg.Storage.ConnectionString
+            : null;
+        return (dbPath, postgres);
+    }
+
+    // Builds its own provider from the config rather than asking the running instance, so a
+    // long run doesn't hold up dictation and any provider can be scored, not just the active one
+    private static int RunEvaluation(CommandLineOptions options, string folder, string[] flags)
+    {
+        if (!Directory.Exists(folder))
+        {
+            Console.Error.WriteLine($"No such folder: {folder}");
+            return 1;
+        }
+        var samples = TranscriptionEvaluator.FindSamples(folder);
+        if (samples.Count == 0)
+        {
+            Console.Error.WriteLine($"No audio files with a matching .txt transcript in {folder}");
+            return 1;
+        }
+
+        var configPath = options.ConfigPath ?? ConfigManager.GetConfigPath();
+        var configManager = new ConfigManager(configPath, NullLogger<ConfigManager>.Instance);
+        var cfg = configManager.Current;
+        var providerAt = Array.IndexOf(flags, "--provider");
+        var providerName = providerAt >= 0 && providerAt + 1 < flags.Length ? flags[providerAt + 1] : cfg.Transcription.Provider;
+        var json = flags.Contains("--json");
+
+        using var loggerFactory = LoggerFactory.Create(builder => builder
+            .SetMinimumLevel(options.LogLevel ?? LogLevel.Warning)
+            .AddSimpleConsole(opts => opts.SingleLine = true));
+        var dictionary = new DictionaryService(loggerFactory.CreateLogger<DictionaryService>()).Load(cfg.PostProcessing.DictionaryFile);
+
+        var httpClientFactory = new SimpleHttpClientFactory(TimeSpan.FromSeconds(60));
+        ITranscriptionProvider provider;
+        string model;
+        switch (providerName.ToLowerInvariant())
+        {
+            case "openai":
+                provider = new OpenAiWhisperProvider(
+                    httpClientFactory,
+                    () => cfg.Transcription.ApiKey,
+                    () => cfg.Transcription,
+                    () => cfg.Transcription.Model,
+                    () => cfg.Transcription.Language,
+                    () => BuildWhisperPrompt(cfg),
+                    dictionary.GetSimpleTerms());
+                model = cfg.Transcription.Model;
+                break;
+            case "whisper.cpp":
+                provider = new WhisperCppProvider(
+                    () => cfg.Transcription.ModelPath, () => cfg.Transcription.Language, dictionary.GetSimpleTerms());
+                model = Path.GetFileName(cfg.Transcription.ModelPath);
+                break;
+            default:
+                Console.Error.WriteLine("--provider must be openai or whisper.cpp");
+                return 1;
+        }
+
+        var pipeline = flags.Contains("--raw") ? null : CreatePipeline(dictionary, configManager, httpClientFactory, loggerFactory);
+        var evaluator = new TranscriptionEvaluator(provider, model, pipeline, loggerFactory.CreateLogger<TranscriptionEvaluator>());
+        List<EvaluationResult> results;
+        try
+        {
+            if (!json)
+                Console.WriteLine($"Evaluating {provider.Name} {model} on {samples.Count} sample(s)");
+            results = evaluator.RunAsync(samples, json ? null : r => Console.WriteLine(
+                $"  {r.Sample,-32} {r.Wer,7:P1}  {r.LatencyMs,6} ms{(r.ErrorMessage != null ? $"  failed: {r.ErrorMessage}" : "")}"))
+                .GetAwaiter().GetResult();
+        }
+        finally
+        {
+            (provider as IDisposable)?.Dispose();
+        }
+
+        // InitializeAsync may run migrations that rewrite stored text, so it needs the same
+        // protector the app would use
+        var (dbPath, postgres) = GetDatabase(configPath, cfg);
+        var protector = postgres == null
+            ? TextProtector.LoadOrCreate(Path.Combine(Path.GetDirectoryName(configPath)!, "storage.key"), () => cfg.History.EncryptText)
+            : null;
+        TokenTalkDbContext CreateDbContext() => new(dbPath, protector, postgres);
+        using (var db = CreateDbContext())
+            db.InitializeAsync().GetAwaiter().GetResult();
+        new DictationRepository(CreateDbContext).SaveEvaluationAsync(results).GetAwaiter().GetResult();
+        Microsoft.Data.Sqlite.SqliteConnection.ClearAllPools();
+
+        var referenceWords = results.Sum(r => r.ReferenceWords);
+        var wer = referenceWords > 0
+            ? (double)results.Sum(r => r.Substitutions + r.Deletions + r.Insertions) / referenceWords
+            : 0;
+        if (json)
+        {
+            Console.WriteLine(System.Text.Json.JsonSerializer.Serialize(
+                new { runId = results[0].RunId, wer, results }, ControlCommandHandler.JsonOptions));
+            return 0;
+        }
+        Console.WriteLine($"WER {wer:P2} over {referenceWords} words, {results.Count(r => r.ErrorMessage != null)} failed, run {results[0].RunId}");
+        return 0;
+    }
+
+    private static int PrintEvaluationRuns(CommandLineOptions options, string[] flags)
+    {
+        var configPath = options.ConfigPath ?? ConfigManager.GetConfigPath();
+        var cfg = new ConfigManager(configPath, NullLogger<ConfigManager>.Instance).Current;
+        var (dbPath, postgres) = GetDatabase(configPath, cfg);
+        if (postgres == null && !File.Exists(dbPath))
+        {
+            Console.Error.WriteLine($"No history database at {dbPath}");
+            return 1;
+        }
+
+        var repository = new DictationRepository(() => new TokenTalkDbContext(dbPath, null, postgres, readOnly: true));
+        List<EvaluationRunSummary> runs;
+        try
+        {
+            runs = repository.GetEvaluationRunsAsync(20).GetAwaiter().GetResult();
+        }
+        catch (DbException)
+        {
+            // The table only exists once a first --eval has migrated the database
+            runs = [];
+        }
+        Microsoft.Data.Sqlite.SqliteConnection.ClearAllPools();
+
+        if (flags.Contains("--json"))
+        {
+            Console.WriteLine(System.Text.Json.JsonSerializer.Serialize(runs, ControlCommandHandler.JsonOptions));
+            return 0;
+        }
+        if (runs.Count == 0)
+            Console.WriteLine("No evaluation runs yet");
+        foreach (var run in runs)
+        {
+            var pipeline = run.Pipeline.Length > 0 ? run.Pipeline : "raw";
+            Console.WriteLine($"{run.Timestamp.ToLocalTime():yyyy-MM-dd HH:mm}  {run.Provider,-11} {run.Model,-24} {pipeline,-40} " +
+                $"{run.Samples,4} samples  WER {run.Wer,6:P1}  {run.AvgLatencyMs,6:F0} ms avg");
+        }
+        return 0;
+    }
+
+    // Reads the database directly, so it works whether or not TokenTalk is running
+    private static int PrintStats(CommandLineOptions options, string[] flags)
+    {
+        var days = 30;
+        var daysAt = Array.IndexOf(flags, "--days");
+        if (daysAt >= 0 && (daysAt + 1 >= flags.Length || !int.TryParse(flags[daysAt + 1], out days) || days <= 0))
+        {
+            Console.Error.WriteLine("--days needs a positive number");
+            return 1;
+        }
+        var json = flags.Contains("--json");
+
+        var configPath = options.ConfigPath ?? ConfigManager.GetConfigPath();
+        var cfg = new ConfigManager(configPath, NullLogger<ConfigManager>.Instance).Current;
+        var (dbPath, postgres) = GetDatabase(configPath, cfg);
+        if (postgres == null && !File.Exists(dbPath))
+        {
+            Console.Error.WriteLine($"No history database at {dbPath}");
+            return 1;
+        }
+
+        // Stats never read the text, so the encryption key is not needed
+        var repository = new DictationRepository(() => new TokenTalkDbContext(dbPath, null, postgres, readOnly: true));
+        var overall = repository.GetOverallStatsAsync(days).GetAwaiter().GetResult();
+        var daily = repository.GetDailyStatsAsync(days).GetAwaiter().GetResult();
+        var providers = repository.GetProviderStatsAsync(days).GetAwaiter().GetResult();
+        var ratings = repository.GetRatingStatsAsync(days).GetAwaiter().GetResult();
+        Microsoft.Data.Sqlite.SqliteConnection.ClearAllPools();
+
+        if (json)
+        {
+            Console.WriteLine(System.Text.Json.JsonSerializer.Serialize(
+                new { days, overall, daily, providers, ratings }, ControlCommandHandler.JsonOptions));
+            return 0;
+        }
+
+        var recording = TimeSpan.FromMilliseconds(overall.TotalRecordingTimeMs);
+        Console.WriteLine($"Last {days} days");
+        Console.WriteLine($"  Dictations   {overall.TotalDictations} ({overall.SuccessCount} ok, {overall.FailureCount} failed)");
+        Console.WriteLine($"  Words        {overall.TotalWords}");
+        Console.WriteLine($"  Recorded     {(int)recording.TotalHours}h {recording.Minutes:00}m");
+        Console.WriteLine($"  Avg latency  {overall.AvgTotalLatencyMs:F0} ms");
+        Console.WriteLine($"  Speaking     {overall.AvgSpeakingWpm:F0} wpm");
+        if (daily.Count > 0)
+        {
+            Console.WriteLine();
+            Console.WriteLine("Daily");
+            foreach (var day in daily)
+                Console.WriteLine($"  {day.Date}  {day.TotalDictations,5} dictations  {day.TotalWords,7} words  {day.FailureCount,3} failed");
+        }
+        if (providers.Count > 0)
+        {
+            Console.WriteLine();
+            Console.WriteLine("Providers");
+            foreach (var provider in providers)
+                Console.WriteLine($"  {provider.Provider,-12} {provider.TotalDictations,5} dictations  {provider.TotalWords,7} words  {provider.AvgLatencyMs,6:F0} ms avg");
+        }
+        if (ratings.Count > 0)
+        {
+            Console.WriteLine();
+            Console.WriteLine("Ratings");
+            foreach (var r in ratings)
+            {
+                var name = string.Join(" / ", new[] { r.Provider, r.Model, r.Language, r.Pipeline }.Where(p => p.Length > 0));
+                Console.WriteLine($"  {name,-40} {r.Up,4} up  {r.Down,4} down  {r.Approval,5:P0}");
+            }
+        }
+        return 0;
+    }
+
+    private static int SendHealthCommand(string pipeName)
+    {
+        var reply = ControlPipeServer.Send(pipeName, "health", TimeSpan.FromSeconds(2), TimeSpan.FromSeconds(10));
+        if (reply == null)
+        {
+            Console.Error.WriteLine("TokenTalk is not running.");
+            return 2;
+        }
+        Console.WriteLine(reply);
+        try
+        {
+            using var doc = System.Text.Json.JsonDocument.Parse(reply);
+            return doc.RootElement.GetProperty("level").GetString() switch
+            {
+                "healthy" => 0,
+                "degraded" => 1,
+                _ => 2,
+            };
+        }
+        catch (System.Text.Json.JsonException)
+        {
+            return 2;
+        }
+    }
+
+    private static int SendControlCommand(string pipeName, string command)
+    {
+        var reply = ControlPipeServer.Send(pipeName, command, TimeSpan.FromSeconds(2));
+        if (reply == null)
+        {
+            Console.Error.WriteLine("TokenTalk is not running.");
+            return 2;
+        }
+        Console.WriteLine(reply);
+        return reply.StartsWith("error:", StringComparison.Ordinal) ? 1 : 0;
+    }
 }
@@ -1,22 +1,82 @@
+using System.Net;
 using System.Net.Http;
+using System.Net.Sockets;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Diagnostics;
 
 namespace TokenTalk;
 
 /// <summary>
 /// Simple IHttpClientFactory implementation that creates clients with a shared handler.
 /// Used to avoid the ASP0000 warning from calling BuildServiceProvider in Program.cs.
+/// Sharing the handler shares its connection pool, so a dictation can reuse the TLS connection
+/// the previous one (or <see cref="Transcription.ConnectionWarmer"/>) opened.
 /// </summary>
 internal sealed class SimpleHttpClientFactory : IHttpClientFactory
 {
+    // Clients that talk to transcription providers, whose traffic the provider trace records
+    private static readonly string[] ProviderClients = ["OpenAI"];
+
     private readonly TimeSpan _timeout;
+    private readonly HttpMessageHandler _handler;
+    private readonly ILogger? _providerTrace;
+    private readonly Func<bool> _isTracing;
 
-    public SimpleHttpClientFactory(TimeSpan timeout)
+    /// <param name="providerTrace">Where provider requests are recorded while <paramref name="isTracing"/> holds.</param>
+    public SimpleHttpClientFactory(TimeSpan timeout, HttpConnectionStats? stats = null,
+        ILogger? providerTrace = null, Func<bool>? isTracing = null)
     {
         _timeout = timeout;
+        _providerTrace = providerTrace;
+        _isTracing = isTracing ?? (() => false);
+        var sockets = new SocketsHttpHandler
+        {
+            // Idle connections outlive the keep-warm interval; the lifetime cap makes DNS changes apply
+            PooledConnectionIdleTimeout = TimeSpan.FromMinutes(10),
+            PooledConnectionLifetime = TimeSpan.FromMinutes(30),
+            // HTTP/2 pings notice a connection the network silently dropped (e.g. after sleep)
+            KeepAlivePingDelay = TimeSpan.FromSeconds(60),
+            KeepAlivePingTimeout = TimeSpan.FromSeconds(15),
+            EnableMultipleHttp2Connections = true,
+            ConnectCallback = async (context, ct) =>
+            {
+                stats?.ConnectionOpened();
+                var socket = new Socket(SocketType.Stream, ProtocolType.Tcp) { NoDelay = true };
+                try
+                {
+                    await socket.ConnectAsync(context.DnsEndPoint, ct);
+                    return new NetworkStream(socket, ownsSocket: true);
+                }
+                catch
+                {
+                    socket.Dispose();
+                    throw;
+                }
+            },
+        };
+        _handler = stats == null ? sockets : new CountingHandler(stats) { InnerHandler = sockets };
     }
 
     public HttpClient CreateClient(string name)
     {
-        return new HttpClient { Timeout = _timeout };
+        // The tracing wrapper holds nothing to dispose; the shared handler under it outlives every client
+        var handler = _providerTrace != null && ProviderClients.Contains(name)
+            ? new ProviderTraceHandler(name, _providerTrace, _isTracing) { InnerHandler = _handler }
+            : _handler;
+        return new HttpClient(handler, disposeHandler: false)
+        {
+            Timeout = _timeout,
+            DefaultRequestVersion = HttpVersion.Version20,
+            DefaultVersionPolicy = HttpVersionPolicy.RequestVersionOrLower,
+        };
+    }
+
+    private sealed class CountingHandler(HttpConnectionStats stats) : DelegatingHandler
+    {
+        protected override Task<HttpResponseMessage> SendAsync(HttpRequestMessage request, CancellationToken ct)
+        {
+            stats.RequestSent();
+            return base.SendAsync(request, ct);
+        }
     }
 }
@@ -0,0 +1,66 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Audio;
+using TokenTalk.Diagnostics;
+using TokenTalk.PostProcessing;
+
+namespace TokenTalk.Storage;
+
+public record WipeResult(int Dictations, int AudioFiles, bool Dictionary);
+
+/// <summary>
+/// Deletes everything TokenTalk has recorded about its user, e.g. before handing the machine on:
+/// all history (the SQLite file is compacted so the rows don't linger in free pages), audio kept
+/// for retry, in-memory traces and the log files, and optionally the custom dictionary.
+/// Settings and downloaded models stay.
+/// </summary>
+public class DataWiper
+{
+    private readonly DictationRepository _repository;
+    private readonly FailedAudioStore _audioStore;
+    private readonly FlightRecorder _traces;
+    private readonly IReadOnlyList<FileLoggerProvider?> _logFiles;
+    private readonly DictionaryService _dictionaryService;
+    private readonly CustomDictionary _dictionary;
+    private readonly Func<string> _getDictionaryPath;
+    private readonly ILogger<DataWiper> _logger;
+
+    public DataWiper(
+        DictationRepository repository,
+        FailedAudioStore audioStore,
+        FlightRecorder traces,
+        IReadOnlyList<FileLoggerProvider?> logFiles,
+        DictionaryService dictionaryService,
+        CustomDictionary dictionary,
+        Func<string> getDictionaryPath,
+        ILogger<DataWiper> logger)
+    {
+        _repository = repository;
+        _audioStore = audioStore;
+        _traces = traces;
+        _logFiles = logFiles;
+        _dictionaryService = dictionaryService;
+        _dictionary = dictionary;
+        _getDictionaryPath = getDictionaryPath;
+        _logger = logger;
+    }
+
+    public async Task<WipeResult> WipeAsync(bool includeDictionary, CancellationToken ct = default)
+    {
+        var dictations = await _repository.DeleteAllAsync(ct);
+        await _repository.CompactAsync(ct);
+        var audioFiles = _audioStore.DeleteAll();
+        _traces.Clear();
+
+        if (includeDictionary)
+        {
+            _dictionary.Entries.Clear();
+            _dictionaryService.Save(_getDictionaryPath(), _dictionary);
+        }
+
+        foreach (var logFile in _logFiles)
+            logFile?.Clear();
+        _logger.LogWarning("Wiped all data: {Dictations} dictation(s), {AudioFiles} audio file(s){Dictionary}",
+            dictations, audioFiles, includeDictionary ? " and the dictionary" : "");
+        return new WipeResult(dictations, audioFiles, includeDictionary);
+    }
+}
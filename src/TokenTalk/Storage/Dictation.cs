@@ -74,4 +74,107 @@ public class Dictation
     [Column("error_message")]
     [JsonPropertyName("ErrorMessage")]
     public string? ErrorMessage { get; set; }
+
+    [Column("starred")]
+    [JsonPropertyName("Starred")]
+    public bool Starred { get; set; }
+
+    // Comma-separated, lower-case tag list
+    [Column("tags")]
+    [JsonPropertyName("Tags")]
+    public string Tags { get; set; } = string.Empty;
+
+    // Transcript as first saved; set the first time the text is edited
+    [Column("original_text")]
+    [JsonPropertyName("OriginalText")]
+    public string? OriginalText { get; set; }
+
+    // Provider output before post-processing; TranscribedText holds the final pasted text
+    [Column("raw_text")]
+    [JsonPropertyName("RawText")]
+    public string? RawText { get; set; }
+
+    // JSON array of post-processing stage names that ran, in order
+    [Column("pipeline_stages")]
+    [JsonPropertyName("PipelineStages")]
+    public string? PipelineStages { get; set; }
+
+    // One of ErrorCategories, set for failed dictations
+    [Column("error_category")]
+    [JsonPropertyName("ErrorCategory")]
+    public string? ErrorCategory { get; set; }
+
+    // Host that recorded the dictation, to tell machines apart in a shared database
+    [Column("machine")]
+    [JsonPropertyName("Machine")]
+    public string Machine { get; set; } = string.Empty;
+
+    // Config profile that was active when recorded; empty when none was
+    [Column("profile")]
+    [JsonPropertyName("Profile")]
+    public string Profile { get; set; } = string.Empty;
+
+    // Process name of the window focused when recording started, without ".exe"; empty when unknown
+    [Column("app")]
+    [JsonPropertyName("App")]
+    public string App { get; set; } = string.Empty;
+
+    // Outcome of Output.Verify for the paste: "verified", "failed" or "unverifiable"; null when not checked
+    [Column("injection_status")]
+    [JsonPropertyName("InjectionStatus")]
+    public string? InjectionStatus { get; set; }
+
+    // Id of the failed dictation this one re-transcribed from its saved audio
+    [Column("retry_of")]
+    [JsonPropertyName("RetryOf")]
+    public long? RetryOf { get; set; }
+
+    // User verdict on the output: 1 thumbs-up, -1 thumbs-down, null when not rated
+    [Column("rating")]
+    [JsonPropertyName("Rating")]
+    public int? Rating { get; set; }
+
+    // JSON array of the words the provider scored below Transcription.LowConfidenceThreshold,
+    // with their confidence; null when none were or the provider reports no confidence
+    [Column("low_confidence")]
+    [JsonPropertyName("LowConfidence")]
+    public string? LowConfidence { get; set; }
+
+    // Dictations less than History.SessionGapMinutes apart share a session id
+    [Column("session_id")]
+    [JsonPropertyName("SessionId")]
+    public string? SessionId { get; set; }
+
+    // Set when deleted from the UI; the row is hidden and purged after History.TrashDays
+    [Column("deleted_at")]
+    [JsonPropertyName("DeletedAt")]
+    public DateTime? DeletedAt { get; set; }
+
+    // Words per minute of recorded speech
+    [Column("speaking_wpm")]
+    [JsonPropertyName("SpeakingWpm")]
+    public double SpeakingWpm { get; set; }
+
+    // Words per minute of wall time from recording start until the text was pasted.
+    // TotalLatencyMs is measured from the end of recording, so the recording is added back.
+    [Column("effective_wpm")]
+    [JsonPropertyName("EffectiveWpm")]
+    public double EffectiveWpm { get; set; }
+
+    // Kept out of history, logs and notifiers; see Agent.SetIncognito
+    [NotMapped]
+    [JsonIgnore]
+    public bool Incognito { get; set; }
+
+    public static int CountWords(string text) =>
+        text.Split(' ', StringSplitOptions.RemoveEmptyEntries).Length;
+
+    public void UpdateRates()
+    {
+        SpeakingWpm = WordsPerMinute(WordCount, RecordingDurationMs);
+        EffectiveWpm = WordsPerMinute(WordCount, RecordingDurationMs + TotalLatencyMs);
+    }
+
+    private static double WordsPerMinute(int words, long durationMs) =>
+        durationMs > 0 ? Math.Round(words / (durationMs / 60000.0), 1) : 0;
 }
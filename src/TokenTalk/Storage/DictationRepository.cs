@@ -1,28 +1,170 @@
+using System.Runtime.CompilerServices;
+using System.Text.Json;
 using Microsoft.EntityFrameworkCore;
+using TokenTalk.Transcription;
 
 namespace TokenTalk.Storage;
 
+/// <summary>
+/// Data access for dictations. Every operation uses its own short-lived DbContext, so callers on
+/// the agent, retention and UI threads never share one. Writes are serialized through a single
+/// lock, which keeps SQLite from returning SQLITE_BUSY between our own writers.
+/// </summary>
 public class DictationRepository
 {
-    private readonly TokenTalkDbContext _db;
+    private readonly Func<TokenTalkDbContext> _createContext;
+    private readonly SemaphoreSlim _writeLock = new(1, 1);
 
-    public DictationRepository(TokenTalkDbContext db)
+    /// <summary>Raised after a stored dictation is modified (e.g. its text was edited).</summary>
+    public event EventHandler<Dictation>? DictationUpdated;
+
+    public static readonly TimeSpan DefaultSessionGap = TimeSpan.FromMinutes(5);
+
+    private readonly Func<TimeSpan> _sessionGap;
+
+    private record SessionAnchor(DateTime Timestamp, string? SessionId);
+
+    // Runs on every save; compiling once skips LINQ translation on the hot path
+    private static readonly Func<TokenTalkDbContext, string, Task<SessionAnchor?>> LatestSessionQuery =
+        EF.CompileAsyncQuery((TokenTalkDbContext db, string machine) => db.Dictations
+            .Where(d => d.Machine == machine)
+            .OrderByDescending(d => d.Timestamp)
+            .Select(d => new SessionAnchor(d.Timestamp, d.SessionId))
+            .FirstOrDefault());
+
+    public DictationRepository(Func<TokenTalkDbContext> createContext, Func<TimeSpan>? sessionGap = null)
+    {
+        _createContext = createContext;
+        _sessionGap = sessionGap ?? (() => DefaultSessionGap);
+    }
+
+    private async Task<T> WriteAsync<T>(Func<TokenTalkDbContext, Task<T>> action, CancellationToken ct)
+    {
+        await _writeLock.WaitAsync(ct);
+        try
+        {
+            await using var db = _createContext();
+            return await action(db);
+        }
+        finally
+        {
+            _writeLock.Release();
+        }
+    }
+
+    private Task WriteAsync(Func<TokenTalkDbContext, Task> action, CancellationToken ct) =>
+        WriteAsync(async db => { await action(db); return true; }, ct);
+
+    public async Task<bool> CanConnectAsync(CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        return await db.Database.CanConnectAsync(ct);
+    }
+
+    public async Task<int> GetSchemaVersionAsync()
+    {
+        await using var db = _createContext();
+        return await SchemaMigrator.GetCurrentVersionAsync(db);
+    }
+
+    public Task SaveAsync(Dictation dictation, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            dictation.UpdateRates();
+            if (string.IsNullOrEmpty(dictation.Machine))
+                dictation.Machine = Environment.MachineName;
+            dictation.SessionId ??= await ResolveSessionIdAsync(db, dictation.Machine);
+            if (!dictation.Success)
+                dictation.ErrorCategory ??= ErrorClassifier.Classify(dictation.ErrorMessage);
+            db.Dictations.Add(dictation);
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    // Continue this machine's latest session when its previous dictation is recent enough
+    private async Task<string> ResolveSessionIdAsync(TokenTalkDbContext db, string machine)
+    {
+        var last = await LatestSessionQuery(db, machine);
+
+        if (last?.SessionId != null && DateTime.UtcNow - last.Timestamp <= _sessionGap())
+            return last.SessionId;
+        return Guid.NewGuid().ToString("N");
+    }
+
+    public async Task<List<SessionSummary>> GetSessionsAsync(int limit, int offset, CancellationToken ct = default)
     {
-        _db = db;
+        await using var db = _createContext();
+        return await db.Dictations
+            .Where(d => d.SessionId != null)
+            .GroupBy(d => d.SessionId!)
+            .Select(g => new SessionSummary
+            {
+                SessionId = g.Key,
+                Start = g.Min(d => d.Timestamp),
+                End = g.Max(d => d.Timestamp),
+                DictationCount = g.Count(),
+                TotalWords = g.Sum(d => d.WordCount),
+                RecordingMs = g.Sum(d => d.RecordingDurationMs),
+            })
+            .OrderByDescending(s => s.Start)
+            .Skip(offset)
+            .Take(limit)
+            .ToListAsync(ct);
     }
 
-    public async Task SaveAsync(Dictation dictation, CancellationToken ct = default)
+    public async Task<List<Dictation>> GetSessionDictationsAsync(string sessionId, CancellationToken ct = default)
     {
-        _db.Dictations.Add(dictation);
-        await _db.SaveChangesAsync(ct);
+        await using var db = _createContext();
+        return await db.Dictations
+            .Where(d => d.SessionId == sessionId)
+            .OrderBy(d => d.Timestamp)
+            .ToListAsync(ct);
     }
 
     public async Task<(List<Dictation> Items, int Total)> GetHistoryAsync(
         int limit, int offset, CancellationToken ct = default)
+        => await GetHistoryAsync(limit, offset, new HistoryFilter(), ct);
+
+    public async Task<(List<Dictation> Items, int Total)> GetHistoryAsync(
+        int limit, int offset, HistoryFilter filter, CancellationToken ct = default)
     {
-        var total = await _db.Dictations.CountAsync(ct);
-        var items = await _db.Dictations
-            .OrderByDescending(d => d.Timestamp)
+        await using var db = _createContext();
+        IQueryable<Dictation> query = db.Dictations;
+        if (filter.StarredOnly)
+            query = query.Where(d => d.Starred);
+        if (!string.IsNullOrWhiteSpace(filter.Tag))
+        {
+            var needle = "," + NormalizeTag(filter.Tag) + ",";
+            query = query.Where(d => ("," + d.Tags + ",").Contains(needle));
+        }
+        if (!string.IsNullOrEmpty(filter.Provider))
+            query = query.Where(d => d.Provider == filter.Provider);
+        if (filter.Success is bool success)
+            query = query.Where(d => d.Success == success);
+        if (!string.IsNullOrEmpty(filter.Language))
+            query = query.Where(d => d.Language == filter.Language);
+        if (filter.From is DateTime from)
+            query = query.Where(d => d.Timestamp >= from);
+        if (filter.To is DateTime to)
+            query = query.Where(d => d.Timestamp < to);
+        if (filter.MinWords is int minWords)
+            query = query.Where(d => d.WordCount >= minWords);
+        if (filter.MaxWords is int maxWords)
+            query = query.Where(d => d.WordCount <= maxWords);
+
+        var total = await query.CountAsync(ct);
+        // Timestamp breaks ties so paging is stable
+        var ordered = filter.Sort switch
+        {
+            HistorySort.Oldest => query.OrderBy(d => d.Timestamp),
+            HistorySort.MostWords => query.OrderByDescending(d => d.WordCount).ThenByDescending(d => d.Timestamp),
+            HistorySort.FewestWords => query.OrderBy(d => d.WordCount).ThenByDescending(d => d.Timestamp),
+            HistorySort.Slowest => query.OrderByDescending(d => d.TotalLatencyMs).ThenByDescending(d => d.Timestamp),
+            HistorySort.Fastest => query.OrderBy(d => d.TotalLatencyMs).ThenByDescending(d => d.Timestamp),
+            _ => query.OrderByDescending(d => d.Timestamp),
+        };
+        var items = await ordered
             .Skip(offset)
             .Take(limit)
             .ToListAsync(ct);
@@ -30,25 +172,372 @@ public class DictationRepository
         return (items, total);
     }
 
-    public async Task DeleteAsync(long id, CancellationToken ct = default)
+    /// <summary>Distinct providers and languages in the history, for filter pickers.</summary>
+    public async Task<(List<string> Providers, List<string> Languages)> GetFilterOptionsAsync(CancellationToken ct = default)
     {
-        var dictation = await _db.Dictations.FindAsync([id], ct);
-        if (dictation == null)
-            throw new KeyNotFoundException($"Dictation {id} not found");
+        await using var db = _createContext();
+        var providers = await db.Dictations
+            .Select(d => d.Provider).Distinct().OrderBy(p => p).ToListAsync(ct);
+        var languages = await db.Dictations
+            .Where(d => d.Language != "")
+            .Select(d => d.Language).Distinct().OrderBy(l => l).ToListAsync(ct);
+        return (providers, languages);
+    }
+
+    /// <summary>
+    /// Replaces the transcript, keeping the first saved text in OriginalText,
+    /// and recalculates word and character counts.
+    /// </summary>
+    public async Task<Dictation> UpdateTextAsync(long id, string text, CancellationToken ct = default)
+    {
+        var updated = await WriteAsync(async db =>
+        {
+            var dictation = await db.Dictations.FindAsync([id], ct)
+                ?? throw new KeyNotFoundException($"Dictation {id} not found");
 
-        _db.Dictations.Remove(dictation);
-        await _db.SaveChangesAsync(ct);
+            dictation.OriginalText ??= dictation.TranscribedText;
+            dictation.TranscribedText = text;
+            dictation.WordCount = Dictation.CountWords(text);
+            dictation.CharacterCount = text.Length;
+            dictation.UpdateRates();
+            // Checked by hand now, so the provider's doubts no longer apply
+            dictation.LowConfidence = null;
+            await db.SaveChangesAsync(ct);
+            return dictation;
+        }, ct);
+
+        DictationUpdated?.Invoke(this, updated);
+        return updated;
     }
 
-    public async Task<OverallStats> GetOverallStatsAsync(int days, CancellationToken ct = default)
+    /// <summary>
+    /// Successful dictations that kept the provider's raw text, in id order starting after
+    /// <paramref name="afterId"/>, for paging through history without holding it all in memory.
+    /// </summary>
+    public async Task<List<Dictation>> GetReprocessBatchAsync(
+        DateTime? from, long afterId, int count, CancellationToken ct = default)
     {
-        var since = DateTime.UtcNow.AddDays(-days);
-        var query = _db.Dictations.Where(d => d.Timestamp >= since);
+        await using var db = _createContext();
+        return await InRange(db.Dictations, from, null)
+            .AsNoTracking()
+            .Where(d => d.Success && d.RawText != null && d.Id > afterId)
+            .OrderBy(d => d.Id)
+            .Take(count)
+            .ToListAsync(ct);
+    }
+
+    /// <summary>
+    /// Successful dictations recorded on <paramref name="machine"/> after <paramref name="after"/>,
+    /// oldest first, for exports that continue where their previous run stopped.
+    /// </summary>
+    public async Task<List<Dictation>> GetRecordedAfterAsync(
+        DateTime after, string machine, int count, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        return await db.Dictations
+            .AsNoTracking()
+            .Where(d => d.Success && d.Timestamp > after && d.Machine == machine)
+            .OrderBy(d => d.Timestamp)
+            .Take(count)
+            .ToListAsync(ct);
+    }
+
+    /// <summary>
+    /// Stores re-run pipeline output as the final text. Unlike <see cref="UpdateTextAsync"/> this
+    /// is not a user edit, so OriginalText is left alone.
+    /// </summary>
+    public Task UpdateProcessedTextAsync(
+        IReadOnlyList<(long Id, string Text, string Stages)> updates, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            foreach (var (id, text, stages) in updates)
+            {
+                var dictation = await db.Dictations.FindAsync([id], ct);
+                if (dictation == null)
+                    continue;
+                dictation.TranscribedText = text;
+                dictation.PipelineStages = stages;
+                dictation.WordCount = Dictation.CountWords(text);
+                dictation.CharacterCount = text.Length;
+                dictation.UpdateRates();
+            }
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    public Task SetStarredAsync(long id, bool starred, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            var dictation = await db.Dictations.FindAsync([id], ct)
+                ?? throw new KeyNotFoundException($"Dictation {id} not found");
+
+            dictation.Starred = starred;
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    /// <summary>
+    /// Records the user's verdict on a dictation: 1 for good, -1 for bad, null to clear it.
+    /// </summary>
+    public async Task<Dictation> SetRatingAsync(long id, int? rating, CancellationToken ct = default)
+    {
+        if (rating is not (null or 1 or -1))
+            throw new ArgumentOutOfRangeException(nameof(rating), "Rating must be 1, -1 or null");
+
+        var updated = await WriteAsync(async db =>
+        {
+            var dictation = await db.Dictations.FindAsync([id], ct)
+                ?? throw new KeyNotFoundException($"Dictation {id} not found");
+
+            dictation.Rating = rating;
+            await db.SaveChangesAsync(ct);
+            return dictation;
+        }, ct);
+
+        DictationUpdated?.Invoke(this, updated);
+        return updated;
+    }
+
+    /// <summary>
+    /// Replaces the tag list of a dictation. Tags are trimmed, lower-cased and de-duplicated.
+    /// </summary>
+    public Task<IReadOnlyList<string>> SetTagsAsync(long id, IEnumerable<string> tags, CancellationToken ct = default)
+    {
+        return WriteAsync<IReadOnlyList<string>>(async db =>
+        {
+            var dictation = await db.Dictations.FindAsync([id], ct)
+                ?? throw new KeyNotFoundException($"Dictation {id} not found");
+
+            var normalized = tags
+                .Select(NormalizeTag)
+                .Where(t => t.Length > 0)
+                .Distinct()
+                .ToList();
+
+            dictation.Tags = string.Join(',', normalized);
+            await db.SaveChangesAsync(ct);
+            return normalized;
+        }, ct);
+    }
+
+    public static IReadOnlyList<string> ParseTags(string tags) =>
+        tags.Split(',', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries);
+
+    /// <summary>Reads <see cref="Dictation.LowConfidence"/>; empty when nothing was flagged.</summary>
+    public static IReadOnlyList<WordConfidence> ParseLowConfidence(string? lowConfidence)
+    {
+        if (string.IsNullOrEmpty(lowConfidence))
+            return [];
+        try
+        {
+            return JsonSerializer.Deserialize<List<WordConfidence>>(lowConfidence) ?? [];
+        }
+        catch (JsonException)
+        {
+            return [];
+        }
+    }
+
+    // Commas separate tags in storage, so they can't appear inside one
+    private static string NormalizeTag(string tag) =>
+        tag.Trim().Replace(",", "").ToLowerInvariant();
+
+    /// <summary>
+    /// Streams dictations oldest-first, optionally bounded by a half-open [from, to) UTC range.
+    /// </summary>
+    public async IAsyncEnumerable<Dictation> StreamAsync(
+        DateTime? from, DateTime? to, [EnumeratorCancellation] CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        IQueryable<Dictation> query = db.Dictations.AsNoTracking();
+        if (from.HasValue)
+            query = query.Where(d => d.Timestamp >= from.Value);
+        if (to.HasValue)
+            query = query.Where(d => d.Timestamp < to.Value);
+
+        await foreach (var d in query.OrderBy(d => d.Timestamp).AsAsyncEnumerable().WithCancellation(ct))
+            yield return d;
+    }
+
+    /// <summary>
+    /// Newest successful dictations whose text contains <paramref name="query"/>, ignoring case.
+    /// Matching runs on the client because the text may be stored encrypted.
+    /// </summary>
+    public async Task<List<Dictation>> SearchAsync(string query, int limit, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var results = new List<Dictation>();
+        var rows = db.Dictations.AsNoTracking()
+            .Where(d => d.Success)
+            .OrderByDescending(d => d.Timestamp)
+            .AsAsyncEnumerable()
+            .WithCancellation(ct);
+        await foreach (var d in rows)
+        {
+            if (!d.TranscribedText.Contains(query, StringComparison.OrdinalIgnoreCase))
+                continue;
+            results.Add(d);
+            if (results.Count >= limit)
+                break;
+        }
+        return results;
+    }
+
+    /// <summary>Soft-deletes a dictation; it can be restored until the trash is purged.</summary>
+    public Task DeleteAsync(long id, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            var dictation = await db.Dictations.FindAsync([id], ct);
+            if (dictation == null)
+                throw new KeyNotFoundException($"Dictation {id} not found");
+
+            dictation.DeletedAt = DateTime.UtcNow;
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    public Task RestoreAsync(long id, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            var dictation = await db.Dictations
+                .IgnoreQueryFilters()
+                .FirstOrDefaultAsync(d => d.Id == id, ct)
+                ?? throw new KeyNotFoundException($"Dictation {id} not found");
+
+            dictation.DeletedAt = null;
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    /// <summary>Permanently removes dictations soft-deleted more than <paramref name="trashDays"/> days ago.</summary>
+    public Task<int> PurgeDeletedAsync(int trashDays, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            var cutoff = DateTime.UtcNow.AddDays(-trashDays);
+            return await db.Dictations
+                .IgnoreQueryFilters()
+                .Where(d => d.DeletedAt != null && d.DeletedAt < cutoff)
+                .ExecuteDeleteAsync(ct);
+        }, ct);
+    }
+
+    /// <summary>
+    /// Counts dictations older than <paramref name="before"/>, or all of them when null. Trashed
+    /// ones are included, as <see cref="DeleteBeforeAsync"/> removes them as well.
+    /// </summary>
+    public async Task<int> CountBeforeAsync(DateTime? before, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var all = db.Dictations.IgnoreQueryFilters();
+        return before.HasValue
+            ? await all.CountAsync(d => d.Timestamp < before.Value, ct)
+            : await all.CountAsync(ct);
+    }
+
+    public Task<int> DeleteBeforeAsync(DateTime before, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            return await db.Dictations
+                .IgnoreQueryFilters()
+                .Where(d => d.Timestamp < before)
+                .ExecuteDeleteAsync(ct);
+        }, ct);
+    }
+
+    // Trashed rows too: a wipe leaves no transcript behind
+    public Task<int> DeleteAllAsync(CancellationToken ct = default)
+    {
+        return WriteAsync(db => db.Dictations.IgnoreQueryFilters().ExecuteDeleteAsync(ct), ct);
+    }
+
+    /// <summary>
+    /// Rebuilds the SQLite file and empties the WAL, so deleted text is gone from disk rather than
+    /// left in free pages. Postgres reclaims space itself and is left alone.
+    /// </summary>
+    public Task CompactAsync(CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            if (!db.Database.IsSqlite())
+                return;
+            await db.Database.ExecuteSqlRawAsync("VACUUM", ct);
+            await db.Database.ExecuteSqlRawAsync("PRAGMA wal_checkpoint(TRUNCATE)", ct);
+        }, ct);
+    }
+
+    /// <summary>
+    /// Deletes dictations older than <paramref name="maxAgeDays"/> and any rows beyond the newest
+    /// <paramref name="maxCount"/>. A limit of 0 disables that rule. Returns the number of rows removed.
+    /// Both rules cover the whole table, trashed dictations included.
+    /// </summary>
+    public Task<int> PruneAsync(int maxAgeDays, int maxCount, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            int removed = 0;
+
+            if (maxAgeDays > 0)
+            {
+                var cutoff = DateTime.UtcNow.AddDays(-maxAgeDays);
+                removed += await db.Dictations
+                    .IgnoreQueryFilters()
+                    .Where(d => d.Timestamp < cutoff)
+                    .ExecuteDeleteAsync(ct);
+            }
+
+            if (maxCount > 0)
+            {
+                var excessIds = await db.Dictations
+                    .IgnoreQueryFilters()
+                    .OrderByDescending(d => d.Timestamp)
+                    .Skip(maxCount)
+                    .Select(d => d.Id)
+                    .ToListAsync(ct);
+
+                if (excessIds.Count > 0)
+                {
+                    removed += await db.Dictations
+                        .IgnoreQueryFilters()
+                        .Where(d => excessIds.Contains(d.Id))
+                        .ExecuteDeleteAsync(ct);
+                }
+            }
+
+            return removed;
+        }, ct);
+    }
+
+    // Stats ranges are UTC; `to` is exclusive, and a null bound is open-ended
+    private static IQueryable<Dictation> InRange(IQueryable<Dictation> query, DateTime? from, DateTime? to)
+    {
+        if (from is DateTime start)
+            query = query.Where(d => d.Timestamp >= start);
+        if (to is DateTime end)
+            query = query.Where(d => d.Timestamp < end);
+        return query;
+    }
+
+    public Task<OverallStats> GetOverallStatsAsync(int days, CancellationToken ct = default)
+        => GetOverallStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<OverallStats> GetOverallStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var query = InRange(db.Dictations, from, to);
 
         var total = await query.CountAsync(ct);
         if (total == 0)
             return new OverallStats();
 
+        // Rates are word-weighted over successful dictations, so short clips don't skew them
+        var rated = query.Where(d => d.Success && d.RecordingDurationMs > 0);
+
         return new OverallStats
         {
             TotalDictations = total,
@@ -62,15 +551,24 @@ public class DictationRepository
             AvgTotalLatencyMs = await query.AverageAsync(d => (double)d.TotalLatencyMs, ct),
             TotalRecordingTimeMs = await query.SumAsync(d => d.RecordingDurationMs, ct),
             TotalAudioSizeBytes = await query.SumAsync(d => d.AudioSizeBytes, ct),
+            AvgSpeakingWpm = WordsPerMinute(
+                await rated.SumAsync(d => d.WordCount, ct),
+                await rated.SumAsync(d => d.RecordingDurationMs, ct)),
+            AvgEffectiveWpm = WordsPerMinute(
+                await rated.SumAsync(d => d.WordCount, ct),
+                await rated.SumAsync(d => d.RecordingDurationMs + d.TotalLatencyMs, ct)),
+            PeakSpeakingWpm = await rated.MaxAsync(d => (double?)d.SpeakingWpm, ct) ?? 0,
         };
     }
 
-    public async Task<List<DailyStats>> GetDailyStatsAsync(int days, CancellationToken ct = default)
+    public Task<List<DailyStats>> GetDailyStatsAsync(int days, CancellationToken ct = default)
+        => GetDailyStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<DailyStats>> GetDailyStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
     {
-        var since = DateTime.UtcNow.AddDays(-days);
+        await using var db = _createContext();
         // Group in SQL, format date on the client to avoid EF translation issues
-        var rows = await _db.Dictations
-            .Where(d => d.Timestamp >= since)
+        var rows = await InRange(db.Dictations, from, to)
             .GroupBy(d => d.Timestamp.Date)
             .Select(g => new
             {
@@ -79,6 +577,9 @@ public class DictationRepository
                 TotalWords = g.Sum(d => d.WordCount),
                 SuccessCount = g.Count(d => d.Success),
                 FailureCount = g.Count(d => !d.Success),
+                RatedWords = g.Where(d => d.Success).Sum(d => d.WordCount),
+                RecordingMs = g.Where(d => d.Success).Sum(d => d.RecordingDurationMs),
+                WallTimeMs = g.Where(d => d.Success).Sum(d => d.RecordingDurationMs + d.TotalLatencyMs),
             })
             .OrderByDescending(s => s.Date)
             .ToListAsync(ct);
@@ -90,14 +591,21 @@ public class DictationRepository
             TotalWords = r.TotalWords,
             SuccessCount = r.SuccessCount,
             FailureCount = r.FailureCount,
+            SpeakingWpm = WordsPerMinute(r.RatedWords, r.RecordingMs),
+            EffectiveWpm = WordsPerMinute(r.RatedWords, r.WallTimeMs),
         }).ToList();
     }
 
-    public async Task<List<ProviderStats>> GetProviderStatsAsync(int days, CancellationToken ct = default)
+    private static double WordsPerMinute(long words, long durationMs) =>
+        durationMs > 0 ? Math.Round(words / (durationMs / 60000.0), 1) : 0;
+
+    public Task<List<ProviderStats>> GetProviderStatsAsync(int days, CancellationToken ct = default)
+        => GetProviderStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<ProviderStats>> GetProviderStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
     {
-        var since = DateTime.UtcNow.AddDays(-days);
-        var results = await _db.Dictations
-            .Where(d => d.Timestamp >= since)
+        await using var db = _createContext();
+        var results = await InRange(db.Dictations, from, to)
             .GroupBy(d => d.Provider)
             .Select(g => new ProviderStats
             {
@@ -114,10 +622,245 @@ public class DictationRepository
         return results;
     }
 
+    /// <summary>
+    /// Groups dictations by provider and model. Percentiles are computed on the client because
+    /// SQLite has no percentile aggregate; only the latency columns are loaded.
+    /// </summary>
+    public Task<List<ModelStats>> GetModelStatsAsync(int days, CancellationToken ct = default)
+        => GetModelStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<ModelStats>> GetModelStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var rows = await InRange(db.Dictations, from, to)
+            .Select(d => new { d.Provider, d.Model, d.Success, d.WordCount, d.RecordingDurationMs, d.TranscriptionLatencyMs, d.TotalLatencyMs })
+            .ToListAsync(ct);
+
+        return rows
+            .GroupBy(r => (r.Provider, r.Model))
+            .Select(g =>
+            {
+                var transcription = g.Select(r => (double)r.TranscriptionLatencyMs).Order().ToList();
+                var total = g.Where(r => r.Success).Select(r => (double)r.TotalLatencyMs).Order().ToList();
+                var failures = g.Count(r => !r.Success);
+                return new ModelStats
+                {
+                    Provider = g.Key.Provider,
+                    Model = g.Key.Model,
+                    TotalDictations = g.Count(),
+                    TotalWords = g.Sum(r => r.WordCount),
+                    SuccessCount = g.Count() - failures,
+                    FailureCount = failures,
+                    TotalRecordingMs = g.Sum(r => r.RecordingDurationMs),
+                    ErrorRate = (double)failures / g.Count(),
+                    AvgTranscriptionMs = transcription.Average(),
+                    P50TranscriptionMs = Percentile(transcription, 0.50),
+                    P90TranscriptionMs = Percentile(transcription, 0.90),
+                    P95TranscriptionMs = Percentile(transcription, 0.95),
+                    P50TotalLatencyMs = Percentile(total, 0.50),
+                    P95TotalLatencyMs = Percentile(total, 0.95),
+                };
+            })
+            .OrderByDescending(s => s.TotalDictations)
+            .ToList();
+    }
+
+    // Linear interpolation between closest ranks; expects sorted input
+    private static double Percentile(List<double> sorted, double p)
+    {
+        if (sorted.Count == 0)
+            return 0;
+        double rank = p * (sorted.Count - 1);
+        int lower = (int)Math.Floor(rank);
+        int upper = (int)Math.Ceiling(rank);
+        return sorted[lower] + (sorted[upper] - sorted[lower]) * (rank - lower);
+    }
+
+    public Task<List<AppStats>> GetAppStatsAsync(int days, CancellationToken ct = default)
+        => GetAppStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<AppStats>> GetAppStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        return await InRange(db.Dictations, from, to)
+            .Where(d => d.Success)
+            .GroupBy(d => d.App)
+            .Select(g => new AppStats
+            {
+                App = g.Key,
+                TotalDictations = g.Count(),
+                TotalWords = g.Sum(d => d.WordCount),
+            })
+            .OrderByDescending(s => s.TotalWords)
+            .ToListAsync(ct);
+    }
+
+    public Task<List<ErrorCategoryStats>> GetErrorCategoryStatsAsync(int days, CancellationToken ct = default)
+        => GetErrorCategoryStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<ErrorCategoryStats>> GetErrorCategoryStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        return await InRange(db.Dictations, from, to)
+            .Where(d => !d.Success)
+            .GroupBy(d => d.ErrorCategory ?? ErrorCategories.Other)
+            .Select(g => new ErrorCategoryStats
+            {
+                Category = g.Key,
+                Count = g.Count(),
+                LastOccurred = g.Max(d => d.Timestamp),
+            })
+            .OrderByDescending(s => s.Count)
+            .ToListAsync(ct);
+    }
+
+    /// <summary>
+    /// Thumbs-up share of rated dictations per provider, model, language and post-processing
+    /// pipeline, so the effect of switching a model or a stage on or off can be compared.
+    /// </summary>
+    public Task<List<RatingStats>> GetRatingStatsAsync(int days, CancellationToken ct = default)
+        => GetRatingStatsAsync(DateTime.UtcNow.AddDays(-days), null, ct);
+
+    public async Task<List<RatingStats>> GetRatingStatsAsync(DateTime? from, DateTime? to, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var rows = await InRange(db.Dictations, from, to)
+            .Where(d => d.Rating != null)
+            .Select(d => new { d.Provider, d.Model, d.Language, d.PipelineStages, d.Rating })
+            .ToListAsync(ct);
+
+        return rows
+            .GroupBy(r => (r.Provider, r.Model, r.Language, Pipeline: DescribePipeline(r.PipelineStages)))
+            .Select(g =>
+            {
+                var up = g.Count(r => r.Rating > 0);
+                return new RatingStats
+                {
+                    Provider = g.Key.Provider,
+                    Model = g.Key.Model,
+                    Language = g.Key.Language,
+                    Pipeline = g.Key.Pipeline,
+                    Up = up,
+                    Down = g.Count() - up,
+                    Approval = (double)up / g.Count(),
+                };
+            })
+            .OrderByDescending(s => s.Up + s.Down)
+            .ToList();
+    }
+
+    // Stage names are stored as a JSON array; rows from before the column existed have none
+    private static string DescribePipeline(string? stages)
+    {
+        if (string.IsNullOrEmpty(stages))
+            return "";
+        try
+        {
+            var names = JsonSerializer.Deserialize<List<string>>(stages);
+            return names is { Count: > 0 } ? string.Join(" + ", names) : "";
+        }
+        catch (JsonException)
+        {
+            return "";
+        }
+    }
+
+    public Task SaveEvaluationAsync(IReadOnlyList<EvaluationResult> results, CancellationToken ct = default)
+    {
+        return WriteAsync(async db =>
+        {
+            db.EvaluationResults.AddRange(results);
+            await db.SaveChangesAsync(ct);
+        }, ct);
+    }
+
+    /// <summary>
+    /// The most recent evaluation runs, newest first. A run's WER is pooled over all its samples
+    /// (total errors over total reference words), so long samples weigh more than short ones.
+    /// </summary>
+    public async Task<List<EvaluationRunSummary>> GetEvaluationRunsAsync(int limit, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var runs = await db.EvaluationResults
+            .GroupBy(e => new { e.RunId, e.Provider, e.Model, e.Pipeline })
+            .Select(g => new EvaluationRunSummary
+            {
+                RunId = g.Key.RunId,
+                Timestamp = g.Min(e => e.Timestamp),
+                Provider = g.Key.Provider,
+                Model = g.Key.Model,
+                Pipeline = g.Key.Pipeline,
+                Samples = g.Count(),
+                Failures = g.Count(e => e.ErrorMessage != null),
+                ReferenceWords = g.Sum(e => e.ReferenceWords),
+                Errors = g.Sum(e => e.Substitutions + e.Deletions + e.Insertions),
+                AvgLatencyMs = g.Average(e => (double)e.LatencyMs),
+            })
+            .OrderByDescending(r => r.Timestamp)
+            .Take(limit)
+            .ToListAsync(ct);
+        foreach (var run in runs)
+            run.Wer = run.ReferenceWords > 0 ? (double)run.Errors / run.ReferenceWords : 0;
+        return runs;
+    }
+
+    /// <summary>
+    /// Evaluates the daily goal against successful dictations grouped by local calendar day.
+    /// A zero target is ignored; with no targets set any dictation counts toward the streak.
+    /// </summary>
+    public async Task<GoalProgress> GetGoalProgressAsync(
+        int dailyWords, int dailyDictations, CancellationToken ct = default)
+    {
+        await using var db = _createContext();
+        var rows = await db.Dictations
+            .Where(d => d.Success)
+            .Select(d => new { d.Timestamp, d.WordCount })
+            .ToListAsync(ct);
+
+        var days = rows
+            .GroupBy(r => DateOnly.FromDateTime(r.Timestamp.ToLocalTime()))
+            .ToDictionary(g => g.Key, g => (Words: g.Sum(r => r.WordCount), Count: g.Count()));
+
+        bool Met(DateOnly day) =>
+            days.TryGetValue(day, out var t)
+            && t.Count > 0
+            && (dailyWords <= 0 || t.Words >= dailyWords)
+            && (dailyDictations <= 0 || t.Count >= dailyDictations);
+
+        var today = DateOnly.FromDateTime(DateTime.Now);
+        days.TryGetValue(today, out var todayTotals);
+
+        // Today still counts as "in progress", so an unmet today doesn't break the streak
+        int current = 0;
+        for (var day = Met(today) ? today : today.AddDays(-1); Met(day); day = day.AddDays(-1))
+            current++;
+
+        int best = 0, run = 0;
+        DateOnly? previous = null;
+        foreach (var day in days.Keys.Where(Met).Order())
+        {
+            run = previous == day.AddDays(-1) ? run + 1 : 1;
+            best = Math.Max(best, run);
+            previous = day;
+        }
+
+        return new GoalProgress
+        {
+            TodayWords = todayTotals.Words,
+            TodayDictations = todayTotals.Count,
+            DailyWordsGoal = dailyWords,
+            DailyDictationsGoal = dailyDictations,
+            TodayMet = Met(today),
+            CurrentStreak = current,
+            BestStreak = best,
+        };
+    }
+
     public async Task<List<HeatmapStats>> GetHeatmapStatsAsync(CancellationToken ct = default)
     {
+        await using var db = _createContext();
         var since = DateTime.UtcNow.AddDays(-365);
-        var rows = await _db.Dictations
+        var rows = await db.Dictations
             .Where(d => d.Timestamp >= since)
             .GroupBy(d => d.Timestamp.Date)
             .Select(g => new { Date = g.Key, Count = g.Count() })
@@ -131,15 +874,15 @@ public class DictationRepository
         }).ToList();
     }
 
-    public async Task<List<WordFrequencyEntry>> GetWordFrequenciesAsync(
+    public Task<List<WordFrequencyEntry>> GetWordFrequenciesAsync(
         int? days, int topN = 100, CancellationToken ct = default)
+        => GetWordFrequenciesAsync(days.HasValue ? DateTime.UtcNow.AddDays(-days.Value) : null, null, topN, ct);
+
+    public async Task<List<WordFrequencyEntry>> GetWordFrequenciesAsync(
+        DateTime? from, DateTime? to, int topN = 100, CancellationToken ct = default)
     {
-        IQueryable<Dictation> query = _db.Dictations.Where(d => d.Success);
-        if (days.HasValue)
-        {
-            var since = DateTime.UtcNow.AddDays(-days.Value);
-            query = query.Where(d => d.Timestamp >= since);
-        }
+        await using var db = _createContext();
+        var query = InRange(db.Dictations, from, to).Where(d => d.Success);
 
         var texts = await query
             .Select(d => d.TranscribedText)
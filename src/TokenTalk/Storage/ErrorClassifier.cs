@@ -0,0 +1,75 @@
+using System.Net;
+using System.Net.Sockets;
+
+namespace TokenTalk.Storage;
+
+public static class ErrorCategories
+{
+    public const string Network = "network";
+    public const string Auth = "auth";
+    public const string RateLimit = "rate_limit";
+    public const string Timeout = "timeout";
+    public const string Provider = "provider";
+    public const string EmptyTranscription = "empty_transcription";
+    // Audio.MinSpeechMs found no speech in the recording, so it was never transcribed
+    public const string NoSpeech = "no_speech";
+    public const string Injection = "injection";
+    // Rejected by the user in the confirmation popup; not an actual failure
+    public const string Discarded = "discarded";
+    // Held back by Output.DuplicateGuardSeconds as a repeat of the previous paste
+    public const string Duplicate = "duplicate";
+    public const string Other = "other";
+}
+
+/// <summary>
+/// Maps failures to an <see cref="ErrorCategories"/> value for analytics. Exceptions are
+/// classified by type and HTTP status; stored messages are classified by keyword so rows
+/// saved before categories existed can be backfilled.
+/// </summary>
+public static class ErrorClassifier
+{
+    public static string Classify(Exception ex)
+    {
+        switch (ex)
+        {
+            case HttpRequestException { StatusCode: HttpStatusCode.Unauthorized or HttpStatusCode.Forbidden }:
+                return ErrorCategories.Auth;
+            case HttpRequestException { StatusCode: HttpStatusCode.TooManyRequests }:
+                return ErrorCategories.RateLimit;
+            case HttpRequestException { StatusCode: not null }:
+                return ErrorCategories.Provider;
+            case HttpRequestException or SocketException:
+                return ErrorCategories.Network;
+            case TimeoutException or TaskCanceledException:
+                return ErrorCategories.Timeout;
+            case Transcription.ProviderUnavailableException:
+                return ErrorCategories.Provider;
+        }
+        return Classify(ex.Message);
+    }
+
+    public static string Classify(string? message)
+    {
+        if (string.IsNullOrWhiteSpace(message))
+            return ErrorCategories.Other;
+
+        var m = message.ToLowerInvariant();
+        if (m.Contains("empty transcription"))
+            return ErrorCategories.EmptyTranscription;
+        if (m.Contains("no speech"))
+            return ErrorCategories.NoSpeech;
+        if (m.Contains("unauthorized") || m.Contains("forbidden") || m.Contains("api key"))
+            return ErrorCategories.Auth;
+        if (m.Contains("toomanyrequests") || m.Contains("rate limit"))
+            return ErrorCategories.RateLimit;
+        if (m.Contains("timed out") || m.Contains("timeout"))
+            return ErrorCategories.Timeout;
+        if (m.Contains("clipboard") || m.Contains("paste") || m.Contains("sendinput"))
+            return ErrorCategories.Injection;
+        if (m.Contains("no such host") || m.Contains("connection") || m.Contains("network"))
+            return ErrorCategories.Network;
+        if (m.Contains("api error"))
+            return ErrorCategories.Provider;
+        return ErrorCategories.Other;
+    }
+}
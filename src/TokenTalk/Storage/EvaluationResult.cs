@@ -0,0 +1,77 @@
+using System.Text.Json.Serialization;
+using System.ComponentModel.DataAnnotations.Schema;
+
+namespace TokenTalk.Storage;
+
+/// <summary>One reference sample scored in a <c>--eval</c> run.</summary>
+[Table("evaluation_results")]
+public class EvaluationResult
+{
+    [Column("id")]
+    [JsonPropertyName("ID")]
+    public long Id { get; set; }
+
+    // Shared by every sample of the same run
+    [Column("run_id")]
+    [JsonPropertyName("RunId")]
+    public string RunId { get; set; } = string.Empty;
+
+    [Column("timestamp")]
+    [JsonPropertyName("Timestamp")]
+    public DateTime Timestamp { get; set; } = DateTime.UtcNow;
+
+    // File name of the sample without extension
+    [Column("sample")]
+    [JsonPropertyName("Sample")]
+    public string Sample { get; set; } = string.Empty;
+
+    [Column("provider")]
+    [JsonPropertyName("Provider")]
+    public string Provider { get; set; } = string.Empty;
+
+    [Column("model")]
+    [JsonPropertyName("Model")]
+    public string Model { get; set; } = string.Empty;
+
+    // Post-processing stages that ran, joined with " + "; empty for raw provider output
+    [Column("pipeline")]
+    [JsonPropertyName("Pipeline")]
+    public string Pipeline { get; set; } = string.Empty;
+
+    [Column("reference_text")]
+    [JsonPropertyName("ReferenceText")]
+    public string ReferenceText { get; set; } = string.Empty;
+
+    [Column("hypothesis_text")]
+    [JsonPropertyName("HypothesisText")]
+    public string HypothesisText { get; set; } = string.Empty;
+
+    [Column("reference_words")]
+    [JsonPropertyName("ReferenceWords")]
+    public int ReferenceWords { get; set; }
+
+    [Column("substitutions")]
+    [JsonPropertyName("Substitutions")]
+    public int Substitutions { get; set; }
+
+    [Column("deletions")]
+    [JsonPropertyName("Deletions")]
+    public int Deletions { get; set; }
+
+    [Column("insertions")]
+    [JsonPropertyName("Insertions")]
+    public int Insertions { get; set; }
+
+    [Column("wer")]
+    [JsonPropertyName("Wer")]
+    public double Wer { get; set; }
+
+    [Column("latency_ms")]
+    [JsonPropertyName("LatencyMs")]
+    public long LatencyMs { get; set; }
+
+    // Set when the provider failed on this sample; the WER is then 1
+    [Column("error_message")]
+    [JsonPropertyName("ErrorMessage")]
+    public string? ErrorMessage { get; set; }
+}
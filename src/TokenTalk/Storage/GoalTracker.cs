@@ -0,0 +1,62 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Storage;
+
+/// <summary>
+/// Tracks progress toward the configured daily goal and raises <see cref="GoalReached"/>
+/// once per day when it is first met. The goal already being met at startup does not notify.
+/// </summary>
+public class GoalTracker
+{
+    private readonly DictationRepository _repository;
+    private readonly Func<GoalOptions> _getOptions;
+    private readonly ILogger<GoalTracker> _logger;
+    private readonly SemaphoreSlim _lock = new(1, 1);
+
+    private bool _initialized;
+    private DateOnly? _notifiedDay;
+
+    public GoalProgress? Current { get; private set; }
+
+    public event EventHandler<GoalProgress>? ProgressChanged;
+    public event EventHandler<GoalProgress>? GoalReached;
+
+    public GoalTracker(DictationRepository repository, Func<GoalOptions> getOptions, ILogger<GoalTracker> logger)
+    {
+        _repository = repository;
+        _getOptions = getOptions;
+        _logger = logger;
+    }
+
+    public async Task<GoalProgress> RefreshAsync(CancellationToken ct = default)
+    {
+        await _lock.WaitAsync(ct);
+        try
+        {
+            var options = _getOptions();
+            var progress = await _repository.GetGoalProgressAsync(options.DailyWords, options.DailyDictations, ct);
+            Current = progress;
+            ProgressChanged?.Invoke(this, progress);
+
+            var today = DateOnly.FromDateTime(DateTime.Now);
+            if (progress.HasGoal && progress.TodayMet && _notifiedDay != today)
+            {
+                _notifiedDay = today;
+                if (_initialized)
+                {
+                    _logger.LogInformation("Daily goal reached ({Words} words, {Count} dictations, streak {Streak})",
+                        progress.TodayWords, progress.TodayDictations, progress.CurrentStreak);
+                    if (options.NotifyOnGoal)
+                        GoalReached?.Invoke(this, progress);
+                }
+            }
+            _initialized = true;
+            return progress;
+        }
+        finally
+        {
+            _lock.Release();
+        }
+    }
+}
@@ -0,0 +1,107 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Diagnostics;
+
+namespace TokenTalk.Storage;
+
+/// <param name="KeepId">The earlier dictation, which stays.</param>
+/// <param name="DuplicateId">The one recorded right after it with (nearly) the same words.</param>
+/// <param name="Similarity">1 minus the word error rate of the duplicate against the kept text.</param>
+public record DuplicatePair(long KeepId, long DuplicateId, DateTime Timestamp, double Similarity, string Text);
+
+/// <summary>
+/// Finds back-to-back dictations from this machine that say the same thing, typically an
+/// accidental second hotkey press, and cleans them up: the later one is moved to the trash
+/// after its star, tags and rating are carried over to the one that stays.
+/// </summary>
+public class HistoryDeduplicator
+{
+    private const int BatchSize = 500;
+    // Further apart than this, saying the same thing again is taken to be deliberate
+    private static readonly TimeSpan Window = TimeSpan.FromSeconds(60);
+    private const double MinSimilarity = 0.9;
+
+    private readonly DictationRepository _repository;
+    private readonly ILogger<HistoryDeduplicator> _logger;
+
+    public HistoryDeduplicator(DictationRepository repository, ILogger<HistoryDeduplicator> logger)
+    {
+        _repository = repository;
+        _logger = logger;
+    }
+
+    /// <param name="from">Only dictations since then; null for all history.</param>
+    public async Task<List<DuplicatePair>> FindAsync(DateTime? from, CancellationToken ct = default)
+    {
+        var matches = await MatchAsync(from, ct);
+        return matches
+            .Select(m => new DuplicatePair(m.Keep.Id, m.Duplicate.Id, m.Duplicate.Timestamp, m.Similarity, m.Duplicate.TranscribedText))
+            .ToList();
+    }
+
+    /// <summary>Trashes every duplicate <see cref="FindAsync"/> reports; returns how many.</summary>
+    public async Task<int> RemoveAsync(DateTime? from, CancellationToken ct = default)
+    {
+        var matches = await MatchAsync(from, ct);
+        foreach (var (keep, duplicate, _) in matches)
+        {
+            await MergeIntoAsync(keep, duplicate, ct);
+            await _repository.DeleteAsync(duplicate.Id, ct);
+        }
+        if (matches.Count > 0)
+            _logger.LogInformation("Moved {Count} duplicate dictation(s) to the trash", matches.Count);
+        return matches.Count;
+    }
+
+    private async Task<List<(Dictation Keep, Dictation Duplicate, double Similarity)>> MatchAsync(DateTime? from, CancellationToken ct)
+    {
+        var matches = new List<(Dictation Keep, Dictation Duplicate, double Similarity)>();
+        Dictation? previous = null;
+        var after = from ?? DateTime.MinValue;
+        while (true)
+        {
+            var batch = await _repository.GetRecordedAfterAsync(after, Environment.MachineName, BatchSize, ct);
+            if (batch.Count == 0)
+                break;
+            after = batch[^1].Timestamp;
+
+            foreach (var dictation in batch)
+            {
+                // Compared with the kept one, so a triple press yields two pairs against the first
+                if (previous != null && dictation.Timestamp - previous.Timestamp <= Window
+                    && Similarity(previous.TranscribedText, dictation.TranscribedText) is var similarity and >= MinSimilarity)
+                {
+                    matches.Add((previous, dictation, Math.Round(similarity, 3)));
+                    continue;
+                }
+                previous = dictation;
+            }
+        }
+        return matches;
+    }
+
+    // The kept row is updated in memory too, so a second duplicate of it merges on top of the first
+    private async Task MergeIntoAsync(Dictation keep, Dictation duplicate, CancellationToken ct)
+    {
+        if (duplicate.Starred && !keep.Starred)
+        {
+            await _repository.SetStarredAsync(keep.Id, true, ct);
+            keep.Starred = true;
+        }
+        var keptTags = DictationRepository.ParseTags(keep.Tags);
+        var tags = keptTags.Union(DictationRepository.ParseTags(duplicate.Tags)).ToList();
+        if (tags.Count > keptTags.Count)
+            keep.Tags = string.Join(',', await _repository.SetTagsAsync(keep.Id, tags, ct));
+        if (keep.Rating == null && duplicate.Rating != null)
+        {
+            await _repository.SetRatingAsync(keep.Id, duplicate.Rating, ct);
+            keep.Rating = duplicate.Rating;
+        }
+    }
+
+    private static double Similarity(string kept, string candidate)
+    {
+        if (string.IsNullOrWhiteSpace(kept) || string.IsNullOrWhiteSpace(candidate))
+            return 0;
+        return 1 - WordErrorRate.Compute(kept, candidate).Rate;
+    }
+}
@@ -0,0 +1,153 @@
+using System.Globalization;
+using System.Text;
+using System.Text.Json;
+
+namespace TokenTalk.Storage;
+
+public enum ExportFormat { Csv, Json, Markdown }
+
+/// <summary>
+/// Streams dictations to CSV, JSON or Markdown without materialising the full history in memory.
+/// </summary>
+public static class HistoryExporter
+{
+    private static readonly string[] CsvColumns =
+    [
+        "id", "timestamp", "recording_start_ms", "recording_duration_ms", "transcription_latency_ms",
+        "injection_latency_ms", "total_latency_ms", "audio_size_bytes", "audio_sample_rate",
+        "provider", "model", "language", "word_count", "character_count", "success",
+        "error_message", "error_category", "starred", "tags", "transcribed_text", "original_text", "raw_text", "pipeline_stages",
+        "speaking_wpm", "effective_wpm", "session_id", "machine", "profile", "retry_of", "app", "injection_status",
+    ];
+
+    public static async Task<int> ExportAsync(
+        IAsyncEnumerable<Dictation> dictations,
+        ExportFormat format,
+        Stream output,
+        CancellationToken ct = default)
+    {
+        return format switch
+        {
+            ExportFormat.Csv => await WriteCsvAsync(dictations, output, ct),
+            ExportFormat.Json => await WriteJsonAsync(dictations, output, ct),
+            _ => await WriteMarkdownAsync(dictations, output, ct),
+        };
+    }
+
+    private static async Task<int> WriteCsvAsync(IAsyncEnumerable<Dictation> dictations, Stream output, CancellationToken ct)
+    {
+        await using var writer = new StreamWriter(output, new UTF8Encoding(false), leaveOpen: true);
+        await writer.WriteLineAsync(string.Join(',', CsvColumns));
+
+        int count = 0;
+        await foreach (var d in dictations.WithCancellation(ct))
+        {
+            string[] fields =
+            [
+                d.Id.ToString(CultureInfo.InvariantCulture),
+                d.Timestamp.ToString("o", CultureInfo.InvariantCulture),
+                d.RecordingStartMs.ToString(CultureInfo.InvariantCulture),
+                d.RecordingDurationMs.ToString(CultureInfo.InvariantCulture),
+                d.TranscriptionLatencyMs.ToString(CultureInfo.InvariantCulture),
+                d.InjectionLatencyMs.ToString(CultureInfo.InvariantCulture),
+                d.TotalLatencyMs.ToString(CultureInfo.InvariantCulture),
+                d.AudioSizeBytes.ToString(CultureInfo.InvariantCulture),
+                d.AudioSampleRate.ToString(CultureInfo.InvariantCulture),
+                d.Provider,
+                d.Model,
+                d.Language,
+                d.WordCount.ToString(CultureInfo.InvariantCulture),
+                d.CharacterCount.ToString(CultureInfo.InvariantCulture),
+                d.Success ? "true" : "false",
+                d.ErrorMessage ?? "",
+                d.ErrorCategory ?? "",
+                d.Starred ? "true" : "false",
+                d.Tags,
+                d.TranscribedText,
+                d.OriginalText ?? "",
+                d.RawText ?? "",
+                d.PipelineStages ?? "",
+                d.SpeakingWpm.ToString(CultureInfo.InvariantCulture),
+                d.EffectiveWpm.ToString(CultureInfo.InvariantCulture),
+                d.SessionId ?? "",
+                d.Machine,
+                d.Profile,
+                d.RetryOf?.ToString(CultureInfo.InvariantCulture) ?? "",
+                d.App,
+                d.InjectionStatus ?? "",
+            ];
+            await writer.WriteLineAsync(string.Join(',', fields.Select(EscapeCsv)));
+            count++;
+        }
+
+        await writer.FlushAsync(ct);
+        return count;
+    }
+
+    private static string EscapeCsv(string value)
+    {
+        if (value.IndexOfAny([',', '"', '\n', '\r']) < 0)
+            return value;
+        return "\"" + value.Replace("\"", "\"\"") + "\"";
+    }
+
+    private static async Task<int> WriteJsonAsync(IAsyncEnumerable<Dictation> dictations, Stream output, CancellationToken ct)
+    {
+        await using var writer = new Utf8JsonWriter(output, new JsonWriterOptions { Indented = true });
+        writer.WriteStartArray();
+
+        int count = 0;
+        await foreach (var d in dictations.WithCancellation(ct))
+        {
+            JsonSerializer.Serialize(writer, d);
+            count++;
+
+            // Flush periodically so large exports don't buffer entirely in the writer
+            if (count % 100 == 0)
+                await writer.FlushAsync(ct);
+        }
+
+        writer.WriteEndArray();
+        await writer.FlushAsync(ct);
+        return count;
+    }
+
+    private static async Task<int> WriteMarkdownAsync(IAsyncEnumerable<Dictation> dictations, Stream output, CancellationToken ct)
+    {
+        await using var writer = new StreamWriter(output, new UTF8Encoding(false), leaveOpen: true);
+        await writer.WriteLineAsync("# TokenTalk History");
+
+        int count = 0;
+        await foreach (var d in dictations.WithCancellation(ct))
+        {
+            await writer.WriteLineAsync();
+            await writer.WriteLineAsync($"## {d.Timestamp.ToLocalTime():yyyy-MM-dd HH:mm:ss}");
+            await writer.WriteLineAsync();
+            await writer.WriteLineAsync(
+                $"- Provider: {d.Provider} ({d.Model}), language: {d.Language}");
+            await writer.WriteLineAsync(
+                $"- Recording: {d.RecordingDurationMs} ms, transcription: {d.TranscriptionLatencyMs} ms, " +
+                $"injection: {d.InjectionLatencyMs} ms, total: {d.TotalLatencyMs} ms");
+            await writer.WriteLineAsync($"- Words: {d.WordCount}, characters: {d.CharacterCount}");
+            if (d.SpeakingWpm > 0)
+                await writer.WriteLineAsync($"- Speaking: {d.SpeakingWpm:0.#} WPM, effective: {d.EffectiveWpm:0.#} WPM");
+            if (d.Starred)
+                await writer.WriteLineAsync("- Starred");
+            if (!string.IsNullOrEmpty(d.Tags))
+                await writer.WriteLineAsync($"- Tags: {string.Join(", ", DictationRepository.ParseTags(d.Tags).Select(t => "#" + t))}");
+            if (!d.Success)
+                await writer.WriteLineAsync($"- Error: {d.ErrorMessage}");
+
+            if (!string.IsNullOrEmpty(d.TranscribedText))
+            {
+                await writer.WriteLineAsync();
+                foreach (var line in d.TranscribedText.Split('\n'))
+                    await writer.WriteLineAsync("> " + line.TrimEnd('\r'));
+            }
+            count++;
+        }
+
+        await writer.FlushAsync(ct);
+        return count;
+    }
+}
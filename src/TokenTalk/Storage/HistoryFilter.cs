@@ -0,0 +1,21 @@
+namespace TokenTalk.Storage;
+
+public enum HistorySort { Newest, Oldest, MostWords, FewestWords, Slowest, Fastest }
+
+/// <summary>
+/// Criteria for <see cref="DictationRepository.GetHistoryAsync(int, int, HistoryFilter, CancellationToken)"/>.
+/// Null or empty members do not filter. Dates are UTC; <see cref="To"/> is exclusive.
+/// </summary>
+public class HistoryFilter
+{
+    public bool StarredOnly { get; set; }
+    public string? Tag { get; set; }
+    public string? Provider { get; set; }
+    public bool? Success { get; set; }
+    public string? Language { get; set; }
+    public DateTime? From { get; set; }
+    public DateTime? To { get; set; }
+    public int? MinWords { get; set; }
+    public int? MaxWords { get; set; }
+    public HistorySort Sort { get; set; } = HistorySort.Newest;
+}
@@ -0,0 +1,80 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Storage;
+
+public record PruneResult(DateTime RunAt, int Removed);
+
+/// <summary>
+/// Periodically applies the configured history retention limits and empties the trash.
+/// Limits are read on every run so changes in Settings apply without restart.
+/// </summary>
+public class HistoryRetentionService
+{
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromMinutes(1);
+    private static readonly TimeSpan Interval = TimeSpan.FromHours(1);
+
+    private readonly DictationRepository _repository;
+    private readonly Func<HistoryOptions> _getOptions;
+    private readonly ILogger<HistoryRetentionService> _logger;
+
+    public PruneResult? LastResult { get; private set; }
+
+    public HistoryRetentionService(
+        DictationRepository repository,
+        Func<HistoryOptions> getOptions,
+        ILogger<HistoryRetentionService> logger)
+    {
+        _repository = repository;
+        _getOptions = getOptions;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        using var timer = new PeriodicTimer(Interval);
+        try
+        {
+            // Let the startup queries (home page, stats) finish before the first prune
+            await Task.Delay(StartupDelay, ct);
+            do
+            {
+                await PruneOnceAsync(ct);
+            }
+            while (await timer.WaitForNextTickAsync(ct));
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    public async Task PruneOnceAsync(CancellationToken ct = default)
+    {
+        var options = _getOptions();
+
+        try
+        {
+            var purged = await _repository.PurgeDeletedAsync(Math.Max(0, options.TrashDays), ct);
+            if (purged > 0)
+                _logger.LogInformation("Purged {Count} deleted dictations", purged);
+
+            if (options.MaxAgeDays <= 0 && options.MaxCount <= 0)
+                return;
+
+            var removed = await _repository.PruneAsync(options.MaxAgeDays, options.MaxCount, ct);
+            LastResult = new PruneResult(DateTime.UtcNow, removed);
+            if (removed > 0)
+                _logger.LogInformation(
+                    "Pruned {Count} dictations (max age {MaxAgeDays}d, max count {MaxCount})",
+                    removed, options.MaxAgeDays, options.MaxCount);
+        }
+        catch (OperationCanceledException)
+        {
+            throw;
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "History pruning failed");
+        }
+    }
+}
@@ -0,0 +1,189 @@
+using Microsoft.EntityFrameworkCore;
+using Microsoft.EntityFrameworkCore.Storage;
+
+namespace TokenTalk.Storage;
+
+/// <summary>
+/// Versioned schema migrations for the SQLite database. EnsureCreated only builds the schema
+/// for a brand-new file; existing databases are brought forward by applying every migration
+/// newer than the version recorded in schema_version, each in its own transaction.
+/// </summary>
+public static class SchemaMigrator
+{
+    private record Migration(int Version, string Description, Func<TokenTalkDbContext, Task> Apply);
+
+    // Append new migrations at the end with the next version number; never edit or reorder
+    // migrations that have shipped. SQL must run on both SQLite and Postgres; Postgres support
+    // arrived at version 11, so earlier migrations only ever run against SQLite. Versions 1-5
+    // check for the column first because databases created before schema_version existed may
+    // already have them.
+    private static readonly Migration[] Migrations =
+    [
+        new(1, "Add starred flag", db => AddColumnIfMissingAsync(db, "dictations", "starred", "INTEGER NOT NULL DEFAULT 0")),
+        new(2, "Add tags", db => AddColumnIfMissingAsync(db, "dictations", "tags", "TEXT NOT NULL DEFAULT ''")),
+        new(3, "Add original text for edits", db => AddColumnIfMissingAsync(db, "dictations", "original_text", "TEXT NULL")),
+        new(4, "Add raw provider text", db => AddColumnIfMissingAsync(db, "dictations", "raw_text", "TEXT NULL")),
+        new(5, "Add pipeline stages", db => AddColumnIfMissingAsync(db, "dictations", "pipeline_stages", "TEXT NULL")),
+        new(6, "Add starred index", db => db.Database.ExecuteSqlRawAsync(
+            "CREATE INDEX IF NOT EXISTS idx_dictations_starred ON dictations (starred)")),
+        new(7, "Add words-per-minute rates", AddRatesAsync),
+        new(8, "Add error categories", AddErrorCategoriesAsync),
+        new(9, "Add soft delete", async db =>
+        {
+            await AddColumnIfMissingAsync(db, "dictations", "deleted_at", "TEXT NULL");
+            await db.Database.ExecuteSqlRawAsync(
+                "CREATE INDEX IF NOT EXISTS idx_dictations_deleted_at ON dictations (deleted_at)");
+        }),
+        new(10, "Add sessions", AddSessionsAsync),
+        new(11, "Add machine name", db => AddColumnIfMissingAsync(db, "dictations", "machine", "TEXT NOT NULL DEFAULT ''")),
+        new(12, "Add profile", db => AddColumnIfMissingAsync(db, "dictations", "profile", "TEXT NOT NULL DEFAULT ''")),
+        new(13, "Add retry link", db => AddColumnIfMissingAsync(db, "dictations", "retry_of", "INTEGER NULL")),
+        new(14, "Add rating", db => AddColumnIfMissingAsync(db, "dictations", "rating", "INTEGER NULL")),
+        new(15, "Add evaluation results", AddEvaluationResultsAsync),
+        new(16, "Add low-confidence words", db => AddColumnIfMissingAsync(db, "dictations", "low_confidence", "TEXT NULL")),
+        new(17, "Add app name", db => AddColumnIfMissingAsync(db, "dictations", "app", "TEXT NOT NULL DEFAULT ''")),
+        new(18, "Add injection status", db => AddColumnIfMissingAsync(db, "dictations", "injection_status", "TEXT NULL")),
+    ];
+
+    public static int LatestVersion => Migrations[^1].Version;
+
+    /// <summary>
+    /// Applies pending migrations and returns how many ran. A database that EnsureCreated has
+    /// just built already matches the current model, so it is stamped with the latest version.
+    /// </summary>
+    public static async Task<int> MigrateAsync(TokenTalkDbContext db, bool freshlyCreated)
+    {
+        await db.Database.ExecuteSqlRawAsync(
+            "CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL PRIMARY KEY, " +
+            "description TEXT NOT NULL, applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP)");
+
+        if (freshlyCreated)
+        {
+            await RecordVersionAsync(db, LatestVersion, "Initial schema");
+            return 0;
+        }
+
+        var current = await GetCurrentVersionAsync(db);
+        int applied = 0;
+        foreach (var migration in Migrations.Where(m => m.Version > current).OrderBy(m => m.Version))
+        {
+            await using var transaction = await db.Database.BeginTransactionAsync();
+            await migration.Apply(db);
+            await RecordVersionAsync(db, migration.Version, migration.Description);
+            await transaction.CommitAsync();
+            applied++;
+        }
+        return applied;
+    }
+
+    public static async Task<int> GetCurrentVersionAsync(TokenTalkDbContext db)
+    {
+        return await ScalarAsync(db, "SELECT COALESCE(MAX(version), 0) FROM schema_version");
+    }
+
+    private static Task RecordVersionAsync(TokenTalkDbContext db, int version, string description)
+    {
+        return db.Database.ExecuteSqlRawAsync(
+            "INSERT INTO schema_version (version, description) VALUES ({0}, {1}) ON CONFLICT DO NOTHING",
+            version, description);
+    }
+
+    private static async Task AddRatesAsync(TokenTalkDbContext db)
+    {
+        await AddColumnIfMissingAsync(db, "dictations", "speaking_wpm", "REAL NOT NULL DEFAULT 0");
+        await AddColumnIfMissingAsync(db, "dictations", "effective_wpm", "REAL NOT NULL DEFAULT 0");
+        // Backfill with the same formula as Dictation.UpdateRates
+        await db.Database.ExecuteSqlRawAsync(
+            "UPDATE dictations SET " +
+            "speaking_wpm = CASE WHEN recording_duration_ms > 0 " +
+            "THEN ROUND(word_count * 60000.0 / recording_duration_ms, 1) ELSE 0 END, " +
+            "effective_wpm = CASE WHEN recording_duration_ms + total_latency_ms > 0 " +
+            "THEN ROUND(word_count * 60000.0 / (recording_duration_ms + total_latency_ms), 1) ELSE 0 END");
+    }
+
+    private static async Task AddErrorCategoriesAsync(TokenTalkDbContext db)
+    {
+        await AddColumnIfMissingAsync(db, "dictations", "error_category", "TEXT NULL");
+        // Messages may be encrypted, so read them through EF (which decrypts) rather than
+        // classifying in SQL. Project only columns that exist at this version, and skip the
+        // soft-delete filter: deleted_at only arrives with migration 9.
+        var failed = await db.Dictations
+            .IgnoreQueryFilters()
+            .Where(d => !d.Success)
+            .Select(d => new { d.Id, d.ErrorMessage })
+            .ToListAsync();
+        foreach (var d in failed)
+        {
+            await db.Database.ExecuteSqlRawAsync(
+                "UPDATE dictations SET error_category = {0} WHERE id = {1}",
+                ErrorClassifier.Classify(d.ErrorMessage), d.Id);
+        }
+    }
+
+    private static async Task AddSessionsAsync(TokenTalkDbContext db)
+    {
+        await AddColumnIfMissingAsync(db, "dictations", "session_id", "TEXT NULL");
+        await db.Database.ExecuteSqlRawAsync(
+            "CREATE INDEX IF NOT EXISTS idx_dictations_session_id ON dictations (session_id)");
+
+        // Backfill with the default gap; later dictations use the configured one
+        var rows = await db.Dictations
+            .IgnoreQueryFilters()
+            .OrderBy(d => d.Timestamp)
+            .Select(d => new { d.Id, d.Timestamp })
+            .ToListAsync();
+        string? session = null;
+        DateTime? previous = null;
+        foreach (var row in rows)
+        {
+            if (previous == null || row.Timestamp - previous.Value > DictationRepository.DefaultSessionGap)
+                session = Guid.NewGuid().ToString("N");
+            previous = row.Timestamp;
+            await db.Database.ExecuteSqlRawAsync(
+                "UPDATE dictations SET session_id = {0} WHERE id = {1}", session!, row.Id);
+        }
+    }
+
+    private static async Task AddEvaluationResultsAsync(TokenTalkDbContext db)
+    {
+        var (id, timestamp) = db.Database.IsSqlite()
+            ? ("INTEGER PRIMARY KEY AUTOINCREMENT", "TEXT")
+            : ("BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY", "TIMESTAMP WITH TIME ZONE");
+        await db.Database.ExecuteSqlRawAsync(
+            $"CREATE TABLE IF NOT EXISTS evaluation_results (id {id}, run_id TEXT NOT NULL, " +
+            $"timestamp {timestamp} NOT NULL, sample TEXT NOT NULL, provider TEXT NOT NULL, model TEXT NOT NULL, " +
+            "pipeline TEXT NOT NULL, reference_text TEXT NOT NULL, hypothesis_text TEXT NOT NULL, " +
+            "reference_words INTEGER NOT NULL, substitutions INTEGER NOT NULL, deletions INTEGER NOT NULL, " +
+            "insertions INTEGER NOT NULL, wer DOUBLE PRECISION NOT NULL, latency_ms BIGINT NOT NULL, error_message TEXT NULL)");
+        await db.Database.ExecuteSqlRawAsync(
+            "CREATE INDEX IF NOT EXISTS idx_evaluation_results_run_id ON evaluation_results (run_id)");
+    }
+
+    private static async Task AddColumnIfMissingAsync(TokenTalkDbContext db, string table, string column, string definition)
+    {
+        var exists = await ScalarAsync(db, db.Database.IsSqlite()
+            ? $"SELECT COUNT(*) FROM pragma_table_info('{table}') WHERE name = '{column}'"
+            : $"SELECT COUNT(*) FROM information_schema.columns WHERE table_name = '{table}' AND column_name = '{column}'") > 0;
+        if (exists)
+            return;
+
+        await db.Database.ExecuteSqlRawAsync($"ALTER TABLE {table} ADD COLUMN {column} {definition}");
+    }
+
+    private static async Task<int> ScalarAsync(TokenTalkDbContext db, string sql)
+    {
+        var connection = db.Database.GetDbConnection();
+        await db.Database.OpenConnectionAsync();
+        try
+        {
+            await using var command = connection.CreateCommand();
+            command.CommandText = sql;
+            command.Transaction = db.Database.CurrentTransaction?.GetDbTransaction();
+            return Convert.ToInt32(await command.ExecuteScalarAsync());
+        }
+        finally
+        {
+            await db.Database.CloseConnectionAsync();
+        }
+    }
+}
@@ -13,6 +13,9 @@ public class OverallStats
     public double AvgTotalLatencyMs { get; set; }
     public long TotalRecordingTimeMs { get; set; }
     public long TotalAudioSizeBytes { get; set; }
+    public double AvgSpeakingWpm { get; set; }
+    public double AvgEffectiveWpm { get; set; }
+    public double PeakSpeakingWpm { get; set; }
 }
 
 public class DailyStats
@@ -22,6 +25,8 @@ public class DailyStats
     public int TotalWords { get; set; }
     public int SuccessCount { get; set; }
     public int FailureCount { get; set; }
+    public double SpeakingWpm { get; set; }
+    public double EffectiveWpm { get; set; }
 }
 
 public class ProviderStats
@@ -34,6 +39,91 @@ public class ProviderStats
     public double AvgLatencyMs { get; set; }
 }
 
+public class ModelStats
+{
+    public string Provider { get; set; } = string.Empty;
+    public string Model { get; set; } = string.Empty;
+    public int TotalDictations { get; set; }
+    public int TotalWords { get; set; }
+    public int SuccessCount { get; set; }
+    public int FailureCount { get; set; }
+    // Audio sent to the provider, which cloud providers bill by the minute
+    public long TotalRecordingMs { get; set; }
+    public double ErrorRate { get; set; }
+    public double AvgTranscriptionMs { get; set; }
+    public double P50TranscriptionMs { get; set; }
+    public double P90TranscriptionMs { get; set; }
+    public double P95TranscriptionMs { get; set; }
+    public double P50TotalLatencyMs { get; set; }
+    public double P95TotalLatencyMs { get; set; }
+}
+
+public class AppStats
+{
+    // Process name; empty for dictations recorded before apps were stored
+    public string App { get; set; } = string.Empty;
+    public int TotalDictations { get; set; }
+    public int TotalWords { get; set; }
+}
+
+public class ErrorCategoryStats
+{
+    public string Category { get; set; } = string.Empty;
+    public int Count { get; set; }
+    public DateTime LastOccurred { get; set; }
+}
+
+public class RatingStats
+{
+    public string Provider { get; set; } = string.Empty;
+    public string Model { get; set; } = string.Empty;
+    public string Language { get; set; } = string.Empty;
+    // Post-processing stages joined with " + "; empty when none ran
+    public string Pipeline { get; set; } = string.Empty;
+    public int Up { get; set; }
+    public int Down { get; set; }
+    public double Approval { get; set; }
+}
+
+public class EvaluationRunSummary
+{
+    public string RunId { get; set; } = string.Empty;
+    public DateTime Timestamp { get; set; }
+    public string Provider { get; set; } = string.Empty;
+    public string Model { get; set; } = string.Empty;
+    public string Pipeline { get; set; } = string.Empty;
+    public int Samples { get; set; }
+    public int Failures { get; set; }
+    public int ReferenceWords { get; set; }
+    public int Errors { get; set; }
+    public double Wer { get; set; }
+    public double AvgLatencyMs { get; set; }
+}
+
+public class GoalProgress
+{
+    public int TodayWords { get; set; }
+    public int TodayDictations { get; set; }
+    public int DailyWordsGoal { get; set; }
+    public int DailyDictationsGoal { get; set; }
+    public bool TodayMet { get; set; }
+    // Consecutive days meeting the goal (any activity when no goal is set), ending today or yesterday
+    public int CurrentStreak { get; set; }
+    public int BestStreak { get; set; }
+    public bool HasGoal => DailyWordsGoal > 0 || DailyDictationsGoal > 0;
+}
+
+public class SessionSummary
+{
+    public string SessionId { get; set; } = string.Empty;
+    public DateTime Start { get; set; }
+    public DateTime End { get; set; }
+    public int DictationCount { get; set; }
+    public int TotalWords { get; set; }
+    public long RecordingMs { get; set; }
+    public TimeSpan Duration => End - Start;
+}
+
 public class HeatmapStats
 {
     public string Date { get; set; } = string.Empty;
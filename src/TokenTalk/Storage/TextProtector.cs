@@ -0,0 +1,79 @@
+using System.Security.Cryptography;
+using System.Text;
+
+namespace TokenTalk.Storage;
+
+/// <summary>
+/// Field-level AES-GCM encryption for transcript columns. The 256-bit key is generated once
+/// and stored next to the database, protected with DPAPI for the current Windows user.
+/// Values without the prefix are treated as plaintext, so rows written before encryption
+/// was enabled (or after it was disabled) stay readable.
+/// </summary>
+public sealed class TextProtector
+{
+    private const string Prefix = "enc:v1:";
+    private const int NonceSize = 12;
+    private const int TagSize = 16;
+
+    private readonly byte[] _key;
+    private readonly Func<bool> _isEnabled;
+
+    private TextProtector(byte[] key, Func<bool> isEnabled)
+    {
+        _key = key;
+        _isEnabled = isEnabled;
+    }
+
+    public static TextProtector LoadOrCreate(string keyPath, Func<bool> isEnabled)
+    {
+        byte[] key;
+        if (File.Exists(keyPath))
+        {
+            key = ProtectedData.Unprotect(File.ReadAllBytes(keyPath), null, DataProtectionScope.CurrentUser);
+        }
+        else
+        {
+            key = RandomNumberGenerator.GetBytes(32);
+            Directory.CreateDirectory(Path.GetDirectoryName(keyPath)!);
+            File.WriteAllBytes(keyPath, ProtectedData.Protect(key, null, DataProtectionScope.CurrentUser));
+        }
+        return new TextProtector(key, isEnabled);
+    }
+
+    public string Protect(string plaintext)
+    {
+        if (!_isEnabled() || plaintext.Length == 0)
+            return plaintext;
+
+        var plainBytes = Encoding.UTF8.GetBytes(plaintext);
+        var payload = new byte[NonceSize + plainBytes.Length + TagSize];
+        var nonce = payload.AsSpan(0, NonceSize);
+        var cipher = payload.AsSpan(NonceSize, plainBytes.Length);
+        var tag = payload.AsSpan(NonceSize + plainBytes.Length, TagSize);
+
+        RandomNumberGenerator.Fill(nonce);
+        using var aes = new AesGcm(_key, TagSize);
+        aes.Encrypt(nonce, plainBytes, cipher, tag);
+
+        return Prefix + Convert.ToBase64String(payload);
+    }
+
+    public string Unprotect(string stored)
+    {
+        if (!stored.StartsWith(Prefix, StringComparison.Ordinal))
+            return stored;
+
+        var payload = Convert.FromBase64String(stored[Prefix.Length..]);
+        var cipherLength = payload.Length - NonceSize - TagSize;
+        var plainBytes = new byte[cipherLength];
+
+        using var aes = new AesGcm(_key, TagSize);
+        aes.Decrypt(
+            payload.AsSpan(0, NonceSize),
+            payload.AsSpan(NonceSize, cipherLength),
+            payload.AsSpan(NonceSize + cipherLength, TagSize),
+            plainBytes);
+
+        return Encoding.UTF8.GetString(plainBytes);
+    }
+}
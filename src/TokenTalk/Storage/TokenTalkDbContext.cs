@@ -5,17 +5,37 @@ namespace TokenTalk.Storage;
 public class TokenTalkDbContext : DbContext
 {
     private readonly string _dbPath;
+    private readonly TextProtector? _protector;
+    private readonly string? _postgresConnectionString;
+    private readonly bool _readOnly;
 
-    public TokenTalkDbContext(string dbPath)
+    /// <param name="dbPath">Local SQLite file, used unless a Postgres connection string is given.</param>
+    /// <param name="postgresConnectionString">Shared Postgres database for aggregating several machines.</param>
+    /// <param name="readOnly">Opens the SQLite file without write access, for tools running next to the app.</param>
+    public TokenTalkDbContext(string dbPath, TextProtector? protector = null, string? postgresConnectionString = null, bool readOnly = false)
     {
         _dbPath = dbPath;
+        _protector = protector;
+        _postgresConnectionString = postgresConnectionString;
+        _readOnly = readOnly;
     }
 
     public DbSet<Dictation> Dictations => Set<Dictation>();
+    public DbSet<EvaluationResult> EvaluationResults => Set<EvaluationResult>();
 
     protected override void OnConfiguring(DbContextOptionsBuilder options)
     {
-        options.UseSqlite($"Data Source={_dbPath}");
+        if (!string.IsNullOrEmpty(_postgresConnectionString))
+        {
+            options.UseNpgsql(_postgresConnectionString);
+            return;
+        }
+
+        // Default Timeout doubles as the busy timeout: Microsoft.Data.Sqlite retries SQLITE_BUSY
+        // until it elapses, which covers a reader holding the WAL checkpoint during a write.
+        // Pooling keeps connections open between the short-lived contexts.
+        var mode = _readOnly ? ";Mode=ReadOnly" : "";
+        options.UseSqlite($"Data Source={_dbPath};Default Timeout=30;Pooling=True{mode}");
     }
 
     protected override void OnModelCreating(ModelBuilder modelBuilder)
@@ -41,17 +61,85 @@ public class TokenTalkDbContext : DbContext
             entity.Property(d => d.CharacterCount).HasColumnName("character_count");
             entity.Property(d => d.Success).HasColumnName("success");
             entity.Property(d => d.ErrorMessage).HasColumnName("error_message").IsRequired(false);
+            entity.Property(d => d.Starred).HasColumnName("starred");
+            entity.Property(d => d.Tags).HasColumnName("tags").HasDefaultValue("");
+            entity.Property(d => d.OriginalText).HasColumnName("original_text").IsRequired(false);
+            entity.Property(d => d.RawText).HasColumnName("raw_text").IsRequired(false);
+            entity.Property(d => d.PipelineStages).HasColumnName("pipeline_stages").IsRequired(false);
+            entity.Property(d => d.ErrorCategory).HasColumnName("error_category").IsRequired(false);
+            entity.Property(d => d.Machine).HasColumnName("machine").HasDefaultValue("");
+            entity.Property(d => d.Profile).HasColumnName("profile").HasDefaultValue("");
+            entity.Property(d => d.App).HasColumnName("app").HasDefaultValue("");
+            entity.Property(d => d.InjectionStatus).HasColumnName("injection_status").IsRequired(false);
+            entity.Property(d => d.RetryOf).HasColumnName("retry_of").IsRequired(false);
+            entity.Property(d => d.Rating).HasColumnName("rating").IsRequired(false);
+            entity.Property(d => d.LowConfidence).HasColumnName("low_confidence").IsRequired(false);
+            entity.Property(d => d.SessionId).HasColumnName("session_id").IsRequired(false);
+            entity.Property(d => d.DeletedAt).HasColumnName("deleted_at").IsRequired(false);
+            entity.Property(d => d.SpeakingWpm).HasColumnName("speaking_wpm");
+            entity.Property(d => d.EffectiveWpm).HasColumnName("effective_wpm");
+
+            // EF caches the model per context type, so the protector captured here is shared
+            // by every context instance in the process.
+            var protector = _protector;
+            if (protector != null)
+            {
+                entity.Property(d => d.TranscribedText)
+                    .HasConversion(v => protector.Protect(v), v => protector.Unprotect(v));
+                entity.Property(d => d.ErrorMessage)
+                    .HasConversion(v => protector.Protect(v!), v => protector.Unprotect(v!));
+                entity.Property(d => d.OriginalText)
+                    .HasConversion(v => protector.Protect(v!), v => protector.Unprotect(v!));
+                entity.Property(d => d.RawText)
+                    .HasConversion(v => protector.Protect(v!), v => protector.Unprotect(v!));
+                entity.Property(d => d.LowConfidence)
+                    .HasConversion(v => protector.Protect(v!), v => protector.Unprotect(v!));
+            }
 
             entity.HasIndex(d => d.Timestamp).HasDatabaseName("idx_dictations_timestamp");
             entity.HasIndex(d => d.Provider).HasDatabaseName("idx_dictations_provider");
             entity.HasIndex(d => d.Success).HasDatabaseName("idx_dictations_success");
+            entity.HasIndex(d => d.Starred).HasDatabaseName("idx_dictations_starred");
+            entity.HasIndex(d => d.SessionId).HasDatabaseName("idx_dictations_session_id");
+            entity.HasIndex(d => d.DeletedAt).HasDatabaseName("idx_dictations_deleted_at");
+
+            // Soft-deleted rows are invisible to every query unless IgnoreQueryFilters is used
+            entity.HasQueryFilter(d => d.DeletedAt == null);
+        });
+
+        modelBuilder.Entity<EvaluationResult>(entity =>
+        {
+            entity.ToTable("evaluation_results");
+            entity.HasKey(e => e.Id);
+            entity.Property(e => e.Id).HasColumnName("id").ValueGeneratedOnAdd();
+            entity.Property(e => e.RunId).HasColumnName("run_id");
+            entity.Property(e => e.Timestamp).HasColumnName("timestamp");
+            entity.Property(e => e.Sample).HasColumnName("sample");
+            entity.Property(e => e.Provider).HasColumnName("provider");
+            entity.Property(e => e.Model).HasColumnName("model");
+            entity.Property(e => e.Pipeline).HasColumnName("pipeline");
+            entity.Property(e => e.ReferenceText).HasColumnName("reference_text");
+            entity.Property(e => e.HypothesisText).HasColumnName("hypothesis_text");
+            entity.Property(e => e.ReferenceWords).HasColumnName("reference_words");
+            entity.Property(e => e.Substitutions).HasColumnName("substitutions");
+            entity.Property(e => e.Deletions).HasColumnName("deletions");
+            entity.Property(e => e.Insertions).HasColumnName("insertions");
+            entity.Property(e => e.Wer).HasColumnName("wer");
+            entity.Property(e => e.LatencyMs).HasColumnName("latency_ms");
+            entity.Property(e => e.ErrorMessage).HasColumnName("error_message").IsRequired(false);
+
+            entity.HasIndex(e => e.RunId).HasDatabaseName("idx_evaluation_results_run_id");
         });
     }
 
     public async Task InitializeAsync()
     {
-        await Database.ExecuteSqlRawAsync("PRAGMA journal_mode=WAL");
-        await Database.ExecuteSqlRawAsync("PRAGMA foreign_keys=ON");
-        await Database.EnsureCreatedAsync();
+        if (Database.IsSqlite())
+        {
+            await Database.ExecuteSqlRawAsync("PRAGMA journal_mode=WAL");
+            await Database.ExecuteSqlRawAsync("PRAGMA foreign_keys=ON");
+        }
+        var created = await Database.EnsureCreatedAsync();
+        await SchemaMigrator.MigrateAsync(this, created);
     }
 }
@@ -0,0 +1,66 @@
+namespace TokenTalk.Transcription;
+
+/// <summary>Thrown instead of calling a provider whose circuit is open.</summary>
+public class ProviderUnavailableException : Exception
+{
+    public string Provider { get; }
+    public DateTime RetryAt { get; }
+
+    public ProviderUnavailableException(string provider, DateTime retryAt)
+        : base($"{provider} is unavailable after repeated failures; trying again after {retryAt.ToLocalTime():HH:mm:ss}")
+    {
+        Provider = provider;
+        RetryAt = retryAt;
+    }
+}
+
+/// <summary>
+/// Counts consecutive failures of one provider. At the threshold the circuit opens: calls are
+/// refused until the cooldown has passed, after which the next call is let through as a trial.
+/// A trial that fails opens it again straight away; any success closes it.
+/// </summary>
+public class CircuitBreaker
+{
+    private readonly object _lock = new();
+    private int _failures;
+    private DateTime _openUntil = DateTime.MinValue;
+
+    public int ConsecutiveFailures
+    {
+        get { lock (_lock) return _failures; }
+    }
+
+    /// <summary>When the circuit lets calls through again; in the past while it is closed.</summary>
+    public DateTime OpenUntil
+    {
+        get { lock (_lock) return _openUntil; }
+    }
+
+    public bool IsOpen
+    {
+        get { lock (_lock) return DateTime.UtcNow < _openUntil; }
+    }
+
+    public void RecordSuccess()
+    {
+        lock (_lock)
+        {
+            _failures = 0;
+            _openUntil = DateTime.MinValue;
+        }
+    }
+
+    /// <summary>Returns true when this failure opened the circuit.</summary>
+    /// <param name="threshold">Consecutive failures that open it; 0 never opens.</param>
+    public bool RecordFailure(int threshold, TimeSpan cooldown)
+    {
+        lock (_lock)
+        {
+            _failures++;
+            if (threshold <= 0 || _failures < threshold)
+                return false;
+            _openUntil = DateTime.UtcNow + cooldown;
+            return true;
+        }
+    }
+}
@@ -0,0 +1,72 @@
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+
+namespace TokenTalk.Transcription;
+
+/// <summary>
+/// Keeps a connection to the OpenAI API open while OpenAI may be used (as provider, race
+/// partner or fallback), so a dictation after a long pause doesn't wait for DNS, TCP and TLS.
+/// Sends an unauthenticated HEAD every <c>Transcription.KeepWarmSeconds</c>; the 401 it gets
+/// back costs nothing and carries no key.
+/// </summary>
+public class ConnectionWarmer
+{
+    private const string WarmUpUrl = "https://api.openai.com/v1/models";
+    private static readonly TimeSpan IdleCheck = TimeSpan.FromSeconds(30);
+
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly Func<TranscriptionOptions> _getOptions;
+    private readonly HttpConnectionStats _stats;
+    private readonly ILogger<ConnectionWarmer> _logger;
+
+    public ConnectionWarmer(IHttpClientFactory httpClientFactory, Func<TranscriptionOptions> getOptions,
+        HttpConnectionStats stats, ILogger<ConnectionWarmer> logger)
+    {
+        _httpClientFactory = httpClientFactory;
+        _getOptions = getOptions;
+        _stats = stats;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        try
+        {
+            while (!ct.IsCancellationRequested)
+            {
+                var options = _getOptions();
+                // Re-read every half minute while off, so turning it on applies without a restart
+                if (options.KeepWarmSeconds <= 0 || !UsesOpenAi(options))
+                {
+                    await Task.Delay(IdleCheck, ct);
+                    continue;
+                }
+                await WarmUpAsync(ct);
+                await Task.Delay(TimeSpan.FromSeconds(options.KeepWarmSeconds), ct);
+            }
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    private static bool UsesOpenAi(TranscriptionOptions options) =>
+        options.Provider == "openai" || options.Fallback == "openai" || options.Race;
+
+    private async Task WarmUpAsync(CancellationToken ct)
+    {
+        try
+        {
+            var client = _httpClientFactory.CreateClient("OpenAI");
+            using var request = new HttpRequestMessage(HttpMethod.Head, WarmUpUrl);
+            using var response = await client.SendAsync(request, ct);
+            _stats.WarmedUp();
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            // Offline or asleep; the next dictation connects on its own
+            _logger.LogDebug(ex, "Connection warm-up failed");
+        }
+    }
+}
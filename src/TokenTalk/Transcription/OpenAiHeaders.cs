@@ -0,0 +1,31 @@
+using System.Net.Http.Headers;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Transcription;
+
+/// <summary>
+/// Headers for every OpenAI request: the bearer key, OpenAI-Organization and OpenAI-Project
+/// for keys scoped to one, and whatever <see cref="TranscriptionOptions.Headers"/> adds, such as
+/// the auth header of a gateway in front of the API. Extra headers are applied last, so they
+/// can replace any of the others.
+/// </summary>
+public static class OpenAiHeaders
+{
+    public static void Apply(HttpHeaders headers, string apiKey, TranscriptionOptions options)
+    {
+        Set(headers, "Authorization", $"Bearer {apiKey}");
+        if (!string.IsNullOrWhiteSpace(options.Organization))
+            Set(headers, "OpenAI-Organization", options.Organization.Trim());
+        if (!string.IsNullOrWhiteSpace(options.Project))
+            Set(headers, "OpenAI-Project", options.Project.Trim());
+        foreach (var (name, value) in options.Headers)
+            Set(headers, name, value);
+    }
+
+    // Names are checked by ConfigValidator; values go out as given
+    private static void Set(HttpHeaders headers, string name, string value)
+    {
+        headers.Remove(name);
+        headers.TryAddWithoutValidation(name, value);
+    }
+}
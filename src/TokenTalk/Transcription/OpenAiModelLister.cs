@@ -0,0 +1,49 @@
+using System.Text.Json;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Transcription;
+
+/// <summary>
+/// Lists the speech-to-text models an OpenAI API key can use, for the Settings model picker.
+/// </summary>
+public class OpenAiModelLister
+{
+    // Offered before the list has been fetched, or when it can't be
+    public static readonly IReadOnlyList<string> KnownModels =
+        ["whisper-1", "gpt-4o-transcribe", "gpt-4o-mini-transcribe"];
+
+    private readonly IHttpClientFactory _httpClientFactory;
+
+    public OpenAiModelLister(IHttpClientFactory httpClientFactory)
+    {
+        _httpClientFactory = httpClientFactory;
+    }
+
+    /// <param name="options">Organization, project and extra headers to send with the key.</param>
+    public async Task<List<string>> ListTranscriptionModelsAsync(string apiKey, TranscriptionOptions options, CancellationToken ct = default)
+    {
+        var client = _httpClientFactory.CreateClient("OpenAI");
+        using var request = new HttpRequestMessage(HttpMethod.Get, "https://api.openai.com/v1/models");
+        OpenAiHeaders.Apply(request.Headers, apiKey, options);
+        using var response = await client.SendAsync(request, ct);
+
+        if (!response.IsSuccessStatusCode)
+        {
+            var errorBody = await response.Content.ReadAsStringAsync(ct);
+            throw new HttpRequestException(
+                $"OpenAI models error ({response.StatusCode}): {errorBody}", null, response.StatusCode);
+        }
+
+        using var doc = JsonDocument.Parse(await response.Content.ReadAsStringAsync(ct));
+        return doc.RootElement.GetProperty("data")
+            .EnumerateArray()
+            .Select(m => m.GetProperty("id").GetString() ?? "")
+            .Where(IsTranscriptionModel)
+            .Order(StringComparer.Ordinal)
+            .ToList();
+    }
+
+    // The endpoint returns every model; only whisper and *-transcribe accept audio/transcriptions
+    private static bool IsTranscriptionModel(string id) =>
+        id.StartsWith("whisper", StringComparison.Ordinal) || id.Contains("transcribe", StringComparison.Ordinal);
+}
@@ -1,6 +1,8 @@
+using System.Globalization;
 using System.Net.Http.Headers;
 using System.Text.Json;
 using TokenTalk.Audio;
+using TokenTalk.Configuration;
 
 namespace TokenTalk.Transcription;
 
@@ -8,6 +10,7 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
 {
     private readonly IHttpClientFactory _httpClientFactory;
     private readonly Func<string> _getApiKey;
+    private readonly Func<TranscriptionOptions> _getOptions;
     private readonly Func<string> _getModel;
     private readonly Func<string> _getLanguage;
     private readonly Func<string> _getPrompt;
@@ -18,6 +21,7 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
     public OpenAiWhisperProvider(
         IHttpClientFactory httpClientFactory,
         Func<string> getApiKey,
+        Func<TranscriptionOptions> getOptions,
         Func<string> getModel,
         Func<string> getLanguage,
         Func<string> getPrompt,
@@ -25,6 +29,7 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
     {
         _httpClientFactory = httpClientFactory;
         _getApiKey = getApiKey;
+        _getOptions = getOptions;
         _getModel = getModel;
         _getLanguage = getLanguage;
         _getPrompt = getPrompt;
@@ -39,8 +44,7 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
         var prompt = _getPrompt();
 
         var httpClient = _httpClientFactory.CreateClient("OpenAI");
-        httpClient.DefaultRequestHeaders.Authorization =
-            new AuthenticationHeaderValue("Bearer", apiKey);
+        OpenAiHeaders.Apply(httpClient.DefaultRequestHeaders, apiKey, _getOptions());
 
         using var content = new MultipartFormDataContent();
 
@@ -52,9 +56,17 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
         // Add model
         content.Add(new StringContent(model), "model");
 
+        // The gpt-4o transcribe models can return token log probabilities; whisper-1 can't
+        if (model.StartsWith("gpt-4o", StringComparison.Ordinal))
+            content.Add(new StringContent("logprobs"), "include[]");
+
         // Add language ("auto" or empty = omit parameter, Whisper auto-detects)
-        if (!string.IsNullOrEmpty(language) && language != "auto")
+        var detect = string.IsNullOrEmpty(language) || language == "auto";
+        if (!detect)
             content.Add(new StringContent(language), "language");
+        // Only whisper-1 reports the language it detected, and only in the verbose format
+        else if (model == "whisper-1")
+            content.Add(new StringContent("verbose_json"), "response_format");
 
         // Build prompt: user prompt + dictionary simple terms
         var promptParts = new List<string>();
@@ -74,11 +86,32 @@ public class OpenAiWhisperProvider : ITranscriptionProvider
         {
             var errorBody = await response.Content.ReadAsStringAsync(ct);
             throw new HttpRequestException(
-                $"Whisper API error ({response.StatusCode}): {errorBody}");
+                $"Whisper API error ({response.StatusCode}): {errorBody}", null, response.StatusCode);
         }
 
         var json = await response.Content.ReadAsStringAsync(ct);
         using var doc = JsonDocument.Parse(json);
-        return doc.RootElement.GetProperty("text").GetString() ?? string.Empty;
+        var text = doc.RootElement.GetProperty("text").GetString() ?? string.Empty;
+        if (detect && doc.RootElement.TryGetProperty("language", out var detected)
+            && LanguageCode(detected.GetString()) is { } code)
+            TranscriptionContext.ReportLanguage(code);
+        if (text.Length > 0 && doc.RootElement.TryGetProperty("logprobs", out var logprobs)
+            && logprobs.ValueKind == JsonValueKind.Array)
+        {
+            TranscriptionContext.ReportWords(TranscriptionContext.MergeTokens(logprobs.EnumerateArray()
+                .Select(t => (t.GetProperty("token").GetString() ?? "", Math.Exp(t.GetProperty("logprob").GetDouble())))));
+        }
+        return text;
+    }
+    // verbose_json names the language in English ("swedish"); history and the pipeline use codes
+    private static string? LanguageCode(string? name)
+    {
+        if (string.IsNullOrEmpty(name))
+            return null;
+        if (name.Length == 2)
+            return name;
+        return CultureInfo.GetCultures(CultureTypes.NeutralCultures)
+            .FirstOrDefault(c => string.Equals(c.EnglishName, name, StringComparison.OrdinalIgnoreCase))
+            ?.TwoLetterISOLanguageName;
     }
 }
@@ -0,0 +1,120 @@
+using System.Runtime.CompilerServices;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Transcription;
+
+/// <summary>How sure the provider was of one word, from 0 to 1.</summary>
+public record WordConfidence(string Word, double Confidence);
+
+/// <summary>
+/// Per-dictation settings that flow with the async call into the providers, so concurrent
+/// dictations (parallel queue policy) can each use their own.
+/// </summary>
+public static class TranscriptionContext
+{
+    private static readonly AsyncLocal<string?> _language = new();
+    private static readonly AsyncLocal<TranscriptionOptions?> _options = new();
+    private static readonly AsyncLocal<List<WordConfidence>?> _words = new();
+    private static readonly AsyncLocal<StrongBox<string?>?> _detectedLanguage = new();
+    private static readonly AsyncLocal<bool> _incognito = new();
+    private static readonly char[] Punctuation = ['.', ',', '!', '?', ';', ':', '"', '(', ')'];
+
+    // Overrides Transcription.Language for the current dictation; null uses the config
+    public static string? Language
+    {
+        get => _language.Value;
+        set => _language.Value = value;
+    }
+
+    // Replaces the Transcription section for the current dictation, for a hotkey bound to a
+    // profile other than the active one; null uses the config
+    public static TranscriptionOptions? Options
+    {
+        get => _options.Value;
+        set => _options.Value = value;
+    }
+
+    // The current dictation is incognito: request tracing leaves out what was said
+    public static bool Incognito
+    {
+        get => _incognito.Value;
+        set => _incognito.Value = value;
+    }
+
+    /// <summary>
+    /// Starts collecting word confidences for the current dictation. The returned list is filled
+    /// by providers that report them and stays empty otherwise.
+    /// </summary>
+    public static List<WordConfidence> CollectWords()
+    {
+        var words = new List<WordConfidence>();
+        _words.Value = words;
+        return words;
+    }
+
+    /// <summary>
+    /// Called by providers with the confidences behind a non-empty result. The first report wins,
+    /// so in race mode the slower provider can't replace the winner's.
+    /// </summary>
+    public static void ReportWords(IEnumerable<WordConfidence> words)
+    {
+        var target = _words.Value;
+        if (target == null)
+            return;
+        lock (target)
+        {
+            if (target.Count == 0)
+                target.AddRange(words);
+        }
+    }
+
+    /// <summary>
+    /// Starts listening for the language the provider detected in the current dictation. The box
+    /// stays empty when the language was fixed in config or the provider doesn't say.
+    /// </summary>
+    public static StrongBox<string?> CollectLanguage()
+    {
+        var box = new StrongBox<string?>();
+        _detectedLanguage.Value = box;
+        return box;
+    }
+
+    /// <summary>Called by providers with the ISO 639-1 code of the language they heard; the first report wins.</summary>
+    public static void ReportLanguage(string language)
+    {
+        var box = _detectedLanguage.Value;
+        if (box == null || string.IsNullOrEmpty(language))
+            return;
+        Interlocked.CompareExchange(ref box.Value, language.ToLowerInvariant(), null);
+    }
+
+    /// <summary>
+    /// Joins sub-word tokens into words: a token starting with a space begins a new word, and a
+    /// word is as uncertain as its least certain token. Tokens without letters or digits (punctuation,
+    /// special markers) are ignored.
+    /// </summary>
+    public static List<WordConfidence> MergeTokens(IEnumerable<(string Text, double Probability)> tokens)
+    {
+        var words = new List<WordConfidence>();
+        var current = "";
+        var confidence = 1.0;
+        foreach (var (text, probability) in tokens)
+        {
+            if (text.StartsWith("[_", StringComparison.Ordinal) || text.StartsWith("<|", StringComparison.Ordinal))
+                continue;
+            if (text.Length > 0 && char.IsWhiteSpace(text[0]) && current.Length > 0)
+            {
+                words.Add(new WordConfidence(current, confidence));
+                current = "";
+                confidence = 1.0;
+            }
+            if (!text.Any(char.IsLetterOrDigit))
+                continue;
+            current += text.Trim().Trim(Punctuation);
+            confidence = Math.Min(confidence, probability);
+        }
+        if (current.Length > 0)
+            words.Add(new WordConfidence(current, confidence));
+        return words;
+    }
+}
@@ -1,36 +1,158 @@
+using System.Diagnostics;
+using Microsoft.Extensions.Logging;
 using TokenTalk.Audio;
+using TokenTalk.Configuration;
 
 namespace TokenTalk.Transcription;
 
 /// <summary>
 /// Delegates to OpenAI or whisper.cpp provider based on current config,
-/// enabling hot-switching at runtime without restart.
+/// enabling hot-switching at runtime without restart. In race mode both
+/// run at once and the first successful result wins. Otherwise each provider
+/// sits behind a <see cref="CircuitBreaker"/>: while the configured one is down,
+/// dictations go to <c>Transcription.Fallback</c> or fail immediately.
 /// </summary>
 public sealed class TranscriptionProviderFactory : ITranscriptionProvider, IDisposable
 {
-    private readonly Func<string> _getProvider;
+    public const string RaceName = "race";
+
+    private readonly Func<TranscriptionOptions> _getOptions;
     private readonly ITranscriptionProvider _openAiProvider;
     private readonly ITranscriptionProvider _whisperCppProvider;
+    private readonly CircuitBreaker _openAiCircuit = new();
+    private readonly CircuitBreaker _whisperCppCircuit = new();
+    private readonly ILogger _logger;
+
+    /// <summary>Raised with the provider's name and the reason when its circuit opens.</summary>
+    public event EventHandler<string>? CircuitOpened;
 
-    public string Name => Current.Name;
+    // Read just before each call, so it names the fallback while the configured provider is down
+    public string Name => _getOptions().Race ? RaceName : Route(_getOptions()).Name;
 
     public TranscriptionProviderFactory(
-        Func<string> getProvider,
+        Func<TranscriptionOptions> getOptions,
         ITranscriptionProvider openAiProvider,
-        ITranscriptionProvider whisperCppProvider)
+        ITranscriptionProvider whisperCppProvider,
+        ILogger<TranscriptionProviderFactory> logger)
     {
-        _getProvider = getProvider;
+        _getOptions = getOptions;
         _openAiProvider = openAiProvider;
         _whisperCppProvider = whisperCppProvider;
+        _logger = logger;
     }
 
-    private ITranscriptionProvider Current =>
-        _getProvider().Equals("whisper.cpp", StringComparison.OrdinalIgnoreCase)
+    private ITranscriptionProvider Select(string name) =>
+        name.Equals("whisper.cpp", StringComparison.OrdinalIgnoreCase)
             ? _whisperCppProvider
             : _openAiProvider;
 
+    private CircuitBreaker CircuitOf(ITranscriptionProvider provider) =>
+        provider == _whisperCppProvider ? _whisperCppCircuit : _openAiCircuit;
+
+    // The configured provider, or the fallback while the configured one's circuit is open
+    private ITranscriptionProvider Route(TranscriptionOptions options)
+    {
+        var primary = Select(options.Provider);
+        if (!CircuitOf(primary).IsOpen || string.IsNullOrEmpty(options.Fallback))
+            return primary;
+        var fallback = Select(options.Fallback);
+        return fallback == primary ? primary : fallback;
+    }
+
     public Task<string> TranscribeAsync(AudioSegment audio, CancellationToken ct = default)
-        => Current.TranscribeAsync(audio, ct);
+    {
+        var options = _getOptions();
+        return options.Race ? RaceAsync(audio, ct) : GuardedAsync(Route(options), options, audio, ct);
+    }
+
+    private async Task<string> GuardedAsync(
+        ITranscriptionProvider provider, TranscriptionOptions options, AudioSegment audio, CancellationToken ct)
+    {
+        var circuit = CircuitOf(provider);
+        if (circuit.IsOpen)
+            throw new ProviderUnavailableException(provider.Name, circuit.OpenUntil);
+        if (provider != Select(options.Provider))
+            _logger.LogInformation("{Provider} is down, using fallback {Fallback}", options.Provider, provider.Name);
+
+        try
+        {
+            var text = await provider.TranscribeAsync(audio, ct);
+            if (circuit.ConsecutiveFailures > 0)
+                _logger.LogInformation("{Provider} is answering again", provider.Name);
+            circuit.RecordSuccess();
+            return text;
+        }
+        // Cancellation (shutdown, the agent's watchdog) says nothing about the provider's health
+        catch (Exception ex) when (!ct.IsCancellationRequested)
+        {
+            if (circuit.RecordFailure(options.CircuitFailures, TimeSpan.FromSeconds(options.CircuitCooldownSeconds)))
+            {
+                var reason = $"{circuit.ConsecutiveFailures} failures in a row, last: {ex.Message}";
+                _logger.LogWarning("Circuit for {Provider} opened for {Cooldown}s after {Reason}",
+                    provider.Name, options.CircuitCooldownSeconds, reason);
+                CircuitOpened?.Invoke(this, $"{provider.Name}: {reason}");
+            }
+            throw;
+        }
+    }
+
+    // The slower provider is left to finish so its latency and text still reach the log
+    private async Task<string> RaceAsync(AudioSegment audio, CancellationToken ct)
+    {
+        List<Task<RaceResult>> pending = [RunAsync(_whisperCppProvider, audio, ct), RunAsync(_openAiProvider, audio, ct)];
+        Exception? firstError = null;
+        var anyEmpty = false;
+
+        while (pending.Count > 0)
+        {
+            var finished = await Task.WhenAny(pending);
+            pending.Remove(finished);
+            var result = await finished;
+            if (result.Error != null)
+            {
+                firstError ??= result.Error;
+                continue;
+            }
+            if (string.IsNullOrWhiteSpace(result.Text))
+            {
+                anyEmpty = true;
+                continue;
+            }
+
+            _logger.LogInformation("Race won by {Provider} in {Elapsed}ms", result.Provider, result.ElapsedMs);
+            return result.Text;
+        }
+
+        ct.ThrowIfCancellationRequested();
+        // Silence heard by a provider that worked beats an error from the other
+        if (anyEmpty || firstError == null)
+            return "";
+        throw firstError;
+    }
+
+    private async Task<RaceResult> RunAsync(ITranscriptionProvider provider, AudioSegment audio, CancellationToken ct)
+    {
+        var start = Stopwatch.GetTimestamp();
+        try
+        {
+            var text = await provider.TranscribeAsync(audio, ct);
+            var elapsed = (long)Stopwatch.GetElapsedTime(start).TotalMilliseconds;
+            if (TranscriptionContext.Incognito)
+                _logger.LogInformation("Race: {Provider} finished in {Elapsed}ms", provider.Name, elapsed);
+            else
+                _logger.LogInformation("Race: {Provider} finished in {Elapsed}ms: {Text}", provider.Name, elapsed, text);
+            return new RaceResult(provider.Name, text, null, elapsed);
+        }
+        catch (Exception ex)
+        {
+            var elapsed = (long)Stopwatch.GetElapsedTime(start).TotalMilliseconds;
+            if (!ct.IsCancellationRequested)
+                _logger.LogWarning(ex, "Race: {Provider} failed after {Elapsed}ms", provider.Name, elapsed);
+            return new RaceResult(provider.Name, null, ex, elapsed);
+        }
+    }
+
+    private sealed record RaceResult(string Provider, string? Text, Exception? Error, long ElapsedMs);
 
     public void Dispose()
     {
@@ -7,16 +7,20 @@ public sealed class WhisperCppProvider : ITranscriptionProvider, IDisposable
 {
     private readonly Func<string> _getModelPath;
     private readonly Func<string> _getLanguage;
+    private readonly IEnumerable<string> _dictionaryTerms;
     private readonly SemaphoreSlim _semaphore = new(1, 1);
     private WhisperFactory? _factory;
     private string _loadedModelPath = "";
 
     public string Name => "whisper.cpp";
 
-    public WhisperCppProvider(Func<string> getModelPath, Func<string> getLanguage)
+    /// <param name="dictionaryTerms">Custom vocabulary, passed as the initial prompt so the
+    /// decoder favours these spellings.</param>
+    public WhisperCppProvider(Func<string> getModelPath, Func<string> getLanguage, IEnumerable<string> dictionaryTerms)
     {
         _getModelPath = getModelPath;
         _getLanguage = getLanguage;
+        _dictionaryTerms = dictionaryTerms;
     }
 
     public async Task<string> TranscribeAsync(AudioSegment audio, CancellationToken ct = default)
@@ -44,17 +48,30 @@ public sealed class WhisperCppProvider : ITranscriptionProvider, IDisposable
 
             var language = _getLanguage();
             var builder = _factory.CreateBuilder();
-            if (!string.IsNullOrEmpty(language) && language != "auto")
-                builder = builder.WithLanguage(language);
+            var detect = string.IsNullOrEmpty(language) || language == "auto";
+            builder = detect ? builder.WithLanguageDetection() : builder.WithLanguage(language);
+            var terms = string.Join(", ", _dictionaryTerms);
+            if (terms.Length > 0)
+                builder = builder.WithPrompt(terms);
 
             using var processor = builder.Build();
             using var stream = new MemoryStream(audio.WavData);
 
             var sb = new System.Text.StringBuilder();
+            var tokens = new List<(string, double)>();
             await foreach (var segment in processor.ProcessAsync(stream, ct))
+            {
                 sb.Append(segment.Text);
+                if (detect)
+                    TranscriptionContext.ReportLanguage(segment.Language);
+                foreach (var token in segment.Tokens ?? [])
+                    tokens.Add((token.Text ?? "", token.Probability));
+            }
 
-            return sb.ToString().Trim();
+            var text = sb.ToString().Trim();
+            if (text.Length > 0)
+                TranscriptionContext.ReportWords(TranscriptionContext.MergeTokens(tokens));
+            return text;
         }
         finally
         {
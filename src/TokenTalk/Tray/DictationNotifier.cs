@@ -0,0 +1,55 @@
+using System.Windows.Forms;
+using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+using TokenTalk.Notifications;
+using TokenTalk.Storage;
+
+namespace TokenTalk.Tray;
+
+/// <summary>
+/// Turns dictation and health events into tray notifications, which Windows 10+ shows as native
+/// toasts. Each kind can be switched off in the Notifications config section.
+/// </summary>
+public class DictationNotifier : IDictationNotifier
+{
+    private const int PreviewLength = 120;
+
+    private readonly TrayIconManager _tray;
+    private readonly Func<NotificationOptions> _getOptions;
+
+    public DictationNotifier(TrayIconManager tray, Func<NotificationOptions> getOptions)
+    {
+        _tray = tray;
+        _getOptions = getOptions;
+    }
+
+    public string Name => NotifierNames.Toast;
+
+    public void OnRecordingStart()
+    {
+    }
+
+    public void OnTranscription(Dictation dictation)
+    {
+        if (!_getOptions().OnTranscription)
+            return;
+        var text = dictation.TranscribedText.ReplaceLineEndings(" ");
+        _tray.ShowNotification("Dictation pasted",
+            text.Length > PreviewLength ? text[..PreviewLength].TrimEnd() + "…" : text);
+    }
+
+    public void OnError(string message, Dictation? dictation)
+    {
+        // An empty transcript or one with no speech is usually just a silent press and not worth interrupting for
+        if (!_getOptions().OnError || dictation?.ErrorCategory is ErrorCategories.EmptyTranscription or ErrorCategories.NoSpeech)
+            return;
+        _tray.ShowNotification("Dictation failed", message, ToolTipIcon.Error);
+    }
+
+    public void OnHealthChanged(HealthReport report)
+    {
+        if (report.Level == HealthLevel.Healthy || !_getOptions().OnHealthProblem)
+            return;
+        _tray.ShowNotification("TokenTalk health", report.Summary, ToolTipIcon.Warning);
+    }
+}
@@ -1,22 +1,68 @@
 using System.Reflection;
 using System.Windows.Forms;
 using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
 using TokenTalk.Overlay;
+using TokenTalk.Platform;
+using TokenTalk.Storage;
+using TokenTalk.Updates;
 
 namespace TokenTalk.Tray;
 
 public class TrayIconManager : IDisposable
 {
+    private const string DefaultTooltip = "TokenTalk - Voice Dictation";
+    // NotifyIcon.Text throws above this length
+    private const int MaxTooltipLength = 127;
+    private const int RecentCount = 5;
+    private const int RecentLabelLength = 50;
+    // Also catches the day rolling over while idle
+    private static readonly TimeSpan TodayRefreshInterval = TimeSpan.FromMinutes(15);
+    // Offered in the Language submenu alongside whatever is currently configured
+    private static readonly string[] QuickLanguages = ["auto", "en", "sv"];
+
     private NotifyIcon? _notifyIcon;
+    private System.Drawing.Icon? _icon;
+    private System.Drawing.Icon? _pausedIcon;
+    private volatile bool _paused;
+    private volatile bool _continuous;
+    private volatile bool _incognito;
+    private volatile bool _grammar;
+    // Refreshed from storage off the UI thread; the submenu is rebuilt from it when opened
+    private volatile IReadOnlyList<string> _recent = [];
+    private volatile UpdateInfo? _update;
+    private volatile string? _healthProblem;
+    private volatile string _today = "";
     private readonly CancellationTokenSource _cts;
     private readonly Action _openWindowCallback;
+    private readonly ConfigManager _configManager;
+    private readonly DictationRepository _repository;
+    private readonly ClipboardService _clipboard;
     private readonly ILogger<TrayIconManager> _logger;
 
-    public TrayIconManager(CancellationTokenSource cts, Action openWindowCallback, ILogger<TrayIconManager> logger)
+    // Raised with the requested state when the user clicks Pause/Resume Dictation
+    public event EventHandler<bool>? PauseToggled;
+    // Raised with the requested state when the user clicks Continuous Dictation
+    public event EventHandler<bool>? ContinuousToggled;
+    // Raised with the requested state when the user clicks Incognito
+    public event EventHandler<bool>? IncognitoToggled;
+    public event EventHandler? UndoRequested;
+
+    public TrayIconManager(
+        CancellationTokenSource cts,
+        Action openWindowCallback,
+        ConfigManager configManager,
+        DictationRepository repository,
+        ClipboardService clipboard,
+        ILogger<TrayIconManager> logger)
     {
         _cts = cts;
         _openWindowCallback = openWindowCallback;
+        _configManager = configManager;
+        _repository = repository;
+        _clipboard = clipboard;
         _logger = logger;
+        _grammar = configManager.Current.PostProcessing.GrammarProvider != GrammarProviders.None;
     }
 
     public void Run(DictationOverlay? overlay = null)
@@ -27,15 +73,19 @@ public class TrayIconManager : IDisposable
 
         overlay?.Initialize();
 
+        _icon = LoadIcon();
+        _pausedIcon = CreatePausedIcon(_icon);
         _notifyIcon = new NotifyIcon
         {
-            Text = "TokenTalk - Voice Dictation",
             Visible = true,
-            Icon = LoadIcon(),
+            Icon = _paused ? _pausedIcon : _icon,
         };
+        UpdateTooltip();
 
         var menu = new ContextMenuStrip();
 
+        var todayItem = new ToolStripMenuItem { Enabled = false };
+
         var openItem = new ToolStripMenuItem("Open TokenTalk");
         openItem.Click += (_, _) =>
         {
@@ -43,6 +93,65 @@ public class TrayIconManager : IDisposable
             catch (Exception ex) { _logger.LogError(ex, "Failed to open window"); }
         };
 
+        var recentItem = new ToolStripMenuItem("Recent Dictations");
+        // Placeholder so the submenu arrow shows before the first refresh
+        recentItem.DropDownItems.Add(new ToolStripMenuItem("(none)") { Enabled = false });
+        recentItem.DropDownOpening += (_, _) => BuildRecentMenu(recentItem);
+
+        var profileItem = new ToolStripMenuItem("Profile");
+        profileItem.DropDownItems.Add(new ToolStripMenuItem("(none)") { Enabled = false });
+        profileItem.DropDownOpening += (_, _) => BuildProfileMenu(profileItem);
+
+        var languageItem = new ToolStripMenuItem("Language");
+        languageItem.DropDownItems.Add(new ToolStripMenuItem("auto"));
+        languageItem.DropDownOpening += (_, _) => BuildLanguageMenu(languageItem);
+
+        var commandsItem = new ToolStripMenuItem("Voice Commands");
+        commandsItem.Click += (_, _) => UpdateConfig(cfg => cfg.PostProcessing.Commands = !cfg.PostProcessing.Commands);
+
+        var undoItem = new ToolStripMenuItem("Undo Last Dictation");
+        undoItem.Click += (_, _) => UndoRequested?.Invoke(this, EventArgs.Empty);
+
+        var continuousItem = new ToolStripMenuItem("Continuous Dictation");
+        continuousItem.Click += (_, _) => ContinuousToggled?.Invoke(this, !_continuous);
+
+        var incognitoItem = new ToolStripMenuItem("Incognito (don't save)");
+        incognitoItem.Click += (_, _) => IncognitoToggled?.Invoke(this, !_incognito);
+
+        var pauseItem = new ToolStripMenuItem("Pause Dictation");
+        pauseItem.Click += (_, _) => PauseToggled?.Invoke(this, !_paused);
+
+        var autostartItem = new ToolStripMenuItem("Start with Windows");
+        autostartItem.Click += (_, _) =>
+        {
+            try { AutostartManager.SetEnabled(!AutostartManager.IsEnabled); }
+            catch (Exception ex) { _logger.LogError(ex, "Failed to change autostart"); }
+        };
+
+        var updateItem = new ToolStripMenuItem { Visible = false };
+        updateItem.Click += (_, _) =>
+        {
+            if (_update != null)
+                OpenUrl(_update.Url);
+        };
+
+        // Pause and autostart can change from the control pipe, so refresh them when the menu opens
+        menu.Opening += (_, _) =>
+        {
+            todayItem.Text = _today.Length > 0 ? _today : "Today: no dictations yet";
+            pauseItem.Text = _paused ? "Resume Dictation" : "Pause Dictation";
+            continuousItem.Checked = _continuous;
+            incognitoItem.Checked = _incognito;
+            var cfg = _configManager.Current;
+            profileItem.Visible = cfg.Profiles.Count > 0;
+            profileItem.Text = cfg.ActiveProfile.Length > 0 ? $"Profile: {cfg.ActiveProfile}" : "Profile";
+            languageItem.Text = $"Language: {cfg.Transcription.Language}";
+            commandsItem.Checked = cfg.PostProcessing.Commands;
+            autostartItem.Checked = AutostartManager.IsEnabled;
+            updateItem.Visible = _update != null;
+            updateItem.Text = $"Download TokenTalk {_update?.Version}…";
+        };
+
         var separator = new ToolStripSeparator();
 
         var quitItem = new ToolStripMenuItem("Quit");
@@ -52,7 +161,20 @@ public class TrayIconManager : IDisposable
             Application.ExitThread();
         };
 
+        menu.Items.Add(todayItem);
+        menu.Items.Add(new ToolStripSeparator());
         menu.Items.Add(openItem);
+        menu.Items.Add(recentItem);
+        menu.Items.Add(undoItem);
+        menu.Items.Add(new ToolStripSeparator());
+        menu.Items.Add(profileItem);
+        menu.Items.Add(languageItem);
+        menu.Items.Add(commandsItem);
+        menu.Items.Add(continuousItem);
+        menu.Items.Add(incognitoItem);
+        menu.Items.Add(pauseItem);
+        menu.Items.Add(autostartItem);
+        menu.Items.Add(updateItem);
         menu.Items.Add(separator);
         menu.Items.Add(quitItem);
         _notifyIcon.ContextMenuStrip = menu;
@@ -61,6 +183,218 @@ public class TrayIconManager : IDisposable
         Application.Run();
     }
 
+    public void ShowNotification(string title, string text, ToolTipIcon icon = ToolTipIcon.Info)
+    {
+        if (_notifyIcon == null) return;
+        try
+        {
+            _notifyIcon.ShowBalloonTip(5000, title, text, icon);
+        }
+        catch (Exception ex)
+        {
+            _logger.LogWarning(ex, "Failed to show tray notification");
+        }
+    }
+
+    /// <summary>Shows the health summary in the tooltip while something is wrong.</summary>
+    public void SetHealthStatus(string? problem)
+    {
+        _healthProblem = problem;
+        UpdateTooltip();
+    }
+
+    /// <summary>Refreshes today's totals every few minutes until cancelled.</summary>
+    public async Task RunAsync(CancellationToken ct)
+    {
+        using var timer = new PeriodicTimer(TodayRefreshInterval);
+        try
+        {
+            while (await timer.WaitForNextTickAsync(ct))
+                await RefreshTodayAsync(ct);
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    /// <summary>Reloads the recent dictations submenu and today's totals.</summary>
+    public async Task RefreshAsync(CancellationToken ct = default)
+    {
+        await RefreshRecentAsync(ct);
+        await RefreshTodayAsync(ct);
+    }
+
+    private async Task RefreshTodayAsync(CancellationToken ct)
+    {
+        try
+        {
+            // Stats are grouped by UTC day, so sum everything since local midnight
+            var days = await _repository.GetDailyStatsAsync(DateTime.Today.ToUniversalTime(), null, ct);
+            var count = days.Sum(d => d.SuccessCount);
+            var words = days.Sum(d => d.TotalWords);
+            _today = count == 0 ? "" : $"Today: {count:N0} dictations, {words:N0} words";
+            UpdateTooltip();
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogWarning(ex, "Failed to load today's stats for the tray");
+        }
+    }
+
+    private void UpdateTooltip()
+    {
+        if (_notifyIcon == null) return;
+        var text = _healthProblem == null ? DefaultTooltip : $"TokenTalk - {_healthProblem}";
+        if (_grammar)
+            text += "\nGrammar correction on";
+        if (_today.Length > 0)
+            text += "\n" + _today;
+        _notifyIcon.Text = text.Length > MaxTooltipLength ? text[..MaxTooltipLength] : text;
+    }
+
+    private async Task RefreshRecentAsync(CancellationToken ct)
+    {
+        try
+        {
+            var (items, _) = await _repository.GetHistoryAsync(RecentCount, 0, new HistoryFilter { Success = true }, ct);
+            _recent = items.Select(d => d.TranscribedText).ToList();
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException)
+        {
+            _logger.LogWarning(ex, "Failed to load recent dictations for the tray");
+        }
+    }
+
+    private void BuildProfileMenu(ToolStripMenuItem parent)
+    {
+        parent.DropDownItems.Clear();
+        var cfg = _configManager.Current;
+        foreach (var profile in cfg.Profiles)
+        {
+            var name = profile.Name;
+            var item = new ToolStripMenuItem(name) { Checked = name == cfg.ActiveProfile };
+            item.Click += (_, _) =>
+            {
+                try { _configManager.SwitchProfile(name); }
+                catch (Exception ex) { _logger.LogError(ex, "Failed to switch profile"); }
+            };
+            parent.DropDownItems.Add(item);
+        }
+    }
+
+    private void BuildLanguageMenu(ToolStripMenuItem parent)
+    {
+        parent.DropDownItems.Clear();
+        var current = _configManager.Current.Transcription.Language;
+        foreach (var language in QuickLanguages.Append(current).Distinct(StringComparer.OrdinalIgnoreCase))
+        {
+            var item = new ToolStripMenuItem(language)
+            {
+                Checked = string.Equals(language, current, StringComparison.OrdinalIgnoreCase),
+            };
+            item.Click += (_, _) => UpdateConfig(cfg => cfg.Transcription.Language = language);
+            parent.DropDownItems.Add(item);
+        }
+    }
+
+    // Same path as saving from the settings page, so the change is live and persisted
+    private void UpdateConfig(Action<TokenTalkOptions> change)
+    {
+        try
+        {
+            var cfg = _configManager.Snapshot();
+            change(cfg);
+            _configManager.Save(cfg);
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Failed to save config from the tray");
+        }
+    }
+
+    private void BuildRecentMenu(ToolStripMenuItem parent)
+    {
+        parent.DropDownItems.Clear();
+        var recent = _recent;
+        if (recent.Count == 0)
+        {
+            parent.DropDownItems.Add(new ToolStripMenuItem("(none)") { Enabled = false });
+            return;
+        }
+
+        foreach (var text in recent)
+        {
+            var item = new ToolStripMenuItem(Truncate(text)) { ToolTipText = "Click to copy" };
+            item.Click += (_, _) =>
+            {
+                if (!_clipboard.SetText(text))
+                    _logger.LogWarning("Failed to copy dictation to the clipboard");
+            };
+            parent.DropDownItems.Add(item);
+        }
+    }
+
+    private static string Truncate(string text)
+    {
+        // Menu labels are single-line, and '&' would be read as a mnemonic
+        var line = text.ReplaceLineEndings(" ").Replace("&", "&&");
+        return line.Length > RecentLabelLength ? line[..RecentLabelLength].TrimEnd() + "…" : line;
+    }
+
+    /// <summary>Offers the release page in the menu and announces the new version once.</summary>
+    public void SetUpdateAvailable(UpdateInfo update)
+    {
+        _update = update;
+        ShowNotification("Update available", $"TokenTalk {update.Version} is available. Open the tray menu to download it.");
+    }
+
+    private void OpenUrl(string url)
+    {
+        try
+        {
+            System.Diagnostics.Process.Start(new System.Diagnostics.ProcessStartInfo(url) { UseShellExecute = true });
+        }
+        catch (Exception ex)
+        {
+            _logger.LogError(ex, "Failed to open {Url}", url);
+        }
+    }
+
+    /// <summary>Shows the greyed-out icon while dictation is paused.</summary>
+    public void SetPaused(bool paused)
+    {
+        _paused = paused;
+        if (_notifyIcon == null) return;
+        _notifyIcon.Icon = paused ? _pausedIcon : _icon;
+    }
+
+    public void SetContinuous(bool continuous) => _continuous = continuous;
+
+    public void SetIncognito(bool incognito) => _incognito = incognito;
+
+    /// <summary>Notes in the tooltip whether grammar correction runs, so a toggle can be checked at a glance.</summary>
+    public void SetGrammar(bool enabled)
+    {
+        _grammar = enabled;
+        UpdateTooltip();
+    }
+
+    private static System.Drawing.Icon CreatePausedIcon(System.Drawing.Icon icon)
+    {
+        using var bitmap = icon.ToBitmap();
+        using var disabled = ToolStripRenderer.CreateDisabledImage(bitmap);
+        using var copy = new System.Drawing.Bitmap(disabled);
+        var handle = copy.GetHicon();
+        try
+        {
+            return (System.Drawing.Icon)System.Drawing.Icon.FromHandle(handle).Clone();
+        }
+        finally
+        {
+            NativeMethods.DestroyIcon(handle);
+        }
+    }
+
     private static System.Drawing.Icon LoadIcon()
     {
         try
@@ -83,5 +417,7 @@ public class TrayIconManager : IDisposable
             _notifyIcon.Dispose();
             _notifyIcon = null;
         }
+        _pausedIcon?.Dispose();
+        _icon?.Dispose();
     }
 }
@@ -0,0 +1,81 @@
+using System.Windows;
+using System.Windows.Input;
+using System.Windows.Threading;
+
+namespace TokenTalk.UI;
+
+/// <summary>
+/// Shows a processed transcript before it is pasted: Enter (or Paste) accepts it, Edit makes
+/// it editable first, Esc (or Discard, or closing the window) drops it.
+/// </summary>
+public partial class ConfirmWindow : Window
+{
+    private readonly TaskCompletionSource<string?> _result = new(TaskCreationOptions.RunContinuationsAsynchronously);
+
+    public ConfirmWindow(string text)
+    {
+        InitializeComponent();
+        _text.Text = text;
+        PreviewKeyDown += OnPreviewKeyDown;
+        Closed += (_, _) => _result.TrySetResult(null);
+        Loaded += (_, _) =>
+        {
+            Activate();
+            _text.Focus();
+        };
+    }
+
+    /// <summary>
+    /// Shows the window on <paramref name="dispatcher"/> and completes with the text to paste,
+    /// or null when it was discarded or <paramref name="ct"/> was cancelled.
+    /// </summary>
+    public static async Task<string?> AskAsync(Dispatcher dispatcher, string text, CancellationToken ct)
+    {
+        var window = await dispatcher.InvokeAsync(() =>
+        {
+            var w = new ConfirmWindow(text);
+            w.Show();
+            return w;
+        });
+        using var registration = ct.Register(() => dispatcher.InvokeAsync(window.Close));
+        return await window._result.Task;
+    }
+
+    private bool IsEditing => !_text.IsReadOnly;
+
+    private void OnPreviewKeyDown(object sender, System.Windows.Input.KeyEventArgs e)
+    {
+        switch (e.Key)
+        {
+            case Key.Escape:
+                Finish(null);
+                e.Handled = true;
+                break;
+            // While editing, Enter starts a new line and Ctrl+Enter pastes
+            case Key.Enter when !IsEditing || Keyboard.Modifiers == ModifierKeys.Control:
+                Finish(_text.Text);
+                e.Handled = true;
+                break;
+        }
+    }
+
+    private void Edit_Click(object sender, RoutedEventArgs e)
+    {
+        _text.IsReadOnly = false;
+        _text.AcceptsReturn = true;
+        _editButton.IsEnabled = false;
+        _hint.Text = "Ctrl+Enter to paste, Esc to discard";
+        _text.Focus();
+        _text.CaretIndex = _text.Text.Length;
+    }
+
+    private void Accept_Click(object sender, RoutedEventArgs e) => Finish(_text.Text);
+
+    private void Discard_Click(object sender, RoutedEventArgs e) => Finish(null);
+
+    private void Finish(string? text)
+    {
+        _result.TrySetResult(text);
+        Close();
+    }
+}
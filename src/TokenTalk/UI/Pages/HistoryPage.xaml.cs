@@ -1,4 +1,5 @@
 using System.Windows;
+using TokenTalk.Storage;
 using TokenTalk.UI.ViewModels;
 
 namespace TokenTalk.UI.Pages;
@@ -17,6 +18,92 @@ public partial class HistoryPage : System.Windows.Controls.UserControl
     private async void Refresh_Click(object sender, RoutedEventArgs e)
         => await _vm.LoadAsync();
 
+    private async void Export_Click(object sender, RoutedEventArgs e)
+    {
+        var dialog = new Microsoft.Win32.SaveFileDialog
+        {
+            FileName = $"tokentalk-history-{DateTime.Now:yyyyMMdd}",
+            Filter = "CSV (*.csv)|*.csv|JSON (*.json)|*.json|Markdown (*.md)|*.md",
+        };
+        if (dialog.ShowDialog() != true) return;
+
+        // FilterIndex is 1-based and follows the Filter order above
+        var format = dialog.FilterIndex switch
+        {
+            2 => ExportFormat.Json,
+            3 => ExportFormat.Markdown,
+            _ => ExportFormat.Csv,
+        };
+
+        try
+        {
+            await _vm.ExportAsync(dialog.FileName, format);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Export failed: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    private void CleanUp_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn || btn.ContextMenu == null) return;
+        btn.ContextMenu.PlacementTarget = btn;
+        btn.ContextMenu.Placement = System.Windows.Controls.Primitives.PlacementMode.Bottom;
+        btn.ContextMenu.IsOpen = true;
+    }
+
+    private async void DeleteOlder_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.MenuItem item) return;
+        if (!int.TryParse(item.Tag as string, out var days)) return;
+
+        // 0 means everything
+        DateTime? before = days > 0 ? DateTime.UtcNow.AddDays(-days) : null;
+
+        try
+        {
+            var count = await _vm.CountBeforeAsync(before);
+            if (count == 0)
+            {
+                System.Windows.MessageBox.Show("There are no dictations to delete.", "TokenTalk",
+                    MessageBoxButton.OK, MessageBoxImage.Information);
+                return;
+            }
+
+            var message = before.HasValue
+                ? $"Permanently delete {count} dictation(s) older than {days} days?"
+                : $"Permanently delete all {count} dictation(s)? This cannot be undone.";
+            var answer = System.Windows.MessageBox.Show(message, "TokenTalk",
+                MessageBoxButton.YesNo, MessageBoxImage.Warning, MessageBoxResult.No);
+            if (answer != MessageBoxResult.Yes) return;
+
+            await _vm.DeleteBeforeAsync(before);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Delete failed: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    private async void UndoDelete_Click(object sender, RoutedEventArgs e)
+        => await _vm.UndoDeleteAsync();
+
+    private async void CopySession_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not string sessionId) return;
+        var text = await _vm.GetSessionTextAsync(sessionId);
+        if (string.IsNullOrEmpty(text)) return;
+        System.Windows.Clipboard.SetText(text);
+        var original = btn.Content;
+        btn.Content = "✓";
+        await Task.Delay(1500);
+        btn.Content = original;
+    }
+
     private async void Prev_Click(object sender, RoutedEventArgs e)
         => await _vm.PrevPageAsync();
 
@@ -35,6 +122,85 @@ public partial class HistoryPage : System.Windows.Controls.UserControl
         btn.Content = original;
     }
 
+    private void Edit_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not HistoryRowViewModel row) return;
+        _vm.BeginEdit(row);
+    }
+
+    private async void EditText_KeyDown(object sender, System.Windows.Input.KeyEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.TextBox box) return;
+        if (box.Tag is not HistoryRowViewModel row) return;
+
+        if (e.Key == System.Windows.Input.Key.Enter)
+            await _vm.SaveEditAsync(row);
+        else if (e.Key == System.Windows.Input.Key.Escape)
+            _vm.CancelEdit(row);
+    }
+
+    private async void Retry_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not long id) return;
+        btn.IsEnabled = false;
+        var retried = await _vm.RetryAsync(id);
+        if (retried is { Success: false })
+            System.Windows.MessageBox.Show($"Retry failed: {retried.ErrorMessage}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Warning);
+    }
+
+    private async void Star_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not HistoryRowViewModel row) return;
+        await _vm.ToggleStarAsync(row);
+    }
+
+    private async void RateUp_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not HistoryRowViewModel row) return;
+        await _vm.RateAsync(row, 1);
+    }
+
+    private async void RateDown_Click(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.Button btn) return;
+        if (btn.Tag is not HistoryRowViewModel row) return;
+        await _vm.RateAsync(row, -1);
+    }
+
+    private async void Tags_LostFocus(object sender, RoutedEventArgs e)
+    {
+        if (sender is not System.Windows.Controls.TextBox box) return;
+        if (box.Tag is not HistoryRowViewModel row) return;
+        await _vm.SaveTagsAsync(row);
+    }
+
+    private void Tags_KeyDown(object sender, System.Windows.Input.KeyEventArgs e)
+    {
+        // Moving focus away commits the edit through Tags_LostFocus
+        if (e.Key == System.Windows.Input.Key.Enter)
+            System.Windows.Input.Keyboard.ClearFocus();
+    }
+
+    private async void Filter_Changed(object sender, RoutedEventArgs e)
+        => await _vm.LoadAsync();
+
+    private async void ClearFilters_Click(object sender, RoutedEventArgs e)
+    {
+        _vm.ClearFilters();
+        await _vm.LoadAsync();
+    }
+
+    private async void TagFilter_KeyDown(object sender, System.Windows.Input.KeyEventArgs e)
+    {
+        if (e.Key == System.Windows.Input.Key.Enter)
+            await _vm.LoadAsync();
+    }
+
     private async void Delete_Click(object sender, RoutedEventArgs e)
     {
         if (sender is not System.Windows.Controls.Button btn) return;
@@ -32,4 +32,10 @@ public partial class HomePage : System.Windows.Controls.UserControl
         if (btn.Tag is not long id) return;
         await _vm.DeleteAsync(id);
     }
+
+    private void ClearDraft_Click(object sender, RoutedEventArgs e)
+        => _vm.ClearDraft();
+
+    private async void UndoDelete_Click(object sender, RoutedEventArgs e)
+        => await _vm.UndoDeleteAsync();
 }
@@ -15,6 +15,28 @@ public partial class SettingsPage : System.Windows.Controls.UserControl
 
         // Pre-populate PasswordBox (can't data-bind PasswordBox.Password for security)
         _apiKeyBox.Password = vm.ApiKey;
+        // Switching profiles reloads the settings, including the key
+        vm.PropertyChanged += (_, e) =>
+        {
+            if (e.PropertyName == nameof(SettingsViewModel.ApiKey) && _apiKeyBox.Password != vm.ApiKey)
+                _apiKeyBox.Password = vm.ApiKey;
+        };
+    }
+
+    private void SwitchProfile_Click(object sender, RoutedEventArgs e)
+        => _vm.SwitchProfile();
+
+    private void SaveProfile_Click(object sender, RoutedEventArgs e)
+        => _vm.SaveAsProfile();
+
+    private void DeleteProfile_Click(object sender, RoutedEventArgs e)
+    {
+        if (string.IsNullOrEmpty(_vm.SelectedProfile)) return;
+        var result = System.Windows.MessageBox.Show(
+            $"Delete the profile '{_vm.SelectedProfile}'? The current settings are kept.", "TokenTalk",
+            MessageBoxButton.YesNo, MessageBoxImage.Question);
+        if (result == MessageBoxResult.Yes)
+            _vm.DeleteProfile();
     }
 
     private void ApiKey_PasswordChanged(object sender, RoutedEventArgs e)
@@ -23,6 +45,115 @@ public partial class SettingsPage : System.Windows.Controls.UserControl
     private void Save_Click(object sender, RoutedEventArgs e)
         => _vm.Save();
 
+    private async void RefreshModels_Click(object sender, RoutedEventArgs e)
+        => await _vm.RefreshModelOptionsAsync();
+
+    private async void DebugBundle_Click(object sender, RoutedEventArgs e)
+    {
+        var dialog = new Microsoft.Win32.SaveFileDialog
+        {
+            FileName = $"tokentalk-debug-{DateTime.Now:yyyyMMdd-HHmm}",
+            Filter = "Zip archive (*.zip)|*.zip",
+        };
+        if (dialog.ShowDialog() != true) return;
+
+        try
+        {
+            await _vm.SaveDebugBundleAsync(dialog.FileName);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Could not save debug bundle: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    private async void WipeData_Click(object sender, RoutedEventArgs e)
+    {
+        var answer = System.Windows.MessageBox.Show(
+            "Permanently delete all dictation history, audio kept for retry and log files? This cannot be undone.",
+            "TokenTalk", MessageBoxButton.YesNo, MessageBoxImage.Warning, MessageBoxResult.No);
+        if (answer != MessageBoxResult.Yes) return;
+        var dictionary = System.Windows.MessageBox.Show(
+            "Also delete the custom dictionary?", "TokenTalk",
+            MessageBoxButton.YesNo, MessageBoxImage.Question, MessageBoxResult.No) == MessageBoxResult.Yes;
+
+        try
+        {
+            var result = await _vm.WipeDataAsync(dictionary);
+            System.Windows.MessageBox.Show(
+                $"Deleted {result.Dictations} dictation(s) and {result.AudioFiles} audio file(s){(result.Dictionary ? " and the dictionary" : "")}.",
+                "TokenTalk", MessageBoxButton.OK, MessageBoxImage.Information);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Delete failed: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    private void ExportConfig_Click(object sender, RoutedEventArgs e)
+    {
+        var dialog = new Microsoft.Win32.SaveFileDialog
+        {
+            FileName = "tokentalk-config",
+            Filter = "JSON file (*.json)|*.json",
+        };
+        if (dialog.ShowDialog() != true) return;
+
+        try
+        {
+            _vm.ExportConfig(dialog.FileName);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Could not export config: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    // Shows a dry run first and only saves once the listed changes are confirmed
+    private void ImportConfig_Click(object sender, RoutedEventArgs e)
+    {
+        var dialog = new Microsoft.Win32.OpenFileDialog { Filter = "JSON file (*.json)|*.json" };
+        if (dialog.ShowDialog() != true) return;
+
+        try
+        {
+            var preview = _vm.ImportConfig(dialog.FileName, dryRun: true);
+            if (preview.Problems.Count > 0)
+            {
+                System.Windows.MessageBox.Show(
+                    "The file was not imported:\n\n" + string.Join("\n", preview.Problems.Select(p => $"{p.Field}: {p.Message}")),
+                    "TokenTalk", MessageBoxButton.OK, MessageBoxImage.Warning);
+                return;
+            }
+            if (preview.Changes.Count == 0)
+            {
+                System.Windows.MessageBox.Show("The file matches the current settings.", "TokenTalk",
+                    MessageBoxButton.OK, MessageBoxImage.Information);
+                return;
+            }
+
+            var shown = preview.Changes.Take(20).ToList();
+            if (preview.Changes.Count > shown.Count)
+                shown.Add($"…and {preview.Changes.Count - shown.Count} more");
+            var answer = System.Windows.MessageBox.Show(
+                "Apply these changes?\n\n" + string.Join("\n", shown),
+                "TokenTalk", MessageBoxButton.YesNo, MessageBoxImage.Question);
+            if (answer == MessageBoxResult.Yes)
+                _vm.ImportConfig(dialog.FileName, dryRun: false);
+        }
+        catch (Exception ex)
+        {
+            System.Windows.MessageBox.Show($"Could not import config: {ex.Message}", "TokenTalk",
+                MessageBoxButton.OK, MessageBoxImage.Error);
+        }
+    }
+
+    private async void Test_Click(object sender, RoutedEventArgs e)
+        => await _vm.TestAsync();
+
     private void Download_Click(object sender, RoutedEventArgs e)
     {
         if (((FrameworkElement)sender).DataContext is ModelCatalogItem item)
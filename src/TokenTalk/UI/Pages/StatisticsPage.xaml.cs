@@ -27,6 +27,15 @@ public partial class StatisticsPage : System.Windows.Controls.UserControl
     private void FilterAllTime_Click(object sender, System.Windows.RoutedEventArgs e)
         => SetRange(StatsTimeRange.AllTime);
 
+    private void FilterCustom_Click(object sender, System.Windows.RoutedEventArgs e)
+        => SetRange(StatsTimeRange.Custom);
+
+    private void CustomDate_Changed(object? sender, SelectionChangedEventArgs e)
+    {
+        if (_vm.IsCustomActive)
+            _ = _vm.LoadAsync();
+    }
+
     private void SetRange(StatsTimeRange range)
     {
         _vm.SelectedRange = range;
@@ -3,47 +3,185 @@ using TokenTalk.Storage;
 
 namespace TokenTalk.UI.ViewModels;
 
-public class HistoryRowViewModel
+public class HistoryRowViewModel : ViewModelBase
 {
+    private bool _starred;
+    private int? _rating;
+    private string _tagsText = "";
+    private string _text = "";
+    private string _wordCount = "";
+    private bool _isEditing;
+    private string _editText = "";
+
     public long Id { get; init; }
+    public string? SessionId { get; init; }
     public string TimeDisplay { get; init; } = "";
-    public string Text { get; init; } = "";
+    public string Text { get => _text; set => SetProperty(ref _text, value); }
     public bool Success { get; init; }
-    public string WordCount { get; init; } = "";
+    // Failed with its audio kept, so it can be transcribed again
+    public bool CanRetry { get; init; }
+    public string WordCount { get => _wordCount; set => SetProperty(ref _wordCount, value); }
+    // Words the provider flagged as uncertain, with their confidence
+    public string LowConfidenceDisplay { get; init; } = "";
+    public bool HasLowConfidence => LowConfidenceDisplay.Length > 0;
+
+    public bool IsEditing
+    {
+        get => _isEditing;
+        set { if (SetProperty(ref _isEditing, value)) OnPropertyChanged(nameof(IsNotEditing)); }
+    }
+
+    public bool IsNotEditing => !IsEditing;
+    public string EditText { get => _editText; set => SetProperty(ref _editText, value); }
+
+    public bool Starred
+    {
+        get => _starred;
+        set { if (SetProperty(ref _starred, value)) OnPropertyChanged(nameof(StarGlyph)); }
+    }
+
+    public int? Rating
+    {
+        get => _rating;
+        set
+        {
+            if (SetProperty(ref _rating, value))
+            {
+                OnPropertyChanged(nameof(UpOpacity));
+                OnPropertyChanged(nameof(DownOpacity));
+            }
+        }
+    }
+
+    // The chosen thumb is shown solid, the other one dimmed
+    public double UpOpacity => Rating == 1 ? 1.0 : 0.35;
+    public double DownOpacity => Rating == -1 ? 1.0 : 0.35;
+
+    // Comma-separated tags as edited inline in the row
+    public string TagsText { get => _tagsText; set => SetProperty(ref _tagsText, value); }
+
+    public string StarGlyph => Starred ? "★" : "☆";
 }
 
 public class HistoryViewModel : ViewModelBase
 {
     private const int PageSize = 25;
+    private static readonly TimeSpan UndoWindow = TimeSpan.FromSeconds(10);
 
     private readonly DictationRepository _repository;
+    private readonly Agent _agent;
     private int _currentPage;
     private int _totalPages;
     private bool _canGoPrev;
     private bool _canGoNext;
     private bool _isLoading;
+    private bool _canUndoDelete;
+    private long? _undoId;
+    private bool _starredOnly;
+    private string _tagFilter = "";
+    private string _providerFilter = AnyOption;
+    private string _languageFilter = AnyOption;
+    private string _statusFilter = AnyOption;
+    private DateTime? _fromDate;
+    private DateTime? _toDate;
+    private string _minWords = "";
+    private string _maxWords = "";
+    private string _sortOption = SortOptions[0];
+    private bool _showFilters;
 
     public int CurrentPage { get => _currentPage; private set => SetProperty(ref _currentPage, value); }
     public int TotalPages { get => _totalPages; private set => SetProperty(ref _totalPages, value); }
     public bool CanGoPrev { get => _canGoPrev; private set => SetProperty(ref _canGoPrev, value); }
     public bool CanGoNext { get => _canGoNext; private set => SetProperty(ref _canGoNext, value); }
     public bool IsLoading { get => _isLoading; private set => SetProperty(ref _isLoading, value); }
+    public bool CanUndoDelete { get => _canUndoDelete; private set => SetProperty(ref _canUndoDelete, value); }
+    public bool StarredOnly { get => _starredOnly; set => SetProperty(ref _starredOnly, value); }
+    public string TagFilter { get => _tagFilter; set => SetProperty(ref _tagFilter, value); }
+    public string ProviderFilter { get => _providerFilter; set => SetProperty(ref _providerFilter, value); }
+    public string LanguageFilter { get => _languageFilter; set => SetProperty(ref _languageFilter, value); }
+    public string StatusFilter { get => _statusFilter; set => SetProperty(ref _statusFilter, value); }
+    // Local calendar dates, both inclusive
+    public DateTime? FromDate { get => _fromDate; set => SetProperty(ref _fromDate, value); }
+    public DateTime? ToDate { get => _toDate; set => SetProperty(ref _toDate, value); }
+    public string MinWords { get => _minWords; set => SetProperty(ref _minWords, value); }
+    public string MaxWords { get => _maxWords; set => SetProperty(ref _maxWords, value); }
+    public string SortOption { get => _sortOption; set => SetProperty(ref _sortOption, value); }
+    public bool ShowFilters { get => _showFilters; set => SetProperty(ref _showFilters, value); }
+
+    public const string AnyOption = "Any";
+    public static readonly List<string> StatusOptions = [AnyOption, "Successful", "Failed"];
+    // Order matches HistorySort
+    public static readonly List<string> SortOptions =
+        ["Newest first", "Oldest first", "Most words", "Fewest words", "Slowest", "Fastest"];
+
+    public ObservableCollection<string> ProviderOptions { get; } = [AnyOption];
+    public ObservableCollection<string> LanguageOptions { get; } = [AnyOption];
 
     public ObservableCollection<HistoryRowViewModel> Items { get; } = [];
 
-    public HistoryViewModel(DictationRepository repository)
+    public HistoryViewModel(DictationRepository repository, Agent agent)
     {
         _repository = repository;
+        _agent = agent;
+    }
+
+    public async Task LoadAsync()
+    {
+        await LoadFilterOptionsAsync();
+        await LoadPageAsync(0);
+    }
+
+    private async Task LoadFilterOptionsAsync()
+    {
+        var (providers, languages) = await _repository.GetFilterOptionsAsync();
+        ReplaceOptions(ProviderOptions, providers);
+        ReplaceOptions(LanguageOptions, languages);
     }
 
-    public async Task LoadAsync() => await LoadPageAsync(0);
+    // Keeps the current selection bound to a live item instead of clearing it
+    private static void ReplaceOptions(ObservableCollection<string> options, List<string> values)
+    {
+        foreach (var stale in options.Skip(1).Where(o => !values.Contains(o)).ToList())
+            options.Remove(stale);
+        foreach (var value in values.Where(v => !options.Contains(v)))
+            options.Add(value);
+    }
+
+    public void ClearFilters()
+    {
+        StarredOnly = false;
+        TagFilter = "";
+        ProviderFilter = AnyOption;
+        LanguageFilter = AnyOption;
+        StatusFilter = AnyOption;
+        FromDate = null;
+        ToDate = null;
+        MinWords = "";
+        MaxWords = "";
+        SortOption = SortOptions[0];
+    }
+
+    private HistoryFilter BuildFilter() => new()
+    {
+        StarredOnly = StarredOnly,
+        Tag = TagFilter,
+        Provider = ProviderFilter == AnyOption ? null : ProviderFilter,
+        Language = LanguageFilter == AnyOption ? null : LanguageFilter,
+        Success = StatusFilter switch { "Successful" => true, "Failed" => false, _ => null },
+        From = FromDate?.Date.ToUniversalTime(),
+        To = ToDate?.Date.AddDays(1).ToUniversalTime(),
+        MinWords = int.TryParse(MinWords, out var min) ? min : null,
+        MaxWords = int.TryParse(MaxWords, out var max) ? max : null,
+        Sort = (HistorySort)Math.Max(0, SortOptions.IndexOf(SortOption)),
+    };
 
     public async Task LoadPageAsync(int page)
     {
         IsLoading = true;
         try
         {
-            var (items, total) = await _repository.GetHistoryAsync(PageSize, page * PageSize);
+            var (items, total) = await _repository.GetHistoryAsync(
+                PageSize, page * PageSize, BuildFilter());
             CurrentPage = page;
             TotalPages = total == 0 ? 1 : (int)Math.Ceiling(total / (double)PageSize);
             CanGoPrev = page > 0;
@@ -55,10 +193,16 @@ public class HistoryViewModel : ViewModelBase
                 Items.Add(new HistoryRowViewModel
                 {
                     Id = d.Id,
+                    SessionId = d.SessionId,
                     TimeDisplay = d.Timestamp.ToLocalTime().ToString("MMM d, HH:mm"),
                     Text = d.TranscribedText ?? d.ErrorMessage ?? "(empty)",
                     Success = d.Success,
+                    CanRetry = !d.Success && _agent.CanRetry(d.Id),
                     WordCount = d.WordCount > 0 ? $"{d.WordCount}w" : "",
+                    Starred = d.Starred,
+                    Rating = d.Rating,
+                    LowConfidenceDisplay = FormatLowConfidence(d.LowConfidence),
+                    TagsText = string.Join(", ", DictationRepository.ParseTags(d.Tags)),
                 });
             }
         }
@@ -68,11 +212,108 @@ public class HistoryViewModel : ViewModelBase
         }
     }
 
+    /// <summary>Transcribes a failed dictation again without pasting; the result is a new row.</summary>
+    public async Task<Dictation?> RetryAsync(long id)
+    {
+        var retried = await _agent.RetryAsync(id, paste: false);
+        await LoadPageAsync(CurrentPage);
+        return retried;
+    }
+
     public async Task DeleteAsync(long id)
     {
         await _repository.DeleteAsync(id);
         var row = Items.FirstOrDefault(r => r.Id == id);
         if (row != null) Items.Remove(row);
+        _ = OfferUndoAsync(id);
+    }
+
+    public async Task UndoDeleteAsync()
+    {
+        if (_undoId is not long id) return;
+        _undoId = null;
+        CanUndoDelete = false;
+        await _repository.RestoreAsync(id);
+        await LoadPageAsync(CurrentPage);
+    }
+
+    private async Task OfferUndoAsync(long id)
+    {
+        _undoId = id;
+        CanUndoDelete = true;
+        await Task.Delay(UndoWindow);
+        if (_undoId == id)
+        {
+            _undoId = null;
+            CanUndoDelete = false;
+        }
+    }
+
+    public Task<int> CountBeforeAsync(DateTime? before) => _repository.CountBeforeAsync(before);
+
+    /// <summary>Deletes dictations older than <paramref name="before"/>, or everything when null.</summary>
+    public async Task<int> DeleteBeforeAsync(DateTime? before)
+    {
+        var removed = before.HasValue
+            ? await _repository.DeleteBeforeAsync(before.Value)
+            : await _repository.DeleteAllAsync();
+        await LoadPageAsync(0);
+        return removed;
+    }
+
+    /// <summary>Joins the successful transcripts of a session in dictation order.</summary>
+    public async Task<string> GetSessionTextAsync(string sessionId)
+    {
+        var dictations = await _repository.GetSessionDictationsAsync(sessionId);
+        return string.Join(Environment.NewLine, dictations
+            .Where(d => d.Success && !string.IsNullOrEmpty(d.TranscribedText))
+            .Select(d => d.TranscribedText));
+    }
+
+    public async Task ToggleStarAsync(HistoryRowViewModel row)
+    {
+        await _repository.SetStarredAsync(row.Id, !row.Starred);
+        row.Starred = !row.Starred;
+    }
+
+    /// <summary>Sets the row's rating, or clears it when the same thumb is clicked again.</summary>
+    public async Task RateAsync(HistoryRowViewModel row, int rating)
+    {
+        int? next = row.Rating == rating ? null : rating;
+        await _repository.SetRatingAsync(row.Id, next);
+        row.Rating = next;
+    }
+
+    public void BeginEdit(HistoryRowViewModel row)
+    {
+        row.EditText = row.Text;
+        row.IsEditing = true;
+    }
+
+    public void CancelEdit(HistoryRowViewModel row) => row.IsEditing = false;
+
+    public async Task SaveEditAsync(HistoryRowViewModel row)
+    {
+        var text = row.EditText.Trim();
+        if (text.Length > 0 && text != row.Text)
+        {
+            var updated = await _repository.UpdateTextAsync(row.Id, text);
+            row.Text = updated.TranscribedText;
+            row.WordCount = updated.WordCount > 0 ? $"{updated.WordCount}w" : "";
+        }
+        row.IsEditing = false;
+    }
+
+    public async Task SaveTagsAsync(HistoryRowViewModel row)
+    {
+        var tags = await _repository.SetTagsAsync(row.Id, row.TagsText.Split(','));
+        row.TagsText = string.Join(", ", tags);
+    }
+
+    public async Task<int> ExportAsync(string path, ExportFormat format)
+    {
+        await using var stream = File.Create(path);
+        return await HistoryExporter.ExportAsync(_repository.StreamAsync(null, null), format, stream);
     }
 
     public async Task NextPageAsync()
@@ -84,4 +325,10 @@ public class HistoryViewModel : ViewModelBase
     {
         if (CanGoPrev) await LoadPageAsync(CurrentPage - 1);
     }
+
+    private static string FormatLowConfidence(string? lowConfidence)
+    {
+        var words = DictationRepository.ParseLowConfidence(lowConfidence);
+        return words.Count == 0 ? "" : "Unsure: " + string.Join(", ", words.Select(w => $"{w.Word} ({w.Confidence:P0})"));
+    }
 }
@@ -19,22 +19,64 @@ public class DictationGroupViewModel : ViewModelBase
 
 public class HomeViewModel : ViewModelBase
 {
+    private static readonly TimeSpan UndoWindow = TimeSpan.FromSeconds(10);
+
     private readonly DictationRepository _repository;
+    private readonly GoalTracker _goals;
+    private readonly Agent _agent;
 
+    private string _goalDisplay = "";
+    private string _dayStreakDisplay = "—";
+    private string _dayStreakToolTip = "";
+    private bool _hasGoal;
+    private bool _goalMet;
+    private bool _canUndoDelete;
+    private long? _undoId;
     private string _weeksStreakDisplay = "—";
     private string _totalWordsDisplay = "—";
     private string _avgWpmDisplay = "—";
     private int _totalWordsRaw;
+    private string _previewText = "";
+    private string _previewStage = "";
+    private bool _hasPreview;
+    private string _draftText = "";
 
     public string WeeksStreakDisplay { get => _weeksStreakDisplay; private set => SetProperty(ref _weeksStreakDisplay, value); }
     public string TotalWordsDisplay { get => _totalWordsDisplay; private set => SetProperty(ref _totalWordsDisplay, value); }
     public string AvgWpmDisplay { get => _avgWpmDisplay; private set => SetProperty(ref _avgWpmDisplay, value); }
 
+    public string GoalDisplay { get => _goalDisplay; private set => SetProperty(ref _goalDisplay, value); }
+    public string DayStreakDisplay { get => _dayStreakDisplay; private set => SetProperty(ref _dayStreakDisplay, value); }
+    public string DayStreakToolTip { get => _dayStreakToolTip; private set => SetProperty(ref _dayStreakToolTip, value); }
+    public bool HasGoal { get => _hasGoal; private set => SetProperty(ref _hasGoal, value); }
+    public bool GoalMet { get => _goalMet; private set => SetProperty(ref _goalMet, value); }
+    public bool CanUndoDelete { get => _canUndoDelete; private set => SetProperty(ref _canUndoDelete, value); }
+
+    // Text of the dictation being processed, updated as each pipeline stage completes
+    public string PreviewText { get => _previewText; private set => SetProperty(ref _previewText, value); }
+    public string PreviewStage { get => _previewStage; private set => SetProperty(ref _previewStage, value); }
+    public bool HasPreview { get => _hasPreview; private set => SetProperty(ref _hasPreview, value); }
+
+    // Accumulated dictations waiting for the send phrase
+    public string DraftText
+    {
+        get => _draftText;
+        private set
+        {
+            if (SetProperty(ref _draftText, value))
+                OnPropertyChanged(nameof(HasDraft));
+        }
+    }
+    public bool HasDraft => DraftText.Length > 0;
+
     public ObservableCollection<DictationGroupViewModel> Groups { get; } = [];
 
-    public HomeViewModel(DictationRepository repository)
+    public HomeViewModel(DictationRepository repository, GoalTracker goals, Agent agent)
     {
         _repository = repository;
+        _goals = goals;
+        _agent = agent;
+        _draftText = agent.Draft;
     }
 
     public async Task LoadAsync()
@@ -42,13 +84,12 @@ public class HomeViewModel : ViewModelBase
         var (items, _) = await _repository.GetHistoryAsync(50, 0);
         var stats = await _repository.GetOverallStatsAsync(365);
         var heatmap = await _repository.GetHeatmapStatsAsync();
+        ApplyGoalProgress(await _goals.RefreshAsync());
 
         _totalWordsRaw = stats.TotalWords;
         TotalWordsDisplay = FormatWordCount(stats.TotalWords);
 
-        int avgWpm = stats.TotalRecordingTimeMs > 0
-            ? (int)(stats.TotalWords / (stats.TotalRecordingTimeMs / 60000.0))
-            : 0;
+        int avgWpm = (int)Math.Round(stats.AvgSpeakingWpm);
         AvgWpmDisplay = avgWpm > 0 ? $"{avgWpm} WPM" : "— WPM";
 
         int streak = ComputeWeeksStreak(heatmap);
@@ -95,9 +136,67 @@ public class HomeViewModel : ViewModelBase
                 group.Items.Remove(row);
                 if (group.Items.Count == 0)
                     Groups.Remove(group);
-                return;
+                break;
             }
         }
+        _ = OfferUndoAsync(id);
+    }
+
+    public async Task UndoDeleteAsync()
+    {
+        if (_undoId is not long id) return;
+        _undoId = null;
+        CanUndoDelete = false;
+        await _repository.RestoreAsync(id);
+        await LoadAsync();
+    }
+
+    private async Task OfferUndoAsync(long id)
+    {
+        _undoId = id;
+        CanUndoDelete = true;
+        await Task.Delay(UndoWindow);
+        if (_undoId == id)
+        {
+            _undoId = null;
+            CanUndoDelete = false;
+        }
+    }
+
+    public void ApplyGoalProgress(GoalProgress p)
+    {
+        HasGoal = p.HasGoal;
+        GoalMet = p.TodayMet;
+
+        var parts = new List<string>();
+        if (p.DailyWordsGoal > 0)
+            parts.Add($"{p.TodayWords:N0}/{p.DailyWordsGoal:N0} words");
+        if (p.DailyDictationsGoal > 0)
+            parts.Add($"{p.TodayDictations}/{p.DailyDictationsGoal} dictations");
+        GoalDisplay = string.Join(", ", parts);
+
+        DayStreakDisplay = p.CurrentStreak == 1 ? "1 day" : $"{p.CurrentStreak} days";
+        DayStreakToolTip = $"Best streak: {p.BestStreak} day(s)";
+    }
+
+    public void ShowPreview(TranscriptPreview preview)
+    {
+        PreviewStage = preview.Stage.EndsWith("Processor")
+            ? preview.Stage[..^"Processor".Length]
+            : preview.Stage;
+        PreviewText = preview.Text;
+        HasPreview = true;
+    }
+
+    public void ShowDraft(string draft) => DraftText = draft;
+
+    public void ClearDraft() => _agent.ClearDraft();
+
+    public void ClearPreview()
+    {
+        HasPreview = false;
+        PreviewText = "";
+        PreviewStage = "";
     }
 
     public void OnNewDictation(Dictation d)
@@ -124,6 +223,25 @@ public class HomeViewModel : ViewModelBase
         });
     }
 
+    public void OnDictationUpdated(Dictation d)
+    {
+        foreach (var group in Groups)
+        {
+            var index = group.Items.ToList().FindIndex(r => r.Id == d.Id);
+            if (index < 0) continue;
+
+            var old = group.Items[index];
+            group.Items[index] = new DictationRowViewModel
+            {
+                Id = old.Id,
+                TimeDisplay = old.TimeDisplay,
+                Text = d.TranscribedText,
+                Success = old.Success,
+            };
+            return;
+        }
+    }
+
     private static string FormatWordCount(int count) =>
         count >= 10_000 ? $"{count / 1000.0:0.#}K words"
         : count >= 1_000 ? $"{count / 1000.0:0.0}K words"
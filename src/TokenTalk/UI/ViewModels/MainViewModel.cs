@@ -1,4 +1,5 @@
 using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
 using TokenTalk.Transcription;
 using WpfApplication = System.Windows.Application;
 using TokenTalk.PostProcessing;
@@ -11,6 +12,9 @@ public enum AppPage { Home, History, Dictionary, Settings, Statistics }
 public class MainViewModel : ViewModelBase, IDisposable
 {
     private readonly Agent _agent;
+    private readonly DictationRepository _repository;
+    private readonly GoalTracker _goals;
+    private readonly IDisposable _stateSubscription;
 
     private string _statusText = "Idle";
     private string _statusColor = "#8E8E93";
@@ -20,6 +24,9 @@ public class MainViewModel : ViewModelBase, IDisposable
     public string StatusColor { get => _statusColor; private set => SetProperty(ref _statusColor, value); }
     public AppPage CurrentPage { get => _currentPage; set => SetProperty(ref _currentPage, value); }
 
+    public string VersionDisplay => $"v{BuildInfo.Version}";
+    public string BuildInfoText => BuildInfo.Summary;
+
     public HomeViewModel HomeVm { get; }
     public HistoryViewModel HistoryVm { get; }
     public DictionaryViewModel DictionaryVm { get; }
@@ -32,35 +39,71 @@ public class MainViewModel : ViewModelBase, IDisposable
         ConfigManager configManager,
         DictionaryService dictionaryService,
         CustomDictionary dictionary,
-        ModelManager modelManager)
+        ModelManager modelManager,
+        HistoryRetentionService retention,
+        GoalTracker goals,
+        ConfigValidator validator,
+        OpenAiModelLister modelLister,
+        DebugBundleWriter debugBundle,
+        DataWiper wiper)
     {
         _agent = agent;
-        HomeVm = new HomeViewModel(repository);
-        HistoryVm = new HistoryViewModel(repository);
+        _repository = repository;
+        _goals = goals;
+        HomeVm = new HomeViewModel(repository, goals, agent);
+        HistoryVm = new HistoryViewModel(repository, agent);
         DictionaryVm = new DictionaryViewModel(dictionaryService, dictionary);
-        SettingsVm = new SettingsViewModel(configManager, modelManager);
+        SettingsVm = new SettingsViewModel(configManager, modelManager, retention, validator, modelLister, debugBundle, wiper);
         StatisticsVm = new StatisticsViewModel(repository);
 
-        _agent.StatusChanged += OnStatusChanged;
+        _stateSubscription = _agent.Bus.Subscribe(OnStateChanged);
         _agent.DictationCompleted += OnDictationCompleted;
+        _agent.TranscriptPreviewed += OnTranscriptPreviewed;
+        _agent.DraftChanged += OnDraftChanged;
+        _repository.DictationUpdated += OnDictationUpdated;
+        _goals.ProgressChanged += OnGoalProgressChanged;
     }
 
-    private void OnStatusChanged(object? sender, string status)
+    private void OnStateChanged(AgentStateChange change)
     {
         WpfApplication.Current?.Dispatcher.Invoke(() =>
         {
-            StatusText = status switch
+            StatusText = change.To switch
             {
-                "recording" => "Recording",
-                "processing" => "Processing",
+                AgentState.Recording => "Recording",
+                AgentState.Transcribing => "Transcribing",
+                AgentState.PostProcessing => "Processing",
+                AgentState.Injecting => "Pasting",
+                AgentState.Error => "Error",
+                AgentState.Paused => "Paused",
                 _ => "Idle",
             };
-            StatusColor = status switch
+            StatusColor = change.To switch
             {
-                "recording" => "#FF3B30",
-                "processing" => "#FF9500",
+                AgentState.Recording => "#FF3B30",
+                AgentState.Transcribing or AgentState.PostProcessing or AgentState.Injecting => "#FF9500",
+                AgentState.Error => "#FF453A",
+                AgentState.Paused => "#FFD60A",
                 _ => "#8E8E93",
             };
+            if (change.To is not (AgentState.Transcribing or AgentState.PostProcessing or AgentState.Injecting))
+                HomeVm.ClearPreview();
+        });
+    }
+
+    private void OnTranscriptPreviewed(object? sender, TranscriptPreview preview)
+    {
+        WpfApplication.Current?.Dispatcher.Invoke(() =>
+        {
+            HomeVm.ShowPreview(preview);
+        });
+    }
+
+    private void OnDraftChanged(object? sender, string draft)
+    {
+        WpfApplication.Current?.Dispatcher.Invoke(() =>
+        {
+            HomeVm.ShowDraft(draft);
         });
     }
 
@@ -72,9 +115,29 @@ public class MainViewModel : ViewModelBase, IDisposable
         });
     }
 
+    private void OnDictationUpdated(object? sender, Dictation d)
+    {
+        WpfApplication.Current?.Dispatcher.Invoke(() =>
+        {
+            HomeVm.OnDictationUpdated(d);
+        });
+    }
+
+    private void OnGoalProgressChanged(object? sender, GoalProgress p)
+    {
+        WpfApplication.Current?.Dispatcher.Invoke(() =>
+        {
+            HomeVm.ApplyGoalProgress(p);
+        });
+    }
+
     public void Dispose()
     {
-        _agent.StatusChanged -= OnStatusChanged;
+        _goals.ProgressChanged -= OnGoalProgressChanged;
+        _repository.DictationUpdated -= OnDictationUpdated;
+        _stateSubscription.Dispose();
         _agent.DictationCompleted -= OnDictationCompleted;
+        _agent.TranscriptPreviewed -= OnTranscriptPreviewed;
+        _agent.DraftChanged -= OnDraftChanged;
     }
 }
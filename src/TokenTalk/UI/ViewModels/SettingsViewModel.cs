@@ -1,6 +1,9 @@
 using System.Collections.ObjectModel;
 using NAudio.Wave;
 using TokenTalk.Configuration;
+using TokenTalk.Diagnostics;
+using TokenTalk.Notifications;
+using TokenTalk.Storage;
 using TokenTalk.Transcription;
 
 namespace TokenTalk.UI.ViewModels;
@@ -83,10 +86,40 @@ public class SettingsViewModel : ViewModelBase
 {
     private readonly ConfigManager _configManager;
     private readonly ModelManager _modelManager;
+    private readonly HistoryRetentionService _retention;
+    private readonly ConfigValidator _validator;
+    private readonly OpenAiModelLister _modelLister;
+    private readonly DebugBundleWriter _debugBundle;
+    private readonly DataWiper _wiper;
+
+    // Problems found in the config file when it was loaded
+    private string _configProblems = "";
+    public string ConfigProblems { get => _configProblems; private set { SetProperty(ref _configProblems, value); OnPropertyChanged(nameof(HasConfigProblems)); } }
+    public bool HasConfigProblems => _configProblems.Length > 0;
+
+    // Profiles
+    private string _selectedProfile = "";
+    private string _newProfileName = "";
+    public ObservableCollection<string> ProfileNames { get; } = [];
+    public string SelectedProfile { get => _selectedProfile; set => SetProperty(ref _selectedProfile, value ?? ""); }
+    public string NewProfileName { get => _newProfileName; set => SetProperty(ref _newProfileName, value); }
 
     // Hotkey
     private string _hotkey = "";
     public string Hotkey { get => _hotkey; set => SetProperty(ref _hotkey, value); }
+    private string _hotkeyMode = HotkeyModes.Hold;
+    public string HotkeyMode { get => _hotkeyMode; set => SetProperty(ref _hotkeyMode, value); }
+
+    private int _minHoldMs = 150;
+    public int MinHoldMs { get => _minHoldMs; set => SetProperty(ref _minHoldMs, value); }
+    private string _queuePolicy = QueuePolicies.Queue;
+    public string QueuePolicy { get => _queuePolicy; set => SetProperty(ref _queuePolicy, value); }
+
+    private int _timeoutSeconds = 60;
+    public int TimeoutSeconds { get => _timeoutSeconds; set => SetProperty(ref _timeoutSeconds, value); }
+
+    private int _shutdownWaitSeconds = 10;
+    public int ShutdownWaitSeconds { get => _shutdownWaitSeconds; set => SetProperty(ref _shutdownWaitSeconds, value); }
 
     // Transcription — provider
     private string _provider = "openai";
@@ -103,6 +136,9 @@ public class SettingsViewModel : ViewModelBase
         }
     }
 
+    private bool _raceProviders;
+    public bool RaceProviders { get => _raceProviders; set => SetProperty(ref _raceProviders, value); }
+
     public bool IsOpenAiProvider => Provider == "openai";
     public bool IsLocalProvider => Provider == "whisper.cpp";
 
@@ -114,6 +150,12 @@ public class SettingsViewModel : ViewModelBase
     public string Model { get => _model; set => SetProperty(ref _model, value); }
     public string Prompt { get => _prompt; set => SetProperty(ref _prompt, value); }
 
+    private bool _isLoadingModels;
+    private string _modelListStatus = "";
+    public ObservableCollection<string> ModelOptions { get; } = new(OpenAiModelLister.KnownModels);
+    public bool IsLoadingModels { get => _isLoadingModels; private set => SetProperty(ref _isLoadingModels, value); }
+    public string ModelListStatus { get => _modelListStatus; private set => SetProperty(ref _modelListStatus, value); }
+
     // Transcription — shared language
     private string _language = "";
     public string Language { get => _language; set => SetProperty(ref _language, value); }
@@ -130,14 +172,82 @@ public class SettingsViewModel : ViewModelBase
     private bool _ppCommands;
     public bool Commands { get => _ppCommands; set => SetProperty(ref _ppCommands, value); }
 
+    // Output
+    private string _outputMode = OutputModes.Paste;
+    public string OutputMode { get => _outputMode; set => SetProperty(ref _outputMode, value); }
+    private bool _accumulate;
+    private string _sendPhrase = "";
+    public bool Accumulate { get => _accumulate; set => SetProperty(ref _accumulate, value); }
+
+    private bool _typeWhileSpeaking;
+    public bool TypeWhileSpeaking { get => _typeWhileSpeaking; set => SetProperty(ref _typeWhileSpeaking, value); }
+
+    private bool _confirmPaste;
+    public bool ConfirmPaste { get => _confirmPaste; set => SetProperty(ref _confirmPaste, value); }
+    public string SendPhrase { get => _sendPhrase; set => SetProperty(ref _sendPhrase, value); }
+    private int _prePasteDelayMs;
+    private int _postPasteDelayMs;
+    private int _restoreDelayMs;
+    public int PrePasteDelayMs { get => _prePasteDelayMs; set => SetProperty(ref _prePasteDelayMs, value); }
+    public int PostPasteDelayMs { get => _postPasteDelayMs; set => SetProperty(ref _postPasteDelayMs, value); }
+    public int RestoreDelayMs { get => _restoreDelayMs; set => SetProperty(ref _restoreDelayMs, value); }
+
+    // History retention
+    private int _maxAgeDays;
+    private int _maxCount;
+    private bool _encryptText;
+    private string _lastPruneDisplay = "";
+    public int MaxAgeDays { get => _maxAgeDays; set => SetProperty(ref _maxAgeDays, value); }
+    public int MaxCount { get => _maxCount; set => SetProperty(ref _maxCount, value); }
+    public bool EncryptText { get => _encryptText; set => SetProperty(ref _encryptText, value); }
+    private int _trashDays;
+    public int TrashDays { get => _trashDays; set => SetProperty(ref _trashDays, value); }
+    public string LastPruneDisplay { get => _lastPruneDisplay; private set => SetProperty(ref _lastPruneDisplay, value); }
+
+    // Goals
+    private int _dailyWords;
+    private int _dailyDictations;
+    private bool _notifyOnGoal;
+    public int DailyWords { get => _dailyWords; set => SetProperty(ref _dailyWords, value); }
+    public int DailyDictations { get => _dailyDictations; set => SetProperty(ref _dailyDictations, value); }
+    public bool NotifyOnGoal { get => _notifyOnGoal; set => SetProperty(ref _notifyOnGoal, value); }
+
+    // Notifications
+    private bool _notifyOnError;
+    private bool _notifyOnHealthProblem;
+    private bool _notifyOnTranscription;
+    public bool NotifyOnError { get => _notifyOnError; set => SetProperty(ref _notifyOnError, value); }
+    public bool NotifyOnHealthProblem { get => _notifyOnHealthProblem; set => SetProperty(ref _notifyOnHealthProblem, value); }
+    public bool NotifyOnTranscription { get => _notifyOnTranscription; set => SetProperty(ref _notifyOnTranscription, value); }
+    private bool _playSounds;
+    public bool PlaySounds { get => _playSounds; set => SetProperty(ref _playSounds, value); }
+    private bool _checkForUpdates;
+    public bool CheckForUpdates { get => _checkForUpdates; set => SetProperty(ref _checkForUpdates, value); }
+
+    private bool _shareUsage;
+    public bool ShareUsage { get => _shareUsage; set => SetProperty(ref _shareUsage, value); }
+
     // UI state
     private bool _saveSuccess;
+    private bool _isTesting;
+    private bool _hasTestResults;
     public bool SaveSuccess { get => _saveSuccess; set => SetProperty(ref _saveSuccess, value); }
+    public bool IsTesting
+    {
+        get => _isTesting;
+        private set { if (SetProperty(ref _isTesting, value)) OnPropertyChanged(nameof(CanTest)); }
+    }
+    public bool CanTest => !IsTesting;
+    public bool HasTestResults { get => _hasTestResults; private set => SetProperty(ref _hasTestResults, value); }
+    public ObservableCollection<ConfigCheck> TestResults { get; } = [];
 
     public List<AudioDeviceItem> AudioDevices { get; } = [];
     public ObservableCollection<ModelCatalogItem> ModelCatalog { get; } = [];
 
     public static readonly List<string> ProviderOptions = ["openai", "whisper.cpp"];
+    public static readonly List<string> HotkeyModeOptions = [.. HotkeyModes.All];
+    public static readonly List<string> QueuePolicyOptions = [.. QueuePolicies.All];
+    public static readonly List<string> OutputModeOptions = [.. OutputModes.All];
 
     public static readonly List<string> LanguageOptions =
     [
@@ -145,16 +255,32 @@ public class SettingsViewModel : ViewModelBase
         "da", "nb", "fi", "zh", "ja", "ko", "ar", "ru",
     ];
 
-    public SettingsViewModel(ConfigManager configManager, ModelManager modelManager)
+    public SettingsViewModel(
+        ConfigManager configManager,
+        ModelManager modelManager,
+        HistoryRetentionService retention,
+        ConfigValidator validator,
+        OpenAiModelLister modelLister,
+        DebugBundleWriter debugBundle,
+        DataWiper wiper)
     {
         _configManager = configManager;
         _modelManager = modelManager;
+        _retention = retention;
+        _validator = validator;
+        _modelLister = modelLister;
+        _debugBundle = debugBundle;
+        _wiper = wiper;
 
         foreach (var info in ModelManager.Catalog)
             ModelCatalog.Add(new ModelCatalogItem(info));
 
         LoadAudioDevices();
         Load();
+
+        // Profile switches from the tray or control pipe, and external edits, show up here too
+        _configManager.Changed += (_, _) =>
+            System.Windows.Application.Current?.Dispatcher.Invoke(Load);
     }
 
     private void LoadAudioDevices()
@@ -170,8 +296,19 @@ public class SettingsViewModel : ViewModelBase
     public void Load()
     {
         var cfg = _configManager.Current;
-        Hotkey = cfg.Hotkey;
+        ConfigProblems = string.Join(Environment.NewLine, _configManager.Problems.Select(p => $"{p.Field}: {p.Message}"));
+        ProfileNames.Clear();
+        foreach (var profile in cfg.Profiles)
+            ProfileNames.Add(profile.Name);
+        SelectedProfile = cfg.ActiveProfile;
+        Hotkey = cfg.PrimaryHotkey.Combo;
+        HotkeyMode = cfg.PrimaryHotkey.Mode;
+        MinHoldMs = cfg.PrimaryHotkey.MinHoldMs;
+        QueuePolicy = cfg.Queue.Policy;
+        TimeoutSeconds = cfg.Queue.TimeoutSeconds;
+        ShutdownWaitSeconds = cfg.Queue.ShutdownWaitSeconds;
         Provider = cfg.Transcription.Provider;
+        RaceProviders = cfg.Transcription.Race;
         ApiKey = cfg.Transcription.ApiKey;
         Model = cfg.Transcription.Model;
         Language = cfg.Transcription.Language;
@@ -180,9 +317,39 @@ public class SettingsViewModel : ViewModelBase
         MaxSeconds = cfg.Audio.MaxSeconds;
         SilenceThreshold = cfg.Audio.SilenceThreshold;
         Commands = cfg.PostProcessing.Commands;
+        OutputMode = cfg.Output.Mode;
+        Accumulate = cfg.Output.Accumulate;
+        TypeWhileSpeaking = cfg.Output.TypeWhileSpeaking;
+        ConfirmPaste = cfg.Output.Confirm;
+        SendPhrase = cfg.Output.SendPhrase;
+        PrePasteDelayMs = cfg.Output.PrePasteDelayMs;
+        PostPasteDelayMs = cfg.Output.PostPasteDelayMs;
+        RestoreDelayMs = cfg.Output.RestoreDelayMs;
+        MaxAgeDays = cfg.History.MaxAgeDays;
+        MaxCount = cfg.History.MaxCount;
+        EncryptText = cfg.History.EncryptText;
+        TrashDays = cfg.History.TrashDays;
+        DailyWords = cfg.Goals.DailyWords;
+        DailyDictations = cfg.Goals.DailyDictations;
+        NotifyOnGoal = cfg.Goals.NotifyOnGoal;
+        NotifyOnError = cfg.Notifications.OnError;
+        NotifyOnHealthProblem = cfg.Notifications.OnHealthProblem;
+        NotifyOnTranscription = cfg.Notifications.OnTranscription;
+        PlaySounds = cfg.Notifications.Notifiers.Contains(NotifierNames.Sound, StringComparer.OrdinalIgnoreCase);
+        CheckForUpdates = cfg.Updates.CheckForUpdates;
+        ShareUsage = cfg.Telemetry.Enabled;
+        RefreshLastPrune();
         RefreshModelStates(cfg.Transcription.ModelPath);
     }
 
+    private void RefreshLastPrune()
+    {
+        var last = _retention.LastResult;
+        LastPruneDisplay = last == null
+            ? ""
+            : $"Last cleanup {last.RunAt.ToLocalTime():MMM d, HH:mm} removed {last.Removed} dictation(s).";
+    }
+
     private void RefreshModelStates(string? currentModelPath)
     {
         foreach (var item in ModelCatalog)
@@ -198,8 +365,122 @@ public class SettingsViewModel : ViewModelBase
     public void Save()
     {
         var cfg = _configManager.Current;
-        cfg.Hotkey = Hotkey;
+        ApplyTo(cfg);
+        _configManager.Save(cfg);
+
+        // Apply new retention limits right away rather than at the next scheduled run
+        _ = _retention.PruneOnceAsync().ContinueWith(_ =>
+            System.Windows.Application.Current?.Dispatcher.Invoke(RefreshLastPrune));
+
+        SaveSuccess = true;
+        Task.Delay(2000).ContinueWith(_ =>
+        {
+            System.Windows.Application.Current?.Dispatcher.Invoke(() => SaveSuccess = false);
+        });
+    }
+
+    public void SwitchProfile()
+    {
+        if (!string.IsNullOrEmpty(SelectedProfile))
+            _configManager.SwitchProfile(SelectedProfile);
+    }
+
+    /// <summary>Saves the settings on this page, including unsaved edits, as a named profile.</summary>
+    public void SaveAsProfile()
+    {
+        var name = NewProfileName.Trim();
+        if (name.Length == 0)
+            return;
+
+        var cfg = _configManager.Current;
+        ApplyTo(cfg);
+        _configManager.Save(cfg);
+        _configManager.SaveProfile(name);
+        NewProfileName = "";
+    }
+
+    public void DeleteProfile()
+    {
+        if (!string.IsNullOrEmpty(SelectedProfile))
+            _configManager.DeleteProfile(SelectedProfile);
+    }
+
+    /// <summary>Replaces the model suggestions with the models available to the entered API key.</summary>
+    public async Task RefreshModelOptionsAsync()
+    {
+        if (IsLoadingModels) return;
+        if (string.IsNullOrWhiteSpace(ApiKey))
+        {
+            ModelListStatus = "Enter an API key to list models.";
+            return;
+        }
+
+        IsLoadingModels = true;
+        ModelListStatus = "Loading models…";
+        try
+        {
+            var models = await _modelLister.ListTranscriptionModelsAsync(ApiKey, _configManager.Current.Transcription);
+            // Keep the typed value selectable even if the account doesn't list it
+            var current = Model;
+            ModelOptions.Clear();
+            foreach (var m in models)
+                ModelOptions.Add(m);
+            if (!string.IsNullOrEmpty(current) && !ModelOptions.Contains(current))
+                ModelOptions.Insert(0, current);
+            Model = current;
+            ModelListStatus = $"{models.Count} transcription model(s) available.";
+        }
+        catch (Exception ex)
+        {
+            ModelListStatus = $"Could not list models: {ex.Message}";
+        }
+        finally
+        {
+            IsLoadingModels = false;
+        }
+    }
+
+    /// <summary>Validates the values on screen without saving them.</summary>
+    public async Task TestAsync()
+    {
+        if (IsTesting) return;
+
+        IsTesting = true;
+        TestResults.Clear();
+        try
+        {
+            var cfg = _configManager.Snapshot();
+            ApplyTo(cfg);
+            foreach (var check in await _validator.TestAsync(cfg))
+                TestResults.Add(check);
+        }
+        finally
+        {
+            HasTestResults = TestResults.Count > 0;
+            IsTesting = false;
+        }
+    }
+
+    public Task SaveDebugBundleAsync(string path) => _debugBundle.WriteAsync(path);
+
+    public Task<WipeResult> WipeDataAsync(bool includeDictionary) => _wiper.WipeAsync(includeDictionary);
+
+    public void ExportConfig(string path) =>
+        File.WriteAllText(path, ConfigTransfer.Export(_configManager.Snapshot()));
+
+    public ConfigImportResult ImportConfig(string path, bool dryRun) =>
+        ConfigTransfer.Import(_configManager, File.ReadAllText(path), dryRun);
+
+    private void ApplyTo(TokenTalkOptions cfg)
+    {
+        cfg.PrimaryHotkey.Combo = Hotkey;
+        cfg.PrimaryHotkey.Mode = HotkeyMode;
+        cfg.PrimaryHotkey.MinHoldMs = MinHoldMs;
+        cfg.Queue.Policy = QueuePolicy;
+        cfg.Queue.TimeoutSeconds = TimeoutSeconds;
+        cfg.Queue.ShutdownWaitSeconds = ShutdownWaitSeconds;
         cfg.Transcription.Provider = Provider;
+        cfg.Transcription.Race = RaceProviders;
         cfg.Transcription.ApiKey = ApiKey;
         cfg.Transcription.Model = Model;
         cfg.Transcription.Language = Language;
@@ -208,13 +489,29 @@ public class SettingsViewModel : ViewModelBase
         cfg.Audio.MaxSeconds = MaxSeconds;
         cfg.Audio.SilenceThreshold = SilenceThreshold;
         cfg.PostProcessing.Commands = Commands;
-        _configManager.Save(cfg);
-
-        SaveSuccess = true;
-        Task.Delay(2000).ContinueWith(_ =>
-        {
-            System.Windows.Application.Current?.Dispatcher.Invoke(() => SaveSuccess = false);
-        });
+        cfg.Output.Mode = OutputMode;
+        cfg.Output.Accumulate = Accumulate;
+        cfg.Output.TypeWhileSpeaking = TypeWhileSpeaking;
+        cfg.Output.Confirm = ConfirmPaste;
+        cfg.Output.SendPhrase = SendPhrase.Trim();
+        cfg.Output.PrePasteDelayMs = PrePasteDelayMs;
+        cfg.Output.PostPasteDelayMs = PostPasteDelayMs;
+        cfg.Output.RestoreDelayMs = RestoreDelayMs;
+        cfg.History.MaxAgeDays = MaxAgeDays;
+        cfg.History.MaxCount = MaxCount;
+        cfg.History.EncryptText = EncryptText;
+        cfg.History.TrashDays = TrashDays;
+        cfg.Goals.DailyWords = DailyWords;
+        cfg.Goals.DailyDictations = DailyDictations;
+        cfg.Goals.NotifyOnGoal = NotifyOnGoal;
+        cfg.Notifications.OnError = NotifyOnError;
+        cfg.Notifications.OnHealthProblem = NotifyOnHealthProblem;
+        cfg.Notifications.OnTranscription = NotifyOnTranscription;
+        cfg.Notifications.Notifiers.RemoveAll(n => string.Equals(n, NotifierNames.Sound, StringComparison.OrdinalIgnoreCase));
+        if (PlaySounds)
+            cfg.Notifications.Notifiers.Add(NotifierNames.Sound);
+        cfg.Updates.CheckForUpdates = CheckForUpdates;
+        cfg.Telemetry.Enabled = ShareUsage;
     }
 
     public async Task DownloadModelAsync(ModelCatalogItem item)
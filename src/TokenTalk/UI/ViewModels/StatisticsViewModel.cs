@@ -3,7 +3,7 @@ using TokenTalk.Storage;
 
 namespace TokenTalk.UI.ViewModels;
 
-public enum StatsTimeRange { Week, Month, Year, AllTime }
+public enum StatsTimeRange { Week, Month, Year, AllTime, Custom }
 
 public class WordCloudItem
 {
@@ -12,6 +12,38 @@ public class WordCloudItem
     public string Color { get; init; } = "#5E5CE6";
 }
 
+public class WpmTrendPoint
+{
+    public string Label { get; init; } = "";
+    public double Wpm { get; init; }
+    public double BarHeight { get; init; }
+    public string ToolTip => $"{Label}: {Wpm:0} WPM";
+}
+
+public class ModelStatsRow
+{
+    public string Name { get; init; } = "";
+    public string DictationsDisplay { get; init; } = "";
+    public string ErrorRateDisplay { get; init; } = "";
+    public string TranscriptionDisplay { get; init; } = "";
+    public string TotalLatencyDisplay { get; init; } = "";
+}
+
+public class ErrorCategoryRow
+{
+    public string Name { get; init; } = "";
+    public string CountDisplay { get; init; } = "";
+    public string LastDisplay { get; init; } = "";
+}
+
+public class RatingStatsRow
+{
+    public string Name { get; init; } = "";
+    public string Pipeline { get; init; } = "";
+    public string VotesDisplay { get; init; } = "";
+    public string ApprovalDisplay { get; init; } = "";
+}
+
 public class StatisticsViewModel : ViewModelBase
 {
     private static readonly string[] Palette =
@@ -19,10 +51,22 @@ public class StatisticsViewModel : ViewModelBase
         "#5E5CE6", "#FF9500", "#34C759", "#FF3B30", "#007AFF", "#AF52DE"
     ];
 
+    private const double MaxBarHeight = 80;
+
     private readonly DictationRepository _repository;
     private StatsTimeRange _selectedRange = StatsTimeRange.Week;
     private bool _isLoading;
     private bool _isEmpty;
+    private string _dictationsDisplay = "—";
+    private string _wordsDisplay = "—";
+    private string _speakingWpmDisplay = "—";
+    private string _effectiveWpmDisplay = "—";
+    private string _peakWpmDisplay = "—";
+    private bool _hasWpmTrend;
+    private bool _hasFailures;
+    private bool _hasRatings;
+    private DateTime? _customFrom = DateTime.Today.AddDays(-6);
+    private DateTime? _customTo = DateTime.Today;
 
     public StatsTimeRange SelectedRange
     {
@@ -35,6 +79,7 @@ public class StatisticsViewModel : ViewModelBase
                 OnPropertyChanged(nameof(IsMonthActive));
                 OnPropertyChanged(nameof(IsYearActive));
                 OnPropertyChanged(nameof(IsAllTimeActive));
+                OnPropertyChanged(nameof(IsCustomActive));
             }
         }
     }
@@ -46,8 +91,26 @@ public class StatisticsViewModel : ViewModelBase
     public bool IsMonthActive => SelectedRange == StatsTimeRange.Month;
     public bool IsYearActive => SelectedRange == StatsTimeRange.Year;
     public bool IsAllTimeActive => SelectedRange == StatsTimeRange.AllTime;
+    public bool IsCustomActive => SelectedRange == StatsTimeRange.Custom;
+
+    // Local calendar dates for the custom range, both inclusive
+    public DateTime? CustomFrom { get => _customFrom; set => SetProperty(ref _customFrom, value); }
+    public DateTime? CustomTo { get => _customTo; set => SetProperty(ref _customTo, value); }
+
+    public string DictationsDisplay { get => _dictationsDisplay; private set => SetProperty(ref _dictationsDisplay, value); }
+    public string WordsDisplay { get => _wordsDisplay; private set => SetProperty(ref _wordsDisplay, value); }
+    public string SpeakingWpmDisplay { get => _speakingWpmDisplay; private set => SetProperty(ref _speakingWpmDisplay, value); }
+    public string EffectiveWpmDisplay { get => _effectiveWpmDisplay; private set => SetProperty(ref _effectiveWpmDisplay, value); }
+    public string PeakWpmDisplay { get => _peakWpmDisplay; private set => SetProperty(ref _peakWpmDisplay, value); }
 
     public ObservableCollection<WordCloudItem> Words { get; } = [];
+    public ObservableCollection<WpmTrendPoint> WpmTrend { get; } = [];
+    public ObservableCollection<ModelStatsRow> Models { get; } = [];
+    public bool HasWpmTrend { get => _hasWpmTrend; private set => SetProperty(ref _hasWpmTrend, value); }
+    public ObservableCollection<ErrorCategoryRow> Failures { get; } = [];
+    public bool HasFailures { get => _hasFailures; private set => SetProperty(ref _hasFailures, value); }
+    public ObservableCollection<RatingStatsRow> Ratings { get; } = [];
+    public bool HasRatings { get => _hasRatings; private set => SetProperty(ref _hasRatings, value); }
 
     public StatisticsViewModel(DictationRepository repository)
     {
@@ -58,17 +121,20 @@ public class StatisticsViewModel : ViewModelBase
     {
         IsLoading = true;
         Words.Clear();
+        WpmTrend.Clear();
+        Models.Clear();
+        Failures.Clear();
+        Ratings.Clear();
         try
         {
-            int? days = SelectedRange switch
-            {
-                StatsTimeRange.Week => 7,
-                StatsTimeRange.Month => 30,
-                StatsTimeRange.Year => 365,
-                _ => null,
-            };
+            var (from, to) = GetRange();
 
-            var entries = await _repository.GetWordFrequenciesAsync(days);
+            await LoadSummaryAsync(from, to);
+            await LoadModelsAsync(from, to);
+            await LoadFailuresAsync(from, to);
+            await LoadRatingsAsync(from, to);
+
+            var entries = await _repository.GetWordFrequenciesAsync(from, to);
 
             if (entries.Count == 0)
             {
@@ -103,4 +169,106 @@ public class StatisticsViewModel : ViewModelBase
             IsLoading = false;
         }
     }
+
+    // UTC bounds for the selected range; custom dates are local days, so the window follows this machine's time zone
+    private (DateTime? From, DateTime? To) GetRange()
+    {
+        int? days = SelectedRange switch
+        {
+            StatsTimeRange.Week => 7,
+            StatsTimeRange.Month => 30,
+            StatsTimeRange.Year => 365,
+            _ => null,
+        };
+        if (days.HasValue)
+            return (DateTime.UtcNow.AddDays(-days.Value), null);
+        if (SelectedRange != StatsTimeRange.Custom)
+            return (null, null);
+
+        var from = CustomFrom?.Date;
+        var to = CustomTo?.Date;
+        if (from > to)
+            (from, to) = (to, from);
+        return (from?.ToUniversalTime(), to?.AddDays(1).ToUniversalTime());
+    }
+
+    private async Task LoadSummaryAsync(DateTime? from, DateTime? to)
+    {
+        var stats = await _repository.GetOverallStatsAsync(from, to);
+        DictationsDisplay = stats.TotalDictations.ToString("N0");
+        WordsDisplay = stats.TotalWords.ToString("N0");
+        SpeakingWpmDisplay = FormatWpm(stats.AvgSpeakingWpm);
+        EffectiveWpmDisplay = FormatWpm(stats.AvgEffectiveWpm);
+        PeakWpmDisplay = FormatWpm(stats.PeakSpeakingWpm);
+
+        // Daily series comes back newest first; the chart reads left to right
+        var daily = (await _repository.GetDailyStatsAsync(from, to))
+            .Where(d => d.SpeakingWpm > 0)
+            .OrderBy(d => d.Date)
+            .TakeLast(60)
+            .ToList();
+        HasWpmTrend = daily.Count > 0;
+        if (daily.Count == 0)
+            return;
+
+        double max = daily.Max(d => d.SpeakingWpm);
+        foreach (var day in daily)
+        {
+            WpmTrend.Add(new WpmTrendPoint
+            {
+                Label = day.Date,
+                Wpm = day.SpeakingWpm,
+                BarHeight = Math.Max(2, day.SpeakingWpm / max * MaxBarHeight),
+            });
+        }
+    }
+
+    private async Task LoadModelsAsync(DateTime? from, DateTime? to)
+    {
+        foreach (var m in await _repository.GetModelStatsAsync(from, to))
+        {
+            Models.Add(new ModelStatsRow
+            {
+                Name = string.IsNullOrEmpty(m.Model) ? m.Provider : $"{m.Provider} · {m.Model}",
+                DictationsDisplay = m.TotalDictations.ToString("N0"),
+                ErrorRateDisplay = $"{m.ErrorRate:P1}",
+                TranscriptionDisplay = $"{m.P50TranscriptionMs:N0} / {m.P90TranscriptionMs:N0} / {m.P95TranscriptionMs:N0} ms",
+                TotalLatencyDisplay = $"{m.P50TotalLatencyMs:N0} / {m.P95TotalLatencyMs:N0} ms",
+            });
+        }
+    }
+
+    private async Task LoadFailuresAsync(DateTime? from, DateTime? to)
+    {
+        var categories = await _repository.GetErrorCategoryStatsAsync(from, to);
+        HasFailures = categories.Count > 0;
+        foreach (var c in categories)
+        {
+            Failures.Add(new ErrorCategoryRow
+            {
+                Name = c.Category.Replace('_', ' '),
+                CountDisplay = c.Count.ToString("N0"),
+                LastDisplay = $"last {c.LastOccurred.ToLocalTime():MMM d, HH:mm}",
+            });
+        }
+    }
+
+    private async Task LoadRatingsAsync(DateTime? from, DateTime? to)
+    {
+        var ratings = await _repository.GetRatingStatsAsync(from, to);
+        HasRatings = ratings.Count > 0;
+        foreach (var r in ratings)
+        {
+            var model = string.IsNullOrEmpty(r.Model) ? r.Provider : $"{r.Provider} · {r.Model}";
+            Ratings.Add(new RatingStatsRow
+            {
+                Name = string.IsNullOrEmpty(r.Language) ? model : $"{model} · {r.Language}",
+                Pipeline = string.IsNullOrEmpty(r.Pipeline) ? "no post-processing" : r.Pipeline,
+                VotesDisplay = $"{r.Up} 👍  {r.Down} 👎",
+                ApprovalDisplay = $"{r.Approval:P0}",
+            });
+        }
+    }
+
+    private static string FormatWpm(double wpm) => wpm > 0 ? $"{wpm:0} WPM" : "—";
 }
@@ -0,0 +1,97 @@
+using System.Net.Http.Headers;
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using TokenTalk.Configuration;
+
+namespace TokenTalk.Updates;
+
+public record UpdateInfo(string Version, string Url);
+
+/// <summary>
+/// Checks the GitHub releases of the project for a newer version shortly after startup and
+/// then daily. Nothing is downloaded; the release page is offered instead so the user installs
+/// it the same way as the first time.
+/// </summary>
+public class UpdateChecker
+{
+    private const string LatestReleaseUrl = "https://api.github.com/repos/markestedt/tokentalk/releases/latest";
+    private static readonly TimeSpan StartupDelay = TimeSpan.FromMinutes(1);
+    private static readonly TimeSpan Interval = TimeSpan.FromHours(24);
+
+    private readonly IHttpClientFactory _httpClientFactory;
+    private readonly Func<UpdateOptions> _getOptions;
+    private readonly ILogger<UpdateChecker> _logger;
+
+    public UpdateInfo? Available { get; private set; }
+
+    // Raised once per newly seen version
+    public event EventHandler<UpdateInfo>? UpdateAvailable;
+
+    public UpdateChecker(IHttpClientFactory httpClientFactory, Func<UpdateOptions> getOptions, ILogger<UpdateChecker> logger)
+    {
+        _httpClientFactory = httpClientFactory;
+        _getOptions = getOptions;
+        _logger = logger;
+    }
+
+    public async Task RunAsync(CancellationToken ct)
+    {
+        using var timer = new PeriodicTimer(Interval);
+        try
+        {
+            await Task.Delay(StartupDelay, ct);
+            do
+            {
+                if (_getOptions().CheckForUpdates)
+                    await CheckAsync(ct);
+            }
+            while (await timer.WaitForNextTickAsync(ct));
+        }
+        catch (OperationCanceledException)
+        {
+        }
+    }
+
+    public async Task<UpdateInfo?> CheckAsync(CancellationToken ct = default)
+    {
+        try
+        {
+            var client = _httpClientFactory.CreateClient("GitHub");
+            using var request = new HttpRequestMessage(HttpMethod.Get, LatestReleaseUrl);
+            // GitHub rejects API requests without a User-Agent
+            request.Headers.UserAgent.Add(new ProductInfoHeaderValue("TokenTalk", BuildInfo.Version));
+            request.Headers.Accept.Add(new MediaTypeWithQualityHeaderValue("application/vnd.github+json"));
+            using var response = await client.SendAsync(request, ct);
+            if (!response.IsSuccessStatusCode)
+            {
+                _logger.LogDebug("Update check returned {Status}", response.StatusCode);
+                return null;
+            }
+
+            using var doc = JsonDocument.Parse(await response.Content.ReadAsStringAsync(ct));
+            var tag = doc.RootElement.GetProperty("tag_name").GetString() ?? "";
+            var url = doc.RootElement.GetProperty("html_url").GetString() ?? "";
+            if (!IsNewer(tag, BuildInfo.Version))
+                return null;
+
+            var update = new UpdateInfo(tag.TrimStart('v', 'V'), url);
+            if (Available?.Version != update.Version)
+            {
+                _logger.LogInformation("Update available: {Version} ({Url})", update.Version, update.Url);
+                Available = update;
+                UpdateAvailable?.Invoke(this, update);
+            }
+            return update;
+        }
+        catch (Exception ex) when (ex is not OperationCanceledException || !ct.IsCancellationRequested)
+        {
+            _logger.LogDebug(ex, "Update check failed");
+            return null;
+        }
+    }
+
+    private static bool IsNewer(string tag, string current) =>
+        System.Version.TryParse(tag.TrimStart('v', 'V').Split('-')[0], out var latest)
+        && System.Version.TryParse(current.Split('-')[0], out var running)
+        && latest > running;
+}